@@ -4,14 +4,20 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
 	"github.com/alexisbeaulieu97/streamy/internal/app/pipeline"
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
 	"github.com/alexisbeaulieu97/streamy/internal/logger"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/planfile"
 	"github.com/alexisbeaulieu97/streamy/internal/tui"
 	validationpkg "github.com/alexisbeaulieu97/streamy/internal/validation"
 )
@@ -21,10 +27,18 @@ type applyOptions struct {
 	DryRun         bool
 	Verbose        bool
 	NonInteractive bool
+	Resume         bool
+	PlanPath       string
+	Plan           *planfile.Plan
+	Vars           map[string]any
+	Selection      engine.Selection
+	WaitForLock    bool
 }
 
 func newApplyCmd(root *rootFlags, app *AppContext) *cobra.Command {
 	opts := applyOptions{}
+	vars := varFlags{}
+	selection := selectionFlags{}
 
 	cmd := &cobra.Command{
 		Use:   "apply",
@@ -34,6 +48,19 @@ func newApplyCmd(root *rootFlags, app *AppContext) *cobra.Command {
 			opts.Verbose = root.verbose
 			opts.NonInteractive = !term.IsTerminal(int(os.Stdout.Fd()))
 
+			overrides, err := vars.resolve()
+			if err != nil {
+				return err
+			}
+			opts.Vars = overrides
+			opts.Selection = selection.selection()
+
+			if opts.PlanPath != "" {
+				if err := loadApplyPlan(&opts); err != nil {
+					return err
+				}
+			}
+
 			if err := validateApplyOptions(opts); err != nil {
 				return err
 			}
@@ -42,20 +69,55 @@ func newApplyCmd(root *rootFlags, app *AppContext) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&opts.ConfigPath, "config", "c", "", "Path to configuration file")
-	cmd.MarkFlagRequired("config") //nolint:errcheck
+	cmd.Flags().StringVarP(&opts.ConfigPath, "config", "c", "", "Path to configuration file (taken from the plan with --plan)")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", false, "Skip steps that completed in the previous, interrupted run")
+	cmd.Flags().BoolVar(&opts.WaitForLock, "wait", false, "Wait for another run of this pipeline to finish instead of failing")
+	cmd.Flags().StringVar(&opts.PlanPath, "plan", "", "Apply a plan saved by 'streamy plan --output'; refused if the config or system changed since")
+	vars.register(cmd)
+	selection.register(cmd)
+	cmd.MarkFlagsMutuallyExclusive("plan", "resume")
+	cmd.MarkFlagsMutuallyExclusive("plan", "var")
+	cmd.MarkFlagsMutuallyExclusive("plan", "var-file")
+	for _, name := range []string{"tags", "skip-tags", "only", "from", "no-deps"} {
+		cmd.MarkFlagsMutuallyExclusive("plan", name)
+	}
 
 	return cmd
 }
 
+// loadApplyPlan reads the saved plan and takes the config path and variables from it.
+func loadApplyPlan(opts *applyOptions) error {
+	plan, err := planfile.Read(opts.PlanPath)
+	if err != nil {
+		return err
+	}
+
+	if opts.ConfigPath != "" {
+		given, err := filepath.Abs(opts.ConfigPath)
+		if err != nil {
+			return fmt.Errorf("resolve config path: %w", err)
+		}
+		if given != plan.ConfigPath {
+			return fmt.Errorf("plan %s was made for %s, not %s", opts.PlanPath, plan.ConfigPath, given)
+		}
+	}
+
+	opts.ConfigPath = plan.ConfigPath
+	opts.Vars = plan.Vars
+	opts.Plan = plan
+	return nil
+}
+
 func runApply(app *AppContext, opts applyOptions) error {
-	ctx := context.Background()
+	// SIGINT/SIGTERM cancel the run; steps in flight stop and the checkpoint keeps what completed.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	service := app.Pipeline
 
-	prepared, err := service.Prepare(opts.ConfigPath)
+	prepared, err := service.PrepareWithOptions(opts.ConfigPath, pipeline.PrepareOptions{Vars: opts.Vars, Selection: opts.Selection})
 	if err != nil {
 		return err
 	}
@@ -70,7 +132,7 @@ func runApply(app *AppContext, opts applyOptions) error {
 		level = "debug"
 	}
 
-	modelState := tui.NewModel(cfg, plan, opts.NonInteractive)
+	modelState := tui.NewModel(cfg, plan, opts.NonInteractive).WithCancel(cancel)
 	interactive := !opts.NonInteractive
 
 	var program *tea.Program
@@ -85,17 +147,48 @@ func runApply(app *AppContext, opts applyOptions) error {
 		}()
 	}
 
-	_, execErr := service.Apply(ctx, pipeline.ApplyRequest{
+	// Step events arrive from executor workers concurrently; serialise updates to the local model.
+	var dispatchMu sync.Mutex
+	dispatch := func(msg tea.Msg) {
+		dispatchMu.Lock()
+		defer dispatchMu.Unlock()
+		dispatchTuiMessage(interactive, program, &modelState, msg)
+	}
+
+	// Journaling is best effort: without a usable home directory the run simply cannot be rolled back.
+	journalDir, _ := defaultJournalDir()
+	checkpointDir, _ := defaultCheckpointDir()
+	if opts.Resume && checkpointDir == "" {
+		return fmt.Errorf("cannot resume: unable to locate the checkpoint directory")
+	}
+
+	outcome, execErr := service.Apply(ctx, pipeline.ApplyRequest{
 		Prepared:        prepared,
 		ConfigPath:      opts.ConfigPath,
 		LoggerOptions:   logger.Options{Level: level, HumanReadable: true},
 		DryRunOverride:  opts.DryRun,
 		VerboseOverride: opts.Verbose,
+		JournalDir:      journalDir,
+		CheckpointDir:   checkpointDir,
+		Resume:          opts.Resume,
+		Plan:            opts.Plan,
+		WaitForLock:     opts.WaitForLock,
+		OnEvent: func(e engine.Event) {
+			if !interactive && e.Type == engine.StepOutput {
+				printStepOutput(e)
+			}
+			if msg := tui.EventMsg(e); msg != nil {
+				dispatch(msg)
+			}
+		},
 		OnStepResult: func(res model.StepResult) {
-			dispatchTuiMessage(interactive, program, &modelState, tui.StepCompleteMsg{Result: res})
+			dispatch(tui.StepCompleteMsg{Result: res})
+		},
+		OnHandlerResult: func(res model.StepResult) {
+			dispatch(tui.HandlerCompleteMsg{Result: res})
 		},
 		OnValidation: func(result validationpkg.ValidationResult) {
-			dispatchTuiMessage(interactive, program, &modelState, tui.ValidationMsg{Passed: result.Passed, Message: result.Message})
+			dispatch(tui.ValidationMsg{Passed: result.Passed, Message: result.Message})
 		},
 	})
 
@@ -111,9 +204,28 @@ func runApply(app *AppContext, opts applyOptions) error {
 		_, _ = fmt.Fprintln(os.Stdout, modelState.View())
 	}
 
+	if outcome != nil && outcome.Resumed > 0 {
+		_, _ = fmt.Fprintf(os.Stdout, "Resumed: %d step(s) completed in the interrupted run were not run again\n", outcome.Resumed)
+	}
+	if execErr != nil && outcome != nil && !opts.DryRun && !cfg.Settings.DryRun {
+		_, _ = fmt.Fprintf(os.Stdout, "Run did not complete; continue it with 'streamy apply --config %s --resume'\n", opts.ConfigPath)
+	}
+	if outcome != nil && outcome.RunID != "" {
+		_, _ = fmt.Fprintf(os.Stdout, "Run %s recorded; undo it with 'streamy rollback %s'\n", outcome.RunID, outcome.RunID)
+	}
+
 	return execErr
 }
 
+// printStepOutput echoes a step's output line to the matching stream when no TUI owns the terminal.
+func printStepOutput(e engine.Event) {
+	out := os.Stdout
+	if e.Stream == "stderr" {
+		out = os.Stderr
+	}
+	_, _ = fmt.Fprintln(out, e.Line)
+}
+
 func dispatchTuiMessage(interactive bool, program *tea.Program, state *tui.Model, msg tea.Msg) {
 	if interactive {
 		if program != nil {
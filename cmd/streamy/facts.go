@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
+)
+
+type factsOptions struct {
+	JSON bool
+}
+
+func newFactsCmd() *cobra.Command {
+	opts := factsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "facts",
+		Short: "Show the host facts available to conditions and templates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFacts(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "Output facts in JSON format")
+
+	return cmd
+}
+
+func runFacts(w io.Writer, opts factsOptions) error {
+	f := facts.Gather(context.Background())
+
+	if opts.JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(f)
+	}
+
+	rows := [][2]string{
+		{"os", f.OS},
+		{"distro", strings.TrimSpace(f.Distro + " " + f.DistroVersion)},
+		{"distro_like", strings.Join(f.DistroLike, ", ")},
+		{"kernel", f.Kernel},
+		{"arch", f.Arch},
+		{"hostname", f.Hostname},
+		{"cpus", fmt.Sprintf("%d", f.CPUs)},
+		{"memory_mb", fmt.Sprintf("%d", f.MemoryBytes/(1024*1024))},
+		{"user", f.User},
+		{"home", f.Home},
+		{"shell", f.Shell},
+		{"package_managers", strings.Join(f.PackageManagers, ", ")},
+	}
+	for _, row := range rows {
+		_, _ = fmt.Fprintf(w, "%-18s %s\n", row[0], row[1])
+	}
+
+	if names := f.ToolNames(); len(names) > 0 {
+		_, _ = fmt.Fprintln(w, "tools:")
+		for _, name := range names {
+			_, _ = fmt.Fprintf(w, "  %-16s %s\n", name, f.Tools[name])
+		}
+	}
+
+	return nil
+}
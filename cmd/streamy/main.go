@@ -7,6 +7,7 @@ import (
 	pipelineapp "github.com/alexisbeaulieu97/streamy/internal/app/pipeline"
 	"github.com/alexisbeaulieu97/streamy/internal/logger"
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+	"github.com/alexisbeaulieu97/streamy/internal/runlock"
 )
 
 func main() {
@@ -28,6 +29,10 @@ func main() {
 		Registry: registry,
 		Pipeline: pipelineapp.NewService(registry),
 	}
+	// Without a home directory there is nowhere to share locks; runs go unguarded.
+	if lockDir, err := defaultLockDir(); err == nil {
+		app.Pipeline.SetRunLocks(runlock.NewManager(lockDir))
+	}
 
 	if err := newRootCmd(app).Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexisbeaulieu97/streamy/internal/app/pipeline"
+	"github.com/alexisbeaulieu97/streamy/internal/logger"
+	"github.com/alexisbeaulieu97/streamy/internal/planfile"
+)
+
+type planOptions struct {
+	ConfigPath string
+	OutputPath string
+	Verbose    bool
+	Vars       map[string]any
+}
+
+func newPlanCmd(root *rootFlags, app *AppContext) *cobra.Command {
+	opts := planOptions{}
+	vars := varFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Evaluate a configuration and save the changes apply would make",
+		Long: `Plan runs every step's read-only evaluation and prints what apply would change.
+With --output the plan is saved as JSON; 'streamy apply --plan <file>' then executes
+exactly that plan and refuses to run if the config or the machine changed since.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Verbose = root.verbose
+
+			if err := validateApplyOptions(applyOptions{ConfigPath: opts.ConfigPath}); err != nil {
+				return err
+			}
+
+			overrides, err := vars.resolve()
+			if err != nil {
+				return err
+			}
+			opts.Vars = overrides
+
+			return runPlan(cmd.Context(), cmd.OutOrStdout(), app, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ConfigPath, "config", "c", "", "Path to configuration file")
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "", "Write the plan as JSON to this file")
+	cmd.MarkFlagRequired("config") //nolint:errcheck
+	vars.register(cmd)
+
+	return cmd
+}
+
+func runPlan(ctx context.Context, out io.Writer, app *AppContext, opts planOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Saved plans are applied later, possibly from another directory.
+	configPath, err := filepath.Abs(opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("resolve config path: %w", err)
+	}
+
+	prepared, err := app.Pipeline.PrepareWithOptions(configPath, pipeline.PrepareOptions{Vars: opts.Vars})
+	if err != nil {
+		return err
+	}
+
+	level := "error"
+	if opts.Verbose {
+		level = "debug"
+	}
+	plan, err := app.Pipeline.Plan(ctx, pipeline.PlanRequest{
+		Prepared:      prepared,
+		LoggerOptions: logger.Options{Level: level, HumanReadable: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	printPlan(out, plan)
+
+	if opts.OutputPath != "" {
+		if err := planfile.Write(opts.OutputPath, plan); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "\nSaved plan to %s; apply it with 'streamy apply --plan %s'\n", opts.OutputPath, opts.OutputPath)
+	}
+	return nil
+}
+
+func printPlan(out io.Writer, plan *planfile.Plan) {
+	for _, step := range plan.Steps {
+		marker := " "
+		if step.RequiresAction {
+			marker = "~"
+		}
+		detail := step.Message
+		if step.SkipReason != "" {
+			detail = step.SkipReason
+		}
+		_, _ = fmt.Fprintf(out, "%s %s [%s] %s\n", marker, step.ID, step.Status, detail)
+		if step.Diff != "" {
+			for _, line := range strings.Split(strings.TrimRight(step.Diff, "\n"), "\n") {
+				_, _ = fmt.Fprintf(out, "    %s\n", line)
+			}
+		}
+	}
+
+	changes := plan.Changes()
+	_, _ = fmt.Fprintf(out, "\nPlan: %d to change, %d unchanged.\n", changes, len(plan.Steps)-changes)
+}
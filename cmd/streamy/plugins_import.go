@@ -8,6 +8,7 @@ import (
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
 	commandplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/command"
 	copyplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/copy"
+	langpackageplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/langpackage"
 	lineinfileplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/lineinfile"
 	packageplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/package"
 	repoplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/repo"
@@ -25,6 +26,7 @@ func RegisterPlugins(registry *plugin.PluginRegistry, log *logger.Logger) error
 	}{
 		{name: "command", factory: commandplugin.New},
 		{name: "copy", factory: copyplugin.New},
+		{name: "lang_package", factory: langpackageplugin.New},
 		{name: "line_in_file", factory: lineinfileplugin.New},
 		{name: "package", factory: packageplugin.New},
 		{name: "repo", factory: repoplugin.New},
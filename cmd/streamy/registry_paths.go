@@ -22,3 +22,30 @@ func defaultStatusCachePath() (string, error) {
 
 	return filepath.Join(home, ".streamy", "status-cache.json"), nil
 }
+
+func defaultJournalDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".streamy", "runs"), nil
+}
+
+func defaultCheckpointDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".streamy", "checkpoints"), nil
+}
+
+func defaultLockDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".streamy", "locks"), nil
+}
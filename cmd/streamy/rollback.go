@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexisbeaulieu97/streamy/internal/journal"
+)
+
+func newRollbackCmd(rootFlags *rootFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback [run-id]",
+		Short: "Undo the changes recorded for an apply run",
+		Long: "Restore files, symlinks and directories changed by an apply run to their previous state.\n" +
+			"Without a run ID the most recent run is rolled back. Use --dry-run to preview the actions.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runID := ""
+			if len(args) == 1 {
+				runID = args[0]
+			}
+			return runRollback(cmd, runID, rootFlags.dryRun)
+		},
+	}
+
+	return cmd
+}
+
+func runRollback(cmd *cobra.Command, runID string, dryRun bool) error {
+	root, err := defaultJournalDir()
+	if err != nil {
+		return newCommandError("rollback", "determining journal directory", err, "Ensure your HOME directory is set correctly.")
+	}
+
+	var run *journal.Run
+	if runID == "" {
+		run, err = journal.Latest(root)
+	} else {
+		run, err = journal.Load(root, runID)
+	}
+	if err != nil {
+		return newCommandError("rollback", "loading run journal", err, "Run 'streamy apply' first, or pass the run ID printed at the end of an apply.")
+	}
+
+	out := cmd.OutOrStdout()
+	verb := "Rolling back"
+	if dryRun {
+		verb = "Would roll back"
+	}
+	_, _ = fmt.Fprintf(out, "%s run %s (%s, started %s)\n", verb, run.ID, run.ConfigPath, run.StartedAt.Format(time.RFC3339))
+
+	actions, rollbackErr := journal.Rollback(root, run, dryRun)
+	for _, action := range actions {
+		marker := "-"
+		switch {
+		case action.Err != nil:
+			marker = "✗"
+		case action.Irreversible:
+			marker = "!"
+		}
+		_, _ = fmt.Fprintf(out, "  %s [%s] %s\n", marker, action.StepID, action.Description)
+		if action.Err != nil {
+			_, _ = fmt.Fprintf(out, "      %v\n", action.Err)
+		}
+	}
+	if len(actions) == 0 {
+		_, _ = fmt.Fprintln(out, "  nothing to undo")
+	}
+
+	if rollbackErr != nil {
+		return newCommandError("rollback", fmt.Sprintf("restoring run %s", run.ID), rollbackErr, "Fix the reported paths and restore them manually; the run is left un-rolled-back so it can be retried.")
+	}
+	return nil
+}
@@ -30,11 +30,15 @@ func newRootCmd(app *AppContext) *cobra.Command {
 	cmd.PersistentFlags().BoolVar(&flags.dryRun, "dry-run", false, "Preview execution without making changes")
 
 	cmd.AddCommand(newApplyCmd(flags, app))
+	cmd.AddCommand(newPlanCmd(flags, app))
 	cmd.AddCommand(newVerifyCmd(flags, app))
 	cmd.AddCommand(newVersionCmd())
+	cmd.AddCommand(newFactsCmd())
 	cmd.AddCommand(newDashboardCmd(app))
 	cmd.AddCommand(newRegistryCmd(flags, app))
 	cmd.AddCommand(newRefreshCmd(flags, app))
+	cmd.AddCommand(newRollbackCmd(flags))
+	cmd.AddCommand(newWatchCmd(flags, app))
 
 	return cmd
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
+)
+
+// selectionFlags collects the flags that limit a run to part of a pipeline.
+type selectionFlags struct {
+	Tags     []string
+	SkipTags []string
+	Only     []string
+	From     string
+	NoDeps   bool
+}
+
+func (f *selectionFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&f.Tags, "tags", nil, "Run only steps with any of these tags (comma-separated or repeated)")
+	cmd.Flags().StringSliceVar(&f.SkipTags, "skip-tags", nil, "Leave out steps with any of these tags, even as dependencies")
+	cmd.Flags().StringSliceVar(&f.Only, "only", nil, "Run only these step IDs (comma-separated or repeated)")
+	cmd.Flags().StringVar(&f.From, "from", "", "Start at this step: run it and every step that depends on it")
+	cmd.Flags().BoolVar(&f.NoDeps, "no-deps", false, "Do not pull in the dependencies of selected steps")
+}
+
+func (f *selectionFlags) selection() engine.Selection {
+	return engine.Selection{
+		Only:     f.Only,
+		Tags:     f.Tags,
+		SkipTags: f.SkipTags,
+		From:     f.From,
+		NoDeps:   f.NoDeps,
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// varFlags collects pipeline variable overrides supplied on the command line.
+type varFlags struct {
+	Vars     []string
+	VarFiles []string
+}
+
+func (f *varFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.Vars, "var", nil, "Set a pipeline variable (key=value); may be repeated")
+	cmd.Flags().StringArrayVar(&f.VarFiles, "var-file", nil, "Load pipeline variables from a YAML file; may be repeated")
+}
+
+// resolve merges var files in order, then individual --var flags, so later values win.
+func (f *varFlags) resolve() (map[string]any, error) {
+	if len(f.Vars) == 0 && len(f.VarFiles) == 0 {
+		return nil, nil
+	}
+
+	vars := make(map[string]any)
+	for _, path := range f.VarFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read var file: %w", err)
+		}
+		var fileVars map[string]any
+		if err := yaml.Unmarshal(data, &fileVars); err != nil {
+			return nil, fmt.Errorf("parse var file %s: %w", path, err)
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+
+	for _, kv := range f.Vars {
+		key, value, ok := strings.Cut(kv, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected key=value", kv)
+		}
+		vars[key] = value
+	}
+
+	return vars, nil
+}
@@ -13,16 +13,21 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/alexisbeaulieu97/streamy/internal/app/pipeline"
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
 	"github.com/alexisbeaulieu97/streamy/internal/logger"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
 	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
 )
 
 type verifyOptions struct {
-	ConfigPath string
-	Verbose    bool
-	JSON       bool
-	Timeout    time.Duration
+	ConfigPath  string
+	Verbose     bool
+	JSON        bool
+	Timeout     time.Duration
+	Parallel    int
+	Vars        map[string]any
+	Selection   engine.Selection
+	WaitForLock bool
 }
 
 var (
@@ -35,6 +40,8 @@ var (
 
 func newVerifyCmd(root *rootFlags, app *AppContext) *cobra.Command {
 	opts := verifyOptions{}
+	vars := varFlags{}
+	selection := selectionFlags{}
 
 	cmd := &cobra.Command{
 		Use:   "verify <config-file>",
@@ -47,12 +54,23 @@ exit code 1 if any changes are needed.`,
 			opts.ConfigPath = args[0]
 			opts.Verbose = root.verbose
 
+			overrides, err := vars.resolve()
+			if err != nil {
+				return err
+			}
+			opts.Vars = overrides
+			opts.Selection = selection.selection()
+
 			return runVerify(app, opts)
 		},
 	}
 
 	cmd.Flags().BoolVar(&opts.JSON, "json", false, "Output results in JSON format")
 	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 30*time.Second, "Default timeout per step; accepts Go duration strings (e.g. 60s)")
+	cmd.Flags().BoolVar(&opts.WaitForLock, "wait", false, "Wait for a running apply of this pipeline to finish instead of failing")
+	cmd.Flags().IntVar(&opts.Parallel, "parallel", 0, "Maximum number of steps to verify concurrently (default: settings.parallel, or 4)")
+	vars.register(cmd)
+	selection.register(cmd)
 
 	return cmd
 }
@@ -69,7 +87,7 @@ func runVerify(app *AppContext, opts verifyOptions) error {
 func runVerifyInternal(app *AppContext, opts verifyOptions) (int, error) {
 	service := app.Pipeline
 
-	prepared, err := service.Prepare(opts.ConfigPath)
+	prepared, err := service.PrepareWithOptions(opts.ConfigPath, pipeline.PrepareOptions{Vars: opts.Vars, Selection: opts.Selection})
 	if err != nil {
 		var parseErr *streamyerrors.ParseError
 		var validationErr *streamyerrors.ValidationError
@@ -108,8 +126,9 @@ func runVerifyInternal(app *AppContext, opts verifyOptions) (int, error) {
 	}
 
 	log.WithFields(map[string]any{
-		"config": opts.ConfigPath,
-		"steps":  len(prepared.Config.Steps),
+		"config":   opts.ConfigPath,
+		"steps":    len(prepared.Config.Steps),
+		"filtered": len(prepared.Excluded),
 	}).Info("Starting verification")
 
 	outcome, verifyErr := service.Verify(ctx, pipeline.VerifyRequest{
@@ -118,6 +137,8 @@ func runVerifyInternal(app *AppContext, opts verifyOptions) (int, error) {
 		Verbose:        opts.Verbose,
 		PerStepTimeout: perStepTimeout,
 		DefaultTimeout: perStepTimeout,
+		Parallel:       opts.Parallel,
+		WaitForLock:    opts.WaitForLock,
 	})
 
 	if verifyErr != nil {
@@ -144,6 +165,7 @@ func runVerifyInternal(app *AppContext, opts verifyOptions) (int, error) {
 		"drifted":   summary.Drifted,
 		"blocked":   summary.Blocked,
 		"unknown":   summary.Unknown,
+		"skipped":   summary.Skipped,
 		"duration":  summary.Duration.String(),
 	}).Info("Verification complete")
 
@@ -173,6 +195,9 @@ func printTableOutput(summary *model.VerificationSummary) {
 		symbol := getStatusSymbol(result.Status)
 		duration := fmt.Sprintf("%.2fs", result.Duration.Seconds())
 		message := truncateString(result.Message, 40)
+		if result.SkipReason != "" {
+			message = truncateString(result.SkipReason, 40)
+		}
 
 		fmt.Printf("%-40s %-12s %-8s %s\n",
 			truncateString(result.StepID, 40),
@@ -191,6 +216,9 @@ func printTableOutput(summary *model.VerificationSummary) {
 	fmt.Printf("  ⚠ Drifted:   %d\n", summary.Drifted)
 	fmt.Printf("  🚫 Blocked:  %d\n", summary.Blocked)
 	fmt.Printf("  ? Unknown:  %d\n", summary.Unknown)
+	if summary.Skipped > 0 {
+		fmt.Printf("  ⊘ Skipped:  %d\n", summary.Skipped)
+	}
 	fmt.Printf("  Duration:  %s\n", summary.Duration.String())
 
 	if summary.AllSatisfied() {
@@ -230,13 +258,14 @@ func printVerboseOutput(summary *model.VerificationSummary) {
 func printJSONOutput(summary *model.VerificationSummary, configPath string) error {
 	// Convert to JSON-friendly format
 	type JSONResult struct {
-		StepID    string  `json:"step_id"`
-		Status    string  `json:"status"`
-		Message   string  `json:"message"`
-		Details   string  `json:"details,omitempty"`
-		Error     string  `json:"error,omitempty"`
-		Duration  float64 `json:"duration_seconds"`
-		Timestamp string  `json:"timestamp"`
+		StepID     string  `json:"step_id"`
+		Status     string  `json:"status"`
+		Message    string  `json:"message"`
+		Details    string  `json:"details,omitempty"`
+		SkipReason string  `json:"skip_reason,omitempty"`
+		Error      string  `json:"error,omitempty"`
+		Duration   float64 `json:"duration_seconds"`
+		Timestamp  string  `json:"timestamp"`
 	}
 
 	type JSONSummary struct {
@@ -246,6 +275,7 @@ func printJSONOutput(summary *model.VerificationSummary, configPath string) erro
 		Drifted    int     `json:"drifted"`
 		Blocked    int     `json:"blocked"`
 		Unknown    int     `json:"unknown"`
+		Skipped    int     `json:"skipped"`
 		Duration   float64 `json:"duration_seconds"`
 	}
 
@@ -264,6 +294,7 @@ func printJSONOutput(summary *model.VerificationSummary, configPath string) erro
 			Drifted:    summary.Drifted,
 			Blocked:    summary.Blocked,
 			Unknown:    summary.Unknown,
+			Skipped:    summary.Skipped,
 			Duration:   summary.Duration.Seconds(),
 		},
 		Results: make([]JSONResult, len(summary.Results)),
@@ -271,12 +302,13 @@ func printJSONOutput(summary *model.VerificationSummary, configPath string) erro
 
 	for i, result := range summary.Results {
 		jsonResult := JSONResult{
-			StepID:    result.StepID,
-			Status:    string(result.Status),
-			Message:   result.Message,
-			Details:   result.Details,
-			Duration:  result.Duration.Seconds(),
-			Timestamp: result.Timestamp.Format(time.RFC3339),
+			StepID:     result.StepID,
+			Status:     string(result.Status),
+			Message:    result.Message,
+			Details:    result.Details,
+			SkipReason: result.SkipReason,
+			Duration:   result.Duration.Seconds(),
+			Timestamp:  result.Timestamp.Format(time.RFC3339),
 		}
 		if result.Error != nil {
 			jsonResult.Error = result.Error.Error()
@@ -305,6 +337,8 @@ func getStatusSymbol(status model.VerificationStatus) string {
 		return "🚫"
 	case model.StatusUnknown:
 		return "?"
+	case model.StatusVerificationSkipped:
+		return "⊘"
 	default:
 		return "?"
 	}
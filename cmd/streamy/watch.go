@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/alexisbeaulieu97/streamy/internal/app/pipeline"
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
+	"github.com/alexisbeaulieu97/streamy/internal/logger"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/watch"
+)
+
+type watchOptions struct {
+	ConfigPath  string
+	DryRun      bool
+	Verbose     bool
+	Vars        map[string]any
+	Debounce    time.Duration
+	Poll        bool
+	WaitForLock bool
+}
+
+func newWatchCmd(root *rootFlags, app *AppContext) *cobra.Command {
+	opts := watchOptions{}
+	vars := varFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Apply a configuration, then re-apply the affected steps whenever its files change",
+		Long: `Watch applies the configuration once, then watches the config file, its includes
+and the sources of copy, template and symlink steps. When they change, only the
+steps affected by the change and the steps depending on them run again.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.DryRun = root.dryRun
+			opts.Verbose = root.verbose
+
+			overrides, err := vars.resolve()
+			if err != nil {
+				return err
+			}
+			opts.Vars = overrides
+
+			return runWatch(app, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ConfigPath, "config", "c", "", "Path to configuration file")
+	cmd.Flags().DurationVar(&opts.Debounce, "debounce", 300*time.Millisecond, "How long files must stay unchanged before re-applying")
+	cmd.Flags().BoolVar(&opts.Poll, "poll", false, "Poll for changes instead of using inotify")
+	cmd.Flags().BoolVar(&opts.WaitForLock, "wait", false, "Wait for another run of this pipeline to finish instead of skipping the change")
+	vars.register(cmd)
+	_ = cmd.MarkFlagRequired("config")
+
+	return cmd
+}
+
+func runWatch(app *AppContext, opts watchOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	service := app.Pipeline
+	view := newWatchView(os.Stdout, term.IsTerminal(int(os.Stdout.Fd())))
+
+	prepared, err := service.PrepareWithOptions(opts.ConfigPath, pipeline.PrepareOptions{Vars: opts.Vars})
+	if err != nil {
+		return err
+	}
+	current := prepared.Config
+
+	steps := 0
+	for _, level := range prepared.Plan.Levels {
+		steps += len(level.StepIDs)
+	}
+	// last keeps each step's latest result, so re-runs can reuse the outputs of steps they skip.
+	last := make(map[string]model.StepResult)
+	view.runStarted("initial apply", steps)
+	watchApply(ctx, service, prepared, nil, last, opts, view)
+
+	var watcher *watch.Watcher
+	var watched []string
+	defer func() {
+		if watcher != nil {
+			_ = watcher.Close()
+		}
+	}()
+
+	for {
+		announce := view.live
+		// Includes and step sources can come and go with each edit of the config.
+		if paths := watch.Paths(current); watcher == nil || !slices.Equal(paths, watched) {
+			if watcher != nil {
+				_ = watcher.Close()
+			}
+			watcher, err = watch.New(paths, watch.Options{Debounce: opts.Debounce, Poll: opts.Poll})
+			if err != nil {
+				return err
+			}
+			watched = paths
+			announce = true
+		}
+		if announce {
+			view.watching(len(watched), watcher.Mode())
+		}
+
+		var changed []string
+		select {
+		case <-ctx.Done():
+			view.stopped()
+			return nil
+		case changed = <-watcher.Changes():
+		}
+
+		next, err := service.PrepareWithOptions(opts.ConfigPath, pipeline.PrepareOptions{Vars: opts.Vars})
+		if err != nil {
+			view.message(fmt.Sprintf("configuration error, keeping the previous one: %v", err))
+			continue
+		}
+
+		steps := next.Graph.Downstream(watch.ChangedSteps(current, next.Config, changed)...)
+		current = next.Config
+		if len(steps) == 0 {
+			view.message(fmt.Sprintf("%s changed; no steps affected", describeChanges(changed)))
+			continue
+		}
+
+		selected, err := service.PrepareWithOptions(opts.ConfigPath, pipeline.PrepareOptions{
+			Vars:      opts.Vars,
+			Selection: engine.Selection{Only: steps, NoDeps: true},
+		})
+		if err != nil {
+			view.message(fmt.Sprintf("configuration error: %v", err))
+			continue
+		}
+
+		// Unchanged steps registering outputs the affected steps read are kept in the selection;
+		// they report their last result instead of running again.
+		carried := watch.Carried(selected.Plan, steps, last)
+		view.runStarted(describeChanges(changed), len(steps)+len(carried))
+		watchApply(ctx, service, selected, carried, last, opts, view)
+	}
+}
+
+// watchApply applies prepared, reporting progress to view and recording step results in last.
+// Failures are shown rather than returned so watching carries on.
+func watchApply(ctx context.Context, service *pipeline.Service, prepared *pipeline.PreparedPipeline, carried, last map[string]model.StepResult, opts watchOptions, view *watchView) {
+	level := "error"
+	if opts.Verbose {
+		level = "debug"
+	}
+
+	started := time.Now()
+	outcome, err := service.Apply(ctx, pipeline.ApplyRequest{
+		Prepared:        prepared,
+		ConfigPath:      opts.ConfigPath,
+		LoggerOptions:   logger.Options{Level: level, HumanReadable: true},
+		DryRunOverride:  opts.DryRun,
+		VerboseOverride: opts.Verbose,
+		WaitForLock:     opts.WaitForLock,
+		Carry:           carried,
+		OnEvent:         view.event,
+		OnStepResult:    view.stepDone,
+		OnHandlerResult: view.stepDone,
+	})
+	if outcome != nil {
+		for _, res := range outcome.Results {
+			last[res.StepID] = res
+		}
+	}
+	view.runFinished(err, time.Since(started))
+}
+
+// describeChanges names the changed files relative to the working directory, e.g. "zshrc and 2 more".
+func describeChanges(paths []string) string {
+	if len(paths) == 0 {
+		return "nothing"
+	}
+	name := paths[0]
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	if len(paths) > 1 {
+		return fmt.Sprintf("%s and %d more", name, len(paths)-1)
+	}
+	return name
+}
+
+// watchView prints a compact log of watch runs: one line per run and per finished step, plus a
+// status line rewritten in place on terminals while steps are running.
+type watchView struct {
+	mu      sync.Mutex
+	out     io.Writer
+	live    bool
+	total   int
+	done    int
+	ok      int
+	failed  int
+	running []string
+	status  bool
+}
+
+func newWatchView(out io.Writer, live bool) *watchView {
+	return &watchView{out: out, live: live}
+}
+
+func (v *watchView) runStarted(reason string, steps int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.total, v.done, v.ok, v.failed, v.running = steps, 0, 0, 0, nil
+	v.clearStatus()
+	_, _ = fmt.Fprintf(v.out, "%s  %s: running %d step(s)\n", time.Now().Format("15:04:05"), reason, steps)
+}
+
+func (v *watchView) event(e engine.Event) {
+	if e.Type != engine.StepStarted {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.running = append(v.running, e.StepID)
+	v.drawStatus()
+}
+
+func (v *watchView) stepDone(res model.StepResult) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.running = slices.DeleteFunc(v.running, func(id string) bool { return id == res.StepID })
+	v.done++
+	symbol := "✔"
+	switch res.Status {
+	case model.StatusFailed, model.StatusFailedIgnored:
+		symbol = "✖"
+		v.failed++
+	case model.StatusSkipped:
+		symbol = "⊘"
+	default:
+		v.ok++
+	}
+	message := res.Message
+	if res.Error != nil {
+		message = res.Error.Error()
+	}
+	v.clearStatus()
+	_, _ = fmt.Fprintf(v.out, "  %s %-30s %6.2fs  %s\n", symbol, res.StepID, res.Duration.Seconds(), truncateString(message, 60))
+	v.drawStatus()
+}
+
+func (v *watchView) runFinished(err error, elapsed time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.clearStatus()
+	if err != nil && v.failed == 0 {
+		_, _ = fmt.Fprintf(v.out, "  ✖ %v\n", err)
+	}
+	_, _ = fmt.Fprintf(v.out, "  %d ok, %d failed in %.1fs\n", v.ok, v.failed, elapsed.Seconds())
+}
+
+func (v *watchView) watching(paths int, mode string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.clearStatus()
+	if v.live {
+		_, _ = fmt.Fprintf(v.out, "watching %d path(s) with %s; Ctrl+C to stop", paths, mode)
+		v.status = true
+		return
+	}
+	_, _ = fmt.Fprintf(v.out, "watching %d path(s) with %s\n", paths, mode)
+}
+
+func (v *watchView) message(msg string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.clearStatus()
+	_, _ = fmt.Fprintf(v.out, "%s  %s\n", time.Now().Format("15:04:05"), msg)
+}
+
+func (v *watchView) stopped() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.clearStatus()
+	_, _ = fmt.Fprintln(v.out, "stopped watching")
+}
+
+// drawStatus shows the steps in flight on the last line; only terminals get it.
+func (v *watchView) drawStatus() {
+	if !v.live || len(v.running) == 0 {
+		return
+	}
+	v.clearStatus()
+	_, _ = fmt.Fprintf(v.out, "  … %d/%d running %s", v.done, v.total, truncateString(strings.Join(v.running, ", "), 60))
+	v.status = true
+}
+
+func (v *watchView) clearStatus() {
+	if v.status {
+		_, _ = fmt.Fprint(v.out, "\r\033[K")
+		v.status = false
+	}
+}
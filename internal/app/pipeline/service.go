@@ -6,16 +6,23 @@ import (
 	"time"
 
 	"github.com/alexisbeaulieu97/streamy/internal/domain/pipeline"
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
+	"github.com/alexisbeaulieu97/streamy/internal/hooks"
 	"github.com/alexisbeaulieu97/streamy/internal/logger"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/planfile"
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
 	"github.com/alexisbeaulieu97/streamy/internal/registry"
+	"github.com/alexisbeaulieu97/streamy/internal/runlock"
 	"github.com/alexisbeaulieu97/streamy/internal/validation"
 )
 
 // Re-export domain types to preserve the public API for callers within the app layer.
 type PreparedPipeline = pipeline.PreparedPipeline
 
+// PrepareOptions customises how a pipeline is loaded.
+type PrepareOptions = pipeline.PrepareOptions
+
 // Service coordinates high-level pipeline operations and adapts domain results for registry/TUI consumers.
 type Service struct {
 	domain *pipeline.Service
@@ -33,6 +40,26 @@ func (s *Service) Prepare(configPath string) (*PreparedPipeline, error) {
 	return s.domain.Prepare(configPath)
 }
 
+// PrepareWithOptions loads configuration with variable overrides applied.
+func (s *Service) PrepareWithOptions(configPath string, opts PrepareOptions) (*PreparedPipeline, error) {
+	return s.domain.PrepareWithOptions(configPath, opts)
+}
+
+// Hooks returns the registry of Go functions configurations can run as lifecycle hooks.
+func (s *Service) Hooks() *hooks.Registry {
+	return s.domain.Hooks()
+}
+
+// SetRunLocks enables pipeline run locks kept by locks.
+func (s *Service) SetRunLocks(locks *runlock.Manager) {
+	s.domain.SetRunLocks(locks)
+}
+
+// LockHolders lists the processes currently holding the run lock of the pipeline at configPath.
+func (s *Service) LockHolders(configPath string) ([]runlock.Holder, error) {
+	return s.domain.LockHolders(configPath)
+}
+
 // VerifyRequest configures a verification run (app-level).
 type VerifyRequest struct {
 	Prepared       *PreparedPipeline
@@ -41,6 +68,8 @@ type VerifyRequest struct {
 	Verbose        bool
 	PerStepTimeout time.Duration
 	DefaultTimeout time.Duration
+	Parallel       int
+	WaitForLock    bool
 }
 
 // VerifyOutcome returns verification details along with registry execution metadata.
@@ -64,6 +93,8 @@ func (s *Service) Verify(ctx context.Context, req VerifyRequest) (*VerifyOutcome
 		Verbose:        req.Verbose,
 		PerStepTimeout: req.PerStepTimeout,
 		DefaultTimeout: req.DefaultTimeout,
+		Parallel:       req.Parallel,
+		WaitForLock:    req.WaitForLock,
 	})
 
 	if domainOutcome == nil {
@@ -98,6 +129,27 @@ func (s *Service) Verify(ctx context.Context, req VerifyRequest) (*VerifyOutcome
 	return outcome, nil
 }
 
+// PlanRequest configures a plan run (app-level).
+type PlanRequest struct {
+	Prepared      *PreparedPipeline
+	ConfigPath    string
+	LoggerOptions logger.Options
+}
+
+// Plan evaluates every step without changing anything and returns the result as a saved plan.
+func (s *Service) Plan(ctx context.Context, req PlanRequest) (*planfile.Plan, error) {
+	log, err := logger.New(req.LoggerOptions)
+	if err != nil {
+		return nil, fmt.Errorf("create logger: %w", err)
+	}
+
+	return s.domain.Plan(ctx, pipeline.PlanRequest{
+		Prepared:   req.Prepared,
+		ConfigPath: req.ConfigPath,
+		Logger:     log,
+	})
+}
+
 // ApplyRequest configures an apply run (app-level).
 type ApplyRequest struct {
 	Prepared        *PreparedPipeline
@@ -107,15 +159,26 @@ type ApplyRequest struct {
 	VerboseOverride bool
 	ContinueOnError bool
 	OnStepResult    func(model.StepResult)
+	OnHandlerResult func(model.StepResult)
 	OnValidation    func(validation.ValidationResult)
+	OnEvent         func(engine.Event)
+	JournalDir      string
+	CheckpointDir   string
+	Resume          bool
+	Carry           map[string]model.StepResult
+	Plan            *planfile.Plan
+	WaitForLock     bool
 }
 
 // ApplyOutcome captures app-level apply execution details.
 type ApplyOutcome struct {
 	Prepared          *PreparedPipeline
 	Results           []model.StepResult
+	HandlerResults    []model.StepResult
 	ValidationResults []validation.ValidationResult
 	ExecutionResult   *registry.ExecutionResult
+	RunID             string
+	Resumed           int
 }
 
 // Apply executes a pipeline apply operation, returning step results, validation data, and a registry execution summary.
@@ -133,7 +196,15 @@ func (s *Service) Apply(ctx context.Context, req ApplyRequest) (*ApplyOutcome, e
 		VerboseOverride: req.VerboseOverride,
 		ContinueOnError: req.ContinueOnError,
 		OnStepResult:    req.OnStepResult,
+		OnHandlerResult: req.OnHandlerResult,
 		OnValidation:    req.OnValidation,
+		OnEvent:         req.OnEvent,
+		JournalDir:      req.JournalDir,
+		CheckpointDir:   req.CheckpointDir,
+		Resume:          req.Resume,
+		Carry:           req.Carry,
+		Plan:            req.Plan,
+		WaitForLock:     req.WaitForLock,
 	})
 	if domainOutcome == nil {
 		return nil, applyErr
@@ -142,10 +213,19 @@ func (s *Service) Apply(ctx context.Context, req ApplyRequest) (*ApplyOutcome, e
 	outcome := &ApplyOutcome{
 		Prepared:          domainOutcome.Prepared,
 		Results:           domainOutcome.Results,
+		HandlerResults:    domainOutcome.HandlerResults,
 		ValidationResults: domainOutcome.ValidationResults,
+		RunID:             domainOutcome.RunID,
+		Resumed:           domainOutcome.Resumed,
 	}
 
 	outcome.ExecutionResult = convertApplyResults(domainOutcome.Results, domainOutcome.Prepared.Path, domainOutcome.ExecutionErr, domainOutcome.ValidationErr)
+	for _, res := range domainOutcome.HandlerResults {
+		outcome.ExecutionResult.HandlerResults = append(outcome.ExecutionResult.HandlerResults, convertStepResult(res, domainOutcome.Prepared.Path))
+		if res.Status == model.StatusFailed {
+			outcome.ExecutionResult.FailedSteps = append(outcome.ExecutionResult.FailedSteps, res.StepID)
+		}
+	}
 
 	// Guard against nil Prepared/Config to avoid nil deref
 	if domainOutcome.Prepared != nil && domainOutcome.Prepared.Config != nil {
@@ -194,10 +274,11 @@ func convertVerificationSummary(summary *model.VerificationSummary, configPath s
 	var failed []string
 	for _, r := range summary.Results {
 		stepResult := registry.StepResult{
-			StepID:   r.StepID,
-			Status:   string(r.Status),
-			Message:  r.Message,
-			Duration: r.Duration,
+			StepID:     r.StepID,
+			Status:     string(r.Status),
+			Message:    r.Message,
+			SkipReason: r.SkipReason,
+			Duration:   r.Duration,
 		}
 		if r.Error != nil {
 			stepResult.Error = &registry.ErrorDetail{
@@ -222,6 +303,8 @@ func convertVerificationSummary(summary *model.VerificationSummary, configPath s
 		result.Summary = fmt.Sprintf("%d steps need changes", summary.Missing+summary.Drifted)
 	case summary.Blocked > 0 || summary.Unknown > 0:
 		result.Summary = fmt.Sprintf("%d steps failed or unknown", summary.Blocked+summary.Unknown)
+	case summary.Skipped > 0:
+		result.Summary = fmt.Sprintf("All %d steps passed (%d skipped by condition)", summary.TotalSteps, summary.Skipped)
 	default:
 		result.Summary = fmt.Sprintf("All %d steps passed", summary.TotalSteps)
 	}
@@ -248,23 +331,17 @@ func convertApplyResults(results []model.StepResult, configPath string, execErr,
 
 	var totalDuration time.Duration
 	var failed []string
+	ignored := 0
 	for _, res := range results {
-		stepResult := registry.StepResult{
-			StepID:   res.StepID,
-			Status:   res.Status,
-			Message:  res.Message,
-			Duration: res.Duration,
-		}
-
+		stepResult := convertStepResult(res, configPath)
 		totalDuration += res.Duration
 
-		if res.Error != nil {
-			stepResult.Error = &registry.ErrorDetail{
-				Message: res.Error.Error(),
-				Context: fmt.Sprintf("Config: %s, Step: %s", configPath, res.StepID),
-			}
+		if res.Error != nil && res.Status != model.StatusFailedIgnored {
 			failed = append(failed, res.StepID)
 		}
+		if res.Status == model.StatusFailedIgnored {
+			ignored++
+		}
 		if res.Status == model.StatusFailed {
 			failed = append(failed, res.StepID)
 		}
@@ -295,6 +372,8 @@ func convertApplyResults(results []model.StepResult, configPath string, execErr,
 		} else if len(execResult.FailedSteps) > 0 {
 			execResult.Summary = fmt.Sprintf("%d steps failed", len(execResult.FailedSteps))
 		}
+	} else if ignored > 0 {
+		execResult.Summary = fmt.Sprintf("%d steps applied successfully, %d optional steps failed", len(results)-ignored, ignored)
 	} else {
 		execResult.Summary = fmt.Sprintf("All %d steps applied successfully", len(results))
 	}
@@ -302,6 +381,30 @@ func convertApplyResults(results []model.StepResult, configPath string, execErr,
 	return execResult
 }
 
+// convertStepResult maps an apply step (or handler) result onto its registry form.
+func convertStepResult(res model.StepResult, configPath string) registry.StepResult {
+	stepResult := registry.StepResult{
+		StepID:     res.StepID,
+		Status:     res.Status,
+		Message:    res.Message,
+		SkipReason: res.SkipReason,
+		Duration:   res.Duration,
+	}
+	if attempts := res.AttemptCount(); attempts > 1 {
+		stepResult.Attempts = attempts
+		for _, attempt := range res.Attempts[:attempts-1] {
+			stepResult.RetryErrors = append(stepResult.RetryErrors, attempt.Message)
+		}
+	}
+	if res.Error != nil {
+		stepResult.Error = &registry.ErrorDetail{
+			Message: res.Error.Error(),
+			Context: fmt.Sprintf("Config: %s, Step: %s", configPath, res.StepID),
+		}
+	}
+	return stepResult
+}
+
 func pipelineStatusFromSummary(summary *model.VerificationSummary) registry.PipelineStatus {
 	switch {
 	case summary == nil:
@@ -183,6 +183,25 @@ func TestConvertApplyResults(t *testing.T) {
 		assert.Equal(t, "invalid schema", result.Error.Message)
 	})
 
+	t.Run("retried step reports attempts", func(t *testing.T) {
+		results := []model.StepResult{
+			{
+				StepID: "clone",
+				Status: "success",
+				Attempts: []model.Attempt{
+					{Number: 1, Status: "failed", Message: "connection reset"},
+					{Number: 2, Status: "success", Message: "cloned"},
+				},
+			},
+		}
+
+		result := convertApplyResults(results, "/test/config.yaml", nil, nil)
+
+		require.Len(t, result.StepResults, 1)
+		assert.Equal(t, 2, result.StepResults[0].Attempts)
+		assert.Equal(t, []string{"connection reset"}, result.StepResults[0].RetryErrors)
+	})
+
 	t.Run("empty results", func(t *testing.T) {
 		results := []model.StepResult{}
 		configPath := "/test/config.yaml"
@@ -0,0 +1,234 @@
+// Package checkpoint persists which steps of an apply run have completed so an interrupted run
+// can be resumed without evaluating finished steps again.
+package checkpoint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+)
+
+// Checkpoint is the completion state of the latest apply run of one pipeline.
+type Checkpoint struct {
+	ConfigPath string `json:"config_path"`
+	// ConfigHash fingerprints the resolved config; a mismatch on resume means the config was edited.
+	ConfigHash string               `json:"config_hash"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	Steps      map[string]StepState `json:"steps"`
+}
+
+// StepState records a step that completed, together with the inputs it completed with.
+type StepState struct {
+	Hash       string         `json:"hash"`
+	Status     string         `json:"status"`
+	Outputs    map[string]any `json:"outputs,omitempty"`
+	FinishedAt time.Time      `json:"finished_at"`
+}
+
+// New returns an empty checkpoint for a run of cfg.
+func New(configPath string, cfg *config.Config) *Checkpoint {
+	return &Checkpoint{
+		ConfigPath: configPath,
+		ConfigHash: cfg.Hash(),
+		Steps:      make(map[string]StepState),
+	}
+}
+
+// Resumable returns the steps that need not run again: those that completed in the checkpointed
+// run with unchanged inputs and whose dependencies are all resumable too. Each result carries the
+// step's recorded outputs so dependents can still read them.
+func (c *Checkpoint) Resumable(steps []config.Step) map[string]model.StepResult {
+	lookup := config.StepMap(steps)
+	memo := make(map[string]bool, len(steps))
+
+	var resumable func(id string) bool
+	resumable = func(id string) bool {
+		if ok, seen := memo[id]; seen {
+			return ok
+		}
+		// Guard against cycles; validated configs have none.
+		memo[id] = false
+
+		step, ok := lookup[id]
+		if !ok {
+			return false
+		}
+		state, ok := c.Steps[id]
+		if !ok || state.Hash != step.Hash() {
+			return false
+		}
+		for _, dep := range step.DependsOn {
+			if !resumable(dep) {
+				return false
+			}
+		}
+		memo[id] = true
+		return true
+	}
+
+	results := make(map[string]model.StepResult)
+	for i := range steps {
+		id := steps[i].ID
+		if resumable(id) {
+			state := c.Steps[id]
+			results[id] = model.StepResult{StepID: id, Status: state.Status, Outputs: state.Outputs, Timestamp: state.FinishedAt}
+		}
+	}
+	return results
+}
+
+// Completed reports whether a step result counts as done for resume purposes. Steps skipped by a
+// condition are left out so their condition is evaluated again.
+func Completed(res model.StepResult) bool {
+	switch res.Status {
+	case model.StatusSuccess:
+		return true
+	case model.StatusSkipped:
+		return res.SkipReason == ""
+	default:
+		return false
+	}
+}
+
+// Store keeps one checkpoint file per pipeline in a directory.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store rooted at dir; the directory is created on first save.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Load returns the checkpoint for a pipeline, or nil when there is none.
+func (s *Store) Load(configPath string) (*Checkpoint, error) {
+	path, err := s.path(configPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	if cp.Steps == nil {
+		cp.Steps = make(map[string]StepState)
+	}
+	return &cp, nil
+}
+
+// Save writes a checkpoint atomically.
+func (s *Store) Save(cp *Checkpoint) error {
+	path, err := s.path(cp.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes a pipeline's checkpoint; a missing checkpoint is not an error.
+func (s *Store) Remove(configPath string) error {
+	path, err := s.path(configPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// path names checkpoint files after the absolute config path so one pipeline maps to one file.
+func (s *Store) path(configPath string) (string, error) {
+	abs, err := filepath.Abs(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", configPath, err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// Recorder updates a checkpoint as steps finish. It is safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex
+	store  *Store
+	cp     *Checkpoint
+	hashes map[string]string
+}
+
+// NewRecorder starts a fresh checkpoint for a run of cfg, replacing any previous one.
+func NewRecorder(store *Store, configPath string, cfg *config.Config) (*Recorder, error) {
+	hashes := make(map[string]string, len(cfg.Steps))
+	for i := range cfg.Steps {
+		hashes[cfg.Steps[i].ID] = cfg.Steps[i].Hash()
+	}
+	r := &Recorder{store: store, cp: New(configPath, cfg), hashes: hashes}
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Record stores the outcome of a finished step. Steps that did not complete are dropped so a
+// resumed run tries them again.
+func (r *Recorder) Record(res model.StepResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if Completed(res) {
+		r.cp.Steps[res.StepID] = StepState{
+			Hash:       r.hashes[res.StepID],
+			Status:     res.Status,
+			Outputs:    res.Outputs,
+			FinishedAt: res.Timestamp,
+		}
+	} else {
+		delete(r.cp.Steps, res.StepID)
+	}
+	return r.save()
+}
+
+// Discard removes the checkpoint once the run has completed and there is nothing to resume.
+func (r *Recorder) Discard() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.Remove(r.cp.ConfigPath)
+}
+
+// save must be called with r.mu held, or before the recorder is shared.
+func (r *Recorder) save() error {
+	r.cp.UpdatedAt = time.Now()
+	return r.store.Save(r.cp)
+}
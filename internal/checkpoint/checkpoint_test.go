@@ -0,0 +1,68 @@
+package checkpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+)
+
+func commandStep(t *testing.T, id, command string, deps ...string) config.Step {
+	t.Helper()
+	step := config.Step{ID: id, Type: "command", DependsOn: deps}
+	require.NoError(t, step.SetConfig(config.CommandStep{Command: command}))
+	return step
+}
+
+func TestRecorder_ResumesCompletedSteps(t *testing.T) {
+	t.Parallel()
+
+	steps := []config.Step{
+		commandStep(t, "install", "echo install"),
+		commandStep(t, "configure", "echo configure", "install"),
+		commandStep(t, "optional", "echo optional"),
+		commandStep(t, "broken", "false"),
+		commandStep(t, "after_broken", "echo after", "broken"),
+	}
+
+	cfg := &config.Config{Steps: steps}
+	store := NewStore(t.TempDir())
+	recorder, err := NewRecorder(store, "/configs/streamy.yaml", cfg)
+	require.NoError(t, err)
+	require.NoError(t, recorder.Record(model.StepResult{StepID: "install", Status: model.StatusSuccess, Outputs: map[string]any{"version": "1.2"}}))
+	require.NoError(t, recorder.Record(model.StepResult{StepID: "configure", Status: model.StatusSkipped}))
+	require.NoError(t, recorder.Record(model.StepResult{StepID: "optional", Status: model.StatusSkipped, SkipReason: "condition false"}))
+	require.NoError(t, recorder.Record(model.StepResult{StepID: "broken", Status: model.StatusFailed}))
+
+	cp, err := store.Load("/configs/streamy.yaml")
+	require.NoError(t, err)
+	require.Equal(t, cfg.Hash(), cp.ConfigHash)
+
+	resumable := cp.Resumable(steps)
+	require.Len(t, resumable, 2)
+	require.Equal(t, map[string]any{"version": "1.2"}, resumable["install"].Outputs)
+	require.Contains(t, resumable, "configure")
+
+	t.Run("changed inputs invalidate the step and its dependents", func(t *testing.T) {
+		t.Parallel()
+
+		edited := append([]config.Step(nil), steps...)
+		edited[0] = commandStep(t, "install", "echo install --force")
+		require.NotEqual(t, cp.ConfigHash, (&config.Config{Steps: edited}).Hash())
+		require.Empty(t, cp.Resumable(edited))
+	})
+
+	t.Run("discard removes the checkpoint", func(t *testing.T) {
+		t.Parallel()
+
+		other := NewStore(t.TempDir())
+		r, err := NewRecorder(other, "/configs/streamy.yaml", cfg)
+		require.NoError(t, err)
+		require.NoError(t, r.Discard())
+		missing, err := other.Load("/configs/streamy.yaml")
+		require.NoError(t, err)
+		require.Nil(t, missing)
+	})
+}
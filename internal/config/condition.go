@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/alexisbeaulieu97/streamy/internal/expr"
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// Policies applied to a step when one of its dependencies was skipped because its condition was false.
+const (
+	// SkippedDependencySkip skips the dependent step as well. This is the default.
+	SkippedDependencySkip = "skip"
+	// SkippedDependencyRun runs the dependent step as if the dependency had succeeded.
+	SkippedDependencyRun = "run"
+	// SkippedDependencyFail fails the dependent step.
+	SkippedDependencyFail = "fail"
+)
+
+// conditionRoots lists the reference roots available to `when` expressions.
+var conditionRoots = map[string]struct{}{
+	"facts":   {},
+	"env":     {},
+	"vars":    {},
+	"outputs": {},
+}
+
+// SkippedDependencyPolicy returns the configured policy, defaulting to SkippedDependencySkip.
+func (s Settings) SkippedDependencyPolicy() string {
+	if s.OnSkippedDependency == "" {
+		return SkippedDependencySkip
+	}
+	return s.OnSkippedDependency
+}
+
+// Condition compiles the step's `when` expression. It returns nil when the step is unconditional.
+func (s *Step) Condition() (*expr.Expression, error) {
+	if s == nil || s.When == "" {
+		return nil, nil
+	}
+	return expr.Compile(s.When)
+}
+
+// validateCondition checks the step's `when` expression, reporting problems against field.
+func validateCondition(field string, step Step) error {
+	condition, err := step.Condition()
+	if err != nil {
+		return streamyerrors.NewValidationError(field, err.Error(), err)
+	}
+	if condition == nil {
+		return nil
+	}
+
+	for _, ref := range condition.References() {
+		if _, ok := conditionRoots[expr.Root(ref)]; !ok {
+			return streamyerrors.NewValidationError(field, fmt.Sprintf("unknown reference %q; conditions may use facts, env, vars and outputs", ref), nil)
+		}
+	}
+
+	funcs := facts.Functions()
+	for _, name := range condition.Calls() {
+		if _, ok := funcs[name]; !ok {
+			return streamyerrors.NewValidationError(field, fmt.Sprintf("unknown function %q", name), nil)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func TestParseConfig_Conditions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		when    string
+		wantErr string
+	}{
+		{name: "valid condition", when: `facts.distro == "ubuntu" && has_command("apt-get")`},
+		{name: "syntax error", when: `facts.distro ==`, wantErr: "invalid expression"},
+		{name: "unknown root", when: `host.name == "x"`, wantErr: `unknown reference "host.name"`},
+		{name: "unknown function", when: `has_package("git")`, wantErr: `unknown function "has_package"`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Conditions"
+steps:
+  - id: conditional
+    type: command
+    when: '`+tc.when+`'
+    command: "echo"
+`)
+
+			cfg, err := ParseConfig(path)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.when, cfg.Steps[0].When)
+				_, hasWhen := cfg.Steps[0].rawConfig["when"]
+				require.False(t, hasWhen)
+				return
+			}
+
+			var validationErr *streamyerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			require.Equal(t, "steps[0].when", validationErr.Field)
+			require.Contains(t, validationErr.Message, tc.wantErr)
+		})
+	}
+}
+
+func TestSettings_SkippedDependencyPolicy(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, SkippedDependencySkip, Settings{}.SkippedDependencyPolicy())
+	require.Equal(t, SkippedDependencyFail, Settings{OnSkippedDependency: "fail"}.SkippedDependencyPolicy())
+}
@@ -13,8 +13,16 @@ func ValidateConfig(cfg *Config) error {
 		return streamyerrors.NewValidationError("config", "configuration is nil", nil)
 	}
 
+	// IDs generated by includes and loops are checked as step references by ValidateStep.
+	var generatedIDs []string
+	for i, step := range cfg.Steps {
+		if step.generated {
+			generatedIDs = append(generatedIDs, fmt.Sprintf("Steps[%d].ID", i))
+		}
+	}
+
 	v := validatorInstance()
-	if err := v.Struct(cfg); err != nil {
+	if err := v.StructExcept(cfg, generatedIDs...); err != nil {
 		return convertValidationError(err)
 	}
 
@@ -22,26 +30,28 @@ func ValidateConfig(cfg *Config) error {
 
 	for i, step := range cfg.Steps {
 		if _, exists := stepIndex[step.ID]; exists {
-			return streamyerrors.NewValidationError(fieldForStep(i, "id"), fmt.Sprintf("duplicate step id %q", step.ID), nil)
+			return withStepLocation(step, streamyerrors.NewValidationError(fieldForStep(i, "id"), fmt.Sprintf("duplicate step id %q", step.ID), nil))
 		}
 
 		if err := ValidateStep(step); err != nil {
-			return err
+			return withStepLocation(step, err)
+		}
+
+		if err := validateCondition(fieldForStep(i, "when"), step); err != nil {
+			return withStepLocation(step, err)
 		}
 
 		stepIndex[step.ID] = i
 	}
 
+	if err := validateRegisteredNames(cfg.Steps); err != nil {
+		return err
+	}
+
 	for i, step := range cfg.Steps {
 		for _, dep := range step.DependsOn {
-			index, ok := stepIndex[dep]
-			if !ok {
-				return streamyerrors.NewValidationError(fieldForStep(i, "depends_on"), fmt.Sprintf("references unknown step %q", dep), nil)
-			}
-
-			if index >= i {
-				// Allow forward references; cycle detection handles invalid ordering.
-				continue
+			if _, ok := stepIndex[dep]; !ok {
+				return withStepLocation(step, streamyerrors.NewValidationError(fieldForStep(i, "depends_on"), fmt.Sprintf("references unknown step %q", dep), nil))
 			}
 		}
 	}
@@ -50,6 +60,14 @@ func ValidateConfig(cfg *Config) error {
 		return streamyerrors.NewValidationError("steps", fmt.Sprintf("dependency cycle detected: %s", strings.Join(cycle, " -> ")), nil)
 	}
 
+	if err := validateHandlers(cfg, stepIndex); err != nil {
+		return err
+	}
+
+	if err := validateHooks(cfg.Hooks); err != nil {
+		return err
+	}
+
 	for i, validation := range cfg.Validations {
 		if err := validateValidation(validation, i); err != nil {
 			return err
@@ -58,3 +76,13 @@ func ValidateConfig(cfg *Config) error {
 
 	return nil
 }
+
+// withStepLocation wraps err in a ParseError pointing at the file and line the step was declared at,
+// so problems in included fragments are reported against the fragment rather than the root config.
+func withStepLocation(step Step, err error) error {
+	loc := step.Location()
+	if err == nil || loc.File == "" {
+		return err
+	}
+	return &streamyerrors.ParseError{Path: loc.File, Line: loc.Line, Message: err.Error(), Err: err}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// HandlerMap builds a lookup table for handlers by ID.
+func (c *Config) HandlerMap() map[string]Step {
+	if c == nil {
+		return nil
+	}
+	return StepMap(c.Handlers)
+}
+
+// validateHandlers checks the handlers section and every step's notify list. Handlers are steps
+// that only run when notified, so they may depend on other handlers but not on pipeline steps.
+func validateHandlers(cfg *Config, stepIndex map[string]int) error {
+	handlerIndex := make(map[string]int, len(cfg.Handlers))
+	for i, handler := range cfg.Handlers {
+		if _, exists := handlerIndex[handler.ID]; exists {
+			return withStepLocation(handler, streamyerrors.NewValidationError(fieldForHandler(i, "id"), fmt.Sprintf("duplicate handler id %q", handler.ID), nil))
+		}
+		if _, exists := stepIndex[handler.ID]; exists {
+			return withStepLocation(handler, streamyerrors.NewValidationError(fieldForHandler(i, "id"), fmt.Sprintf("handler id %q is already used by a step", handler.ID), nil))
+		}
+		if handler.Loop != nil {
+			return withStepLocation(handler, streamyerrors.NewValidationError(fieldForHandler(i, "loop"), "handlers cannot loop", nil))
+		}
+		if len(handler.Notify) > 0 {
+			return withStepLocation(handler, streamyerrors.NewValidationError(fieldForHandler(i, "notify"), "handlers cannot notify other handlers; use depends_on to order them", nil))
+		}
+		if err := ValidateStep(handler); err != nil {
+			return withStepLocation(handler, err)
+		}
+		if err := validateCondition(fieldForHandler(i, "when"), handler); err != nil {
+			return withStepLocation(handler, err)
+		}
+		handlerIndex[handler.ID] = i
+	}
+
+	for i, handler := range cfg.Handlers {
+		for _, dep := range handler.DependsOn {
+			if _, ok := handlerIndex[dep]; !ok {
+				return withStepLocation(handler, streamyerrors.NewValidationError(fieldForHandler(i, "depends_on"), fmt.Sprintf("references unknown handler %q", dep), nil))
+			}
+		}
+	}
+	if cycle := detectCycle(cfg.Handlers); len(cycle) > 0 {
+		return streamyerrors.NewValidationError("handlers", fmt.Sprintf("dependency cycle detected: %s", strings.Join(cycle, " -> ")), nil)
+	}
+
+	for i, step := range cfg.Steps {
+		for _, name := range step.Notify {
+			if _, ok := handlerIndex[name]; !ok {
+				return withStepLocation(step, streamyerrors.NewValidationError(fieldForStep(i, "notify"), fmt.Sprintf("references unknown handler %q", name), nil))
+			}
+		}
+	}
+
+	return nil
+}
+
+func fieldForHandler(index int, field string) string {
+	return fmt.Sprintf("handlers[%d].%s", index, field)
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func TestParseConfig_Handlers(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Handlers"
+vars:
+  service: foo
+steps:
+  - id: render
+    type: command
+    command: "true"
+    notify: [restart]
+handlers:
+  - id: restart
+    type: command
+    command: "systemctl --user restart ${vars.service}"
+  - id: status
+    type: command
+    command: "systemctl --user status ${vars.service}"
+    depends_on: [restart]
+`)
+
+	cfg, err := ParseConfig(path)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"restart"}, cfg.Steps[0].Notify)
+	_, leaked := cfg.Steps[0].RawConfig()["notify"]
+	require.False(t, leaked)
+
+	handlers := cfg.HandlerMap()
+	require.Len(t, handlers, 2)
+	restart := handlers["restart"]
+	require.True(t, restart.Enabled)
+	var cmd CommandStep
+	require.NoError(t, restart.DecodeConfig(&cmd))
+	require.Equal(t, "systemctl --user restart foo", cmd.Command)
+	require.Equal(t, path, restart.Location().File)
+}
+
+func TestParseConfig_HandlerValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		notify   string
+		handlers string
+		message  string
+	}{
+		{
+			name:     "unknown handler",
+			notify:   "[missing]",
+			handlers: "  - id: restart\n    type: command\n    command: \"true\"\n",
+			message:  `references unknown handler "missing"`,
+		},
+		{
+			name:     "handler clashes with step",
+			notify:   "[]",
+			handlers: "  - id: render\n    type: command\n    command: \"true\"\n",
+			message:  `handler id "render" is already used by a step`,
+		},
+		{
+			name:     "handler depends on step",
+			notify:   "[]",
+			handlers: "  - id: restart\n    type: command\n    command: \"true\"\n    depends_on: [render]\n",
+			message:  `references unknown handler "render"`,
+		},
+		{
+			name:     "handler notifies",
+			notify:   "[]",
+			handlers: "  - id: restart\n    type: command\n    command: \"true\"\n    notify: [restart]\n",
+			message:  "handlers cannot notify",
+		},
+		{
+			name:     "handler cycle",
+			notify:   "[]",
+			handlers: "  - id: a\n    type: command\n    command: \"true\"\n    depends_on: [b]\n  - id: b\n    type: command\n    command: \"true\"\n    depends_on: [a]\n",
+			message:  "dependency cycle detected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Handlers"
+steps:
+  - id: render
+    type: command
+    command: "true"
+    notify: `+tt.notify+`
+handlers:
+`+tt.handlers)
+
+			_, err := ParseConfig(path)
+			var validationErr *streamyerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			require.Contains(t, validationErr.Message, tt.message)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Hash fingerprints everything in the step's definition that affects what it does or how it is
+// run, so callers can tell whether a step changed between two loads of a config. Only the
+// display name is left out.
+func (s *Step) Hash() string {
+	def := struct {
+		Step
+		Config map[string]any `json:"config"`
+	}{*s, s.RawConfig()}
+	def.Name = ""
+	return hashValue(def)
+}
+
+// HashSteps fingerprints a whole pipeline from its step hashes, independent of step order.
+func HashSteps(steps []Step) string {
+	hashes := make([]string, 0, len(steps))
+	for i := range steps {
+		hashes = append(hashes, steps[i].Hash())
+	}
+	sort.Strings(hashes)
+
+	h := sha256.New()
+	for _, hash := range hashes {
+		h.Write([]byte(hash))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Hash fingerprints the resolved config: its settings, steps, handlers, hooks and validations.
+// Includes and variables are already resolved into those, and the name, description and
+// version only describe the pipeline.
+func (c *Config) Hash() string {
+	return hashValue(struct {
+		Settings    Settings     `json:"settings"`
+		Steps       string       `json:"steps"`
+		Handlers    string       `json:"handlers"`
+		Hooks       Hooks        `json:"hooks"`
+		Validations []Validation `json:"validations"`
+	}{c.Settings, HashSteps(c.Steps), HashSteps(c.Handlers), c.Hooks, c.Validations})
+}
+
+func hashValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// Values JSON cannot encode still print deterministically; fmt sorts map keys.
+		data = fmt.Appendf(nil, "%#v", v)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
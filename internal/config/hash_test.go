@@ -0,0 +1,131 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepHash(t *testing.T) {
+	t.Parallel()
+
+	build := func(command string, deps ...string) Step {
+		step := Step{ID: "install", Type: "command", DependsOn: deps}
+		require.NoError(t, step.SetConfig(CommandStep{Command: command}))
+		return step
+	}
+
+	base := build("echo hi")
+	same := build("echo hi")
+	require.Equal(t, base.Hash(), same.Hash())
+
+	// The display name is not an input.
+	same.Name = "Install things"
+	require.Equal(t, base.Hash(), same.Hash())
+
+	changed := build("echo bye")
+	require.NotEqual(t, base.Hash(), changed.Hash())
+	withDeps := build("echo hi", "setup")
+	require.NotEqual(t, base.Hash(), withDeps.Hash())
+
+	other := Step{ID: "other", Type: "command"}
+	require.Equal(t, HashSteps([]Step{base, other}), HashSteps([]Step{other, same}))
+	require.NotEqual(t, HashSteps([]Step{base}), HashSteps([]Step{base, other}))
+}
+
+func TestStepHash_CoversRunSettings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		mutate func(*Step)
+	}{
+		{name: "enabled", mutate: func(s *Step) { s.Enabled = false }},
+		{name: "when", mutate: func(s *Step) { s.When = "facts.os == 'linux'" }},
+		{name: "register", mutate: func(s *Step) { s.Register = "result" }},
+		{name: "notify", mutate: func(s *Step) { s.Notify = []string{"restart"} }},
+		{name: "timeout", mutate: func(s *Step) { s.Timeout = 60 }},
+		{name: "verify_timeout", mutate: func(s *Step) { s.VerifyTimeout = 5 }},
+		{name: "retries", mutate: func(s *Step) { s.Retries = 3 }},
+		{name: "retry_delay", mutate: func(s *Step) { s.RetryDelay = "2s" }},
+		{name: "backoff", mutate: func(s *Step) { s.Backoff = "exponential" }},
+		{name: "retry_on", mutate: func(s *Step) { s.RetryOn = []string{"timeout"} }},
+		{name: "lock", mutate: func(s *Step) { s.Locks = []string{"apt"} }},
+		{name: "tags", mutate: func(s *Step) { s.Tags = []string{"shell"} }},
+		{name: "continue_on_error", mutate: func(s *Step) { s.ContinueOnError = true }},
+		{name: "priority", mutate: func(s *Step) { s.Priority = 10 }},
+	}
+
+	base := Step{ID: "install", Type: "command", Enabled: true}
+	require.NoError(t, base.SetConfig(CommandStep{Command: "echo hi"}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			step := base
+			tt.mutate(&step)
+			require.NotEqual(t, base.Hash(), step.Hash())
+		})
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	t.Parallel()
+
+	build := func() *Config {
+		step := Step{ID: "install", Type: "command", Enabled: true}
+		require.NoError(t, step.SetConfig(CommandStep{Command: "echo hi"}))
+		handler := Step{ID: "restart", Type: "command", Enabled: true}
+		require.NoError(t, handler.SetConfig(CommandStep{Command: "echo restart"}))
+		return &Config{
+			Version:  "1.0",
+			Name:     "Dev",
+			Settings: Settings{Parallel: 2},
+			Steps:    []Step{step},
+			Handlers: []Step{handler},
+			Hooks:    Hooks{BeforeApply: []Hook{{Command: "echo start"}}},
+			Validations: []Validation{
+				{Type: "command_exists", CommandExists: &CommandExistsValidation{Command: "git"}},
+			},
+		}
+	}
+
+	base := build()
+	require.Equal(t, base.Hash(), build().Hash())
+
+	// Descriptive fields are not inputs.
+	described := build()
+	described.Name = "Renamed"
+	described.Description = "A pipeline"
+	require.Equal(t, base.Hash(), described.Hash())
+
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+	}{
+		{name: "settings", mutate: func(c *Config) { c.Settings.Timeout = 30 }},
+		{name: "settings on_skipped_dependency", mutate: func(c *Config) { c.Settings.OnSkippedDependency = "run" }},
+		{name: "step", mutate: func(c *Config) { c.Steps[0].Retries = 2 }},
+		{name: "handler", mutate: func(c *Config) {
+			require.NoError(t, c.Handlers[0].SetConfig(CommandStep{Command: "echo reload"}))
+		}},
+		{name: "handler added", mutate: func(c *Config) {
+			c.Handlers = append(c.Handlers, Step{ID: "reload", Type: "command"})
+		}},
+		{name: "hook", mutate: func(c *Config) { c.Hooks.BeforeApply[0].Command = "echo begin" }},
+		{name: "hook event", mutate: func(c *Config) { c.Hooks.OnFailure = []Hook{{Func: "notify"}} }},
+		{name: "validation", mutate: func(c *Config) { c.Validations[0].CommandExists.Command = "curl" }},
+		{name: "validation added", mutate: func(c *Config) {
+			c.Validations = append(c.Validations, Validation{Type: "file_exists", FileExists: &FileExistsValidation{Path: "/etc/hosts"}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := build()
+			tt.mutate(cfg)
+			require.NotEqual(t, base.Hash(), cfg.Hash())
+		})
+	}
+}
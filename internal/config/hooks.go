@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// Lifecycle events hooks can be attached to.
+const (
+	HookBeforeApply  = "before_apply"
+	HookAfterApply   = "after_apply"
+	HookOnFailure    = "on_failure"
+	HookBeforeVerify = "before_verify"
+	HookAfterVerify  = "after_verify"
+)
+
+// HookEvents lists the lifecycle events in the order they are declared under `hooks:`.
+var HookEvents = []string{HookBeforeApply, HookAfterApply, HookOnFailure, HookBeforeVerify, HookAfterVerify}
+
+// Hooks lists commands or registered functions run around apply and verify.
+type Hooks struct {
+	BeforeApply  []Hook `yaml:"before_apply,omitempty" validate:"omitempty,dive"`
+	AfterApply   []Hook `yaml:"after_apply,omitempty" validate:"omitempty,dive"`
+	OnFailure    []Hook `yaml:"on_failure,omitempty" validate:"omitempty,dive"`
+	BeforeVerify []Hook `yaml:"before_verify,omitempty" validate:"omitempty,dive"`
+	AfterVerify  []Hook `yaml:"after_verify,omitempty" validate:"omitempty,dive"`
+}
+
+// Hook is a single lifecycle hook: either a shell command or the name of a Go function
+// registered with the pipeline service.
+type Hook struct {
+	Name    string            `yaml:"name,omitempty"`
+	Command string            `yaml:"command,omitempty"`
+	Func    string            `yaml:"func,omitempty"`
+	Shell   string            `yaml:"shell,omitempty"`
+	WorkDir string            `yaml:"workdir,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty"`
+	// Timeout bounds the hook in seconds; zero leaves it unbounded.
+	Timeout int `yaml:"timeout,omitempty" validate:"omitempty,min=1,max=3600"`
+}
+
+// UnmarshalYAML accepts either a bare command string or a mapping.
+func (h *Hook) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*h = Hook{Command: value.Value}
+		return nil
+	}
+
+	type rawHook Hook
+	var temp rawHook
+	if err := value.Decode(&temp); err != nil {
+		return err
+	}
+	*h = Hook(temp)
+	return nil
+}
+
+// Label identifies the hook in logs and errors: its name, else its function or command.
+func (h Hook) Label() string {
+	switch {
+	case h.Name != "":
+		return h.Name
+	case h.Func != "":
+		return h.Func
+	default:
+		return h.Command
+	}
+}
+
+// ByEvent returns the hooks configured for a lifecycle event such as HookBeforeApply.
+func (h Hooks) ByEvent(event string) []Hook {
+	switch event {
+	case HookBeforeApply:
+		return h.BeforeApply
+	case HookAfterApply:
+		return h.AfterApply
+	case HookOnFailure:
+		return h.OnFailure
+	case HookBeforeVerify:
+		return h.BeforeVerify
+	case HookAfterVerify:
+		return h.AfterVerify
+	default:
+		return nil
+	}
+}
+
+func validateHooks(hooks Hooks) error {
+	for _, event := range HookEvents {
+		for i, hook := range hooks.ByEvent(event) {
+			field := fmt.Sprintf("hooks.%s[%d]", event, i)
+			hasCommand := strings.TrimSpace(hook.Command) != ""
+			hasFunc := strings.TrimSpace(hook.Func) != ""
+			switch {
+			case hasCommand && hasFunc:
+				return streamyerrors.NewValidationError(field, "hook sets both command and func; choose one", nil)
+			case !hasCommand && !hasFunc:
+				return streamyerrors.NewValidationError(field, "hook requires a command or func", nil)
+			case hasFunc && (hook.Shell != "" || hook.WorkDir != "" || len(hook.Env) > 0):
+				return streamyerrors.NewValidationError(field, "shell, workdir and env only apply to command hooks", nil)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func TestParseConfig_Hooks(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Hooks"
+hooks:
+  before_apply:
+    - "git pull --ff-only"
+  on_failure:
+    - name: notify
+      command: notify-send "streamy failed"
+      env:
+        DISPLAY: ":0"
+      timeout: 10
+  after_verify:
+    - func: report
+steps:
+  - id: hello
+    type: command
+    command: "true"
+`)
+
+	cfg, err := ParseConfig(path)
+	require.NoError(t, err)
+
+	require.Equal(t, []Hook{{Command: "git pull --ff-only"}}, cfg.Hooks.ByEvent(HookBeforeApply))
+	onFailure := cfg.Hooks.ByEvent(HookOnFailure)
+	require.Len(t, onFailure, 1)
+	require.Equal(t, "notify", onFailure[0].Label())
+	require.Equal(t, map[string]string{"DISPLAY": ":0"}, onFailure[0].Env)
+	require.Equal(t, 10, onFailure[0].Timeout)
+	require.Equal(t, "report", cfg.Hooks.AfterVerify[0].Label())
+	require.Empty(t, cfg.Hooks.ByEvent(HookAfterApply))
+}
+
+func TestParseConfig_HookValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		hooks   string
+		field   string
+		message string
+	}{
+		{
+			name:    "command and func",
+			hooks:   "  after_apply:\n    - command: \"true\"\n      func: report\n",
+			field:   "hooks.after_apply[0]",
+			message: "choose one",
+		},
+		{
+			name:    "empty hook",
+			hooks:   "  before_verify:\n    - name: nothing\n",
+			field:   "hooks.before_verify[0]",
+			message: "requires a command or func",
+		},
+		{
+			name:    "env on func hook",
+			hooks:   "  on_failure:\n    - func: report\n      env:\n        A: b\n",
+			field:   "hooks.on_failure[0]",
+			message: "only apply to command hooks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Hooks"
+steps:
+  - id: hello
+    type: command
+    command: "true"
+hooks:
+`+tt.hooks)
+
+			_, err := ParseConfig(path)
+			var validationErr *streamyerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			require.Equal(t, tt.field, validationErr.Field)
+			require.Contains(t, validationErr.Message, tt.message)
+		})
+	}
+}
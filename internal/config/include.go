@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// NamespaceSeparator joins an include namespace and the step ID declared in the fragment.
+const NamespaceSeparator = "/"
+
+var namespaceInvalidChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// fragment is the subset of a configuration document that may be included from another file.
+type fragment struct {
+	Include     []Include    `yaml:"include,omitempty"`
+	Imports     []Include    `yaml:"imports,omitempty"`
+	Steps       []Step       `yaml:"steps,omitempty"`
+	Validations []Validation `yaml:"validations,omitempty"`
+}
+
+// includeResolver loads fragments recursively while guarding against include cycles.
+type includeResolver struct {
+	stack   []string
+	sources []string
+}
+
+// resolveIncludes merges every fragment referenced by cfg into its step and validation lists.
+func resolveIncludes(path string, cfg *Config) error {
+	r := &includeResolver{sources: []string{path}}
+	if abs, err := filepath.Abs(path); err == nil {
+		r.stack = append(r.stack, abs)
+	}
+
+	steps, validations, err := r.resolve(path, allIncludes(cfg.Include, cfg.Imports), "")
+	if err != nil {
+		return err
+	}
+
+	cfg.Steps = append(cfg.Steps, steps...)
+	cfg.Validations = append(cfg.Validations, validations...)
+	cfg.sources = r.sources
+	return nil
+}
+
+func (r *includeResolver) resolve(from string, includes []Include, prefix string) ([]Step, []Validation, error) {
+	var steps []Step
+	var validations []Validation
+
+	baseDir := filepath.Dir(from)
+	for _, inc := range includes {
+		if strings.TrimSpace(inc.Path) == "" {
+			return nil, nil, streamyerrors.NewParseError(from, inc.line, fmt.Errorf("include path is required"))
+		}
+
+		files, err := expandIncludePath(baseDir, inc.Path)
+		if err != nil {
+			return nil, nil, streamyerrors.NewParseError(from, inc.line, err)
+		}
+
+		for _, file := range files {
+			namespace := inc.Namespace
+			if namespace == "" {
+				namespace = namespaceFromPath(file)
+			}
+			if prefix != "" {
+				namespace = prefix + NamespaceSeparator + namespace
+			}
+
+			fragSteps, fragValidations, err := r.loadFragment(file, namespace)
+			if err != nil {
+				return nil, nil, err
+			}
+			steps = append(steps, fragSteps...)
+			validations = append(validations, fragValidations...)
+		}
+	}
+
+	return steps, validations, nil
+}
+
+func (r *includeResolver) loadFragment(path, namespace string) ([]Step, []Validation, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, streamyerrors.NewParseError(path, 0, err)
+	}
+	for _, active := range r.stack {
+		if active == abs {
+			return nil, nil, streamyerrors.NewParseError(path, 0, fmt.Errorf("include cycle detected: %s", strings.Join(append(r.stack, abs), " -> ")))
+		}
+	}
+	r.stack = append(r.stack, abs)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+	r.sources = append(r.sources, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, streamyerrors.NewParseError(path, 0, err)
+	}
+
+	var frag fragment
+	if err := yaml.Unmarshal(data, &frag); err != nil {
+		return nil, nil, streamyerrors.NewParseError(path, extractLine(err), err)
+	}
+	setStepFile(frag.Steps, path)
+
+	nested, nestedValidations, err := r.resolve(path, allIncludes(frag.Include, frag.Imports), namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	known := make(map[string]struct{}, len(frag.Steps)+len(nested))
+	for _, step := range frag.Steps {
+		known[qualify(namespace, step.ID)] = struct{}{}
+	}
+	for _, step := range nested {
+		known[step.ID] = struct{}{}
+	}
+
+	for i := range frag.Steps {
+		step := &frag.Steps[i]
+		if err := validateDeclaredID(*step, i); err != nil {
+			return nil, nil, err
+		}
+		step.ID = qualify(namespace, step.ID)
+		step.generated = true
+		for j, dep := range step.DependsOn {
+			// Prefer steps declared in this fragment (or its own includes); anything else is a global reference.
+			// Loop instances ("link[zshrc]") are matched by the looped step they expand from.
+			local := dep
+			if group := loopGroupOf(dep); group != "" {
+				local = group
+			}
+			if _, ok := known[qualify(namespace, local)]; ok {
+				step.DependsOn[j] = qualify(namespace, dep)
+			}
+		}
+	}
+
+	steps := append(frag.Steps, nested...)
+	validations := append(frag.Validations, nestedValidations...)
+	return steps, validations, nil
+}
+
+// validateDeclaredID checks an ID as written in the config, before includes or loops extend it.
+func validateDeclaredID(step Step, index int) error {
+	if stepIDPattern.MatchString(step.ID) {
+		return nil
+	}
+	field := fieldForStep(index, "id")
+	return withStepLocation(step, streamyerrors.NewValidationError(field, fmt.Sprintf("%s %q failed validation for tag 'step_id'", field, step.ID), nil))
+}
+
+func expandIncludePath(baseDir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, fmt.Errorf("include %s: %w", pattern, err)
+		}
+		return []string{pattern}, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("include %s: %w", pattern, err)
+	}
+	return matches, nil
+}
+
+func namespaceFromPath(path string) string {
+	base := filepath.Base(path)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	ns := namespaceInvalidChars.ReplaceAllString(strings.ToLower(stem), "_")
+	if ns == "" {
+		return "include"
+	}
+	return ns
+}
+
+func qualify(namespace, id string) string {
+	if namespace == "" {
+		return id
+	}
+	return namespace + NamespaceSeparator + id
+}
+
+func allIncludes(include, imports []Include) []Include {
+	out := make([]Include, 0, len(include)+len(imports))
+	out = append(out, include...)
+	return append(out, imports...)
+}
+
+func setStepFile(steps []Step, path string) {
+	for i := range steps {
+		steps[i].location.File = path
+	}
+}
@@ -0,0 +1,342 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func writeConfigFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestParseConfig_Includes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("merges fragment steps under a namespace", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeConfigFile(t, dir, "fragments/git.yaml", `
+steps:
+  - id: install
+    type: command
+    command: "echo install"
+  - id: configure
+    type: command
+    depends_on: [install]
+    command: "echo configure"
+validations:
+  - type: command_exists
+    command: echo
+`)
+		root := writeConfigFile(t, dir, "streamy.yaml", `
+version: "1.0"
+name: "Root"
+include:
+  - fragments/git.yaml
+steps:
+  - id: finish
+    type: command
+    depends_on: [git/configure]
+    command: "echo done"
+`)
+
+		cfg, err := ParseConfig(root)
+		require.NoError(t, err)
+		require.Len(t, cfg.Steps, 3)
+
+		steps := StepMap(cfg.Steps)
+		require.Contains(t, steps, "git/install")
+		require.Equal(t, []string{"git/install"}, steps["git/configure"].DependsOn)
+		require.Equal(t, []string{"git/configure"}, steps["finish"].DependsOn)
+		require.Len(t, cfg.Validations, 1)
+		require.Equal(t, []string{root, filepath.Join(dir, "fragments/git.yaml")}, cfg.SourceFiles())
+	})
+
+	t.Run("imports alias with explicit namespace and globs", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeConfigFile(t, dir, "parts/a.yaml", `
+steps:
+  - id: setup
+    type: command
+    command: "echo a"
+`)
+		writeConfigFile(t, dir, "parts/b.yaml", `
+steps:
+  - id: setup
+    type: command
+    command: "echo b"
+`)
+		writeConfigFile(t, dir, "shared.yaml", `
+steps:
+  - id: setup
+    type: command
+    command: "echo shared"
+`)
+		root := writeConfigFile(t, dir, "streamy.yaml", `
+version: "1.0"
+name: "Root"
+imports:
+  - parts/*.yaml
+  - path: shared.yaml
+    namespace: common
+steps:
+  - id: setup
+    type: command
+    command: "echo root"
+`)
+
+		cfg, err := ParseConfig(root)
+		require.NoError(t, err)
+
+		ids := make([]string, 0, len(cfg.Steps))
+		for _, step := range cfg.Steps {
+			ids = append(ids, step.ID)
+		}
+		require.Equal(t, []string{"setup", "a/setup", "b/setup", "common/setup"}, ids)
+	})
+
+	t.Run("nested includes extend the namespace", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeConfigFile(t, dir, "inner.yaml", `
+steps:
+  - id: leaf
+    type: command
+    command: "echo leaf"
+`)
+		writeConfigFile(t, dir, "outer.yaml", `
+include: [inner.yaml]
+steps:
+  - id: branch
+    type: command
+    depends_on: [inner/leaf]
+    command: "echo branch"
+`)
+		root := writeConfigFile(t, dir, "streamy.yaml", `
+version: "1.0"
+name: "Root"
+include: [outer.yaml]
+steps:
+  - id: trunk
+    type: command
+    depends_on: [outer/branch]
+    command: "echo trunk"
+`)
+
+		cfg, err := ParseConfig(root)
+		require.NoError(t, err)
+
+		steps := StepMap(cfg.Steps)
+		require.Equal(t, []string{"outer/inner/leaf"}, steps["outer/branch"].DependsOn)
+	})
+
+	t.Run("looped fragment steps keep namespaced instance IDs", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeConfigFile(t, dir, "dotfiles.yaml", `
+steps:
+  - id: link
+    type: command
+    loop: [zshrc, vimrc]
+    command: "echo ${item}"
+  - id: zsh_plugins
+    type: command
+    depends_on: ["link[zshrc]"]
+    command: "echo plugins"
+`)
+		root := writeConfigFile(t, dir, "streamy.yaml", `
+version: "1.0"
+name: "Root"
+include: [dotfiles.yaml]
+steps:
+  - id: done
+    type: command
+    depends_on: [dotfiles/link]
+    command: "echo done"
+`)
+
+		cfg, err := ParseConfig(root)
+		require.NoError(t, err)
+
+		steps := StepMap(cfg.Steps)
+		require.Equal(t, []string{"dotfiles/link[zshrc]"}, steps["dotfiles/zsh_plugins"].DependsOn)
+		require.Equal(t, []string{"dotfiles/link[zshrc]", "dotfiles/link[vimrc]"}, steps["done"].DependsOn)
+	})
+
+	t.Run("include cycles are rejected", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeConfigFile(t, dir, "a.yaml", "include: [b.yaml]\n")
+		writeConfigFile(t, dir, "b.yaml", "include: [a.yaml]\n")
+		root := writeConfigFile(t, dir, "streamy.yaml", `
+version: "1.0"
+name: "Root"
+include: [a.yaml]
+steps:
+  - id: only
+    type: command
+    command: "echo"
+`)
+
+		_, err := ParseConfig(root)
+		var parseErr *streamyerrors.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Contains(t, parseErr.Message, "include cycle detected")
+	})
+
+	t.Run("missing include points at the including line", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		root := writeConfigFile(t, dir, "streamy.yaml", `version: "1.0"
+name: "Root"
+include:
+  - missing.yaml
+steps:
+  - id: only
+    type: command
+    command: "echo"
+`)
+
+		_, err := ParseConfig(root)
+		var parseErr *streamyerrors.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, root, parseErr.Path)
+		require.Equal(t, 4, parseErr.Line)
+	})
+
+	t.Run("yaml errors report the fragment file", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		fragment := writeConfigFile(t, dir, "broken.yaml", "steps:\n  - id: [unterminated\n")
+		root := writeConfigFile(t, dir, "streamy.yaml", `
+version: "1.0"
+name: "Root"
+include: [broken.yaml]
+steps:
+  - id: only
+    type: command
+    command: "echo"
+`)
+
+		_, err := ParseConfig(root)
+		var parseErr *streamyerrors.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, fragment, parseErr.Path)
+	})
+
+	t.Run("validation errors in fragments carry file and line", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		fragment := writeConfigFile(t, dir, "tools.yaml", `steps:
+  - id: ok
+    type: command
+    command: "echo ok"
+  - id: broken
+    type: command
+    depends_on: [nowhere]
+    command: "echo broken"
+`)
+		root := writeConfigFile(t, dir, "streamy.yaml", `
+version: "1.0"
+name: "Root"
+include: [tools.yaml]
+steps:
+  - id: only
+    type: command
+    command: "echo"
+`)
+
+		_, err := ParseConfig(root)
+		var parseErr *streamyerrors.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, fragment, parseErr.Path)
+		require.Equal(t, 5, parseErr.Line)
+
+		var validationErr *streamyerrors.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Contains(t, validationErr.Message, "nowhere")
+	})
+
+	t.Run("declared IDs cannot use namespace or loop syntax", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeConfigFile(t, dir, "tools.yaml", `steps:
+  - id: git/clone
+    type: command
+    command: "echo clone"
+`)
+		root := writeConfigFile(t, dir, "streamy.yaml", `
+version: "1.0"
+name: "Root"
+include: [tools.yaml]
+steps:
+  - id: only
+    type: command
+    command: "echo"
+`)
+		_, err := ParseConfig(root)
+		var validationErr *streamyerrors.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Contains(t, validationErr.Message, "step_id")
+
+		for _, id := range []string{"git/clone", "link[zshrc]"} {
+			root := writeConfigFile(t, dir, "root.yaml", `
+version: "1.0"
+name: "Root"
+steps:
+  - id: "`+id+`"
+    type: command
+    command: "echo"
+`)
+			_, err := ParseConfig(root)
+			require.ErrorAs(t, err, &validationErr, id)
+			require.Contains(t, validationErr.Message, "step_id", id)
+		}
+	})
+
+	t.Run("cycles across fragments are detected on the merged graph", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeConfigFile(t, dir, "loop.yaml", `
+steps:
+  - id: inner
+    type: command
+    depends_on: [outer]
+    command: "echo inner"
+`)
+		root := writeConfigFile(t, dir, "streamy.yaml", `
+version: "1.0"
+name: "Root"
+include: [loop.yaml]
+steps:
+  - id: outer
+    type: command
+    depends_on: [loop/inner]
+    command: "echo outer"
+`)
+
+		_, err := ParseConfig(root)
+		var validationErr *streamyerrors.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Contains(t, validationErr.Message, "dependency cycle detected")
+	})
+}
+
+func TestNamespaceFromPath(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "git", namespaceFromPath("/tmp/git.yaml"))
+	require.Equal(t, "dot_files", namespaceFromPath("dot.files.yml"))
+	require.Equal(t, "my-tools", namespaceFromPath("My-Tools.yaml"))
+}
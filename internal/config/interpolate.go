@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alexisbeaulieu97/streamy/internal/expr"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// Interpolated returns a copy of the step with ${...} references in its name and
+// plugin configuration resolved against scope. References to roots missing from the
+// scope are preserved so they can be resolved later (or passed through to a shell).
+func (s Step) Interpolated(scope expr.Scope) (Step, error) {
+	out := s
+
+	name, err := expr.Interpolate(s.Name, scope)
+	if err != nil {
+		return Step{}, &expr.FieldError{Field: "name", Err: err}
+	}
+	out.Name = expr.Stringify(name)
+
+	if len(s.rawConfig) > 0 {
+		raw, err := expr.InterpolateValue(s.rawConfig, scope)
+		if err != nil {
+			return Step{}, err
+		}
+		out.rawConfig = raw.(map[string]any)
+	}
+
+	return out, nil
+}
+
+// mergeVars layers overrides on top of the variables declared in the config.
+func mergeVars(declared, overrides map[string]any) map[string]any {
+	merged := make(map[string]any, len(declared)+len(overrides))
+	for k, v := range declared {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+func validateVarNames(vars map[string]any) error {
+	for name := range vars {
+		if !templateVarNamePattern.MatchString(name) {
+			return streamyerrors.NewValidationError("vars", fmt.Sprintf("variable %q is invalid; must match %s", name, templateVarNamePattern.String()), nil)
+		}
+	}
+	return nil
+}
+
+// interpolateSteps resolves pipeline variables in every step and handler before plugin configuration is decoded.
+func interpolateSteps(cfg *Config) error {
+	scope := expr.Scope{"vars": cfg.Vars}
+	for i := range cfg.Steps {
+		step := cfg.Steps[i]
+		resolved, err := step.Interpolated(scope)
+		if err != nil {
+			return withStepLocation(step, interpolationError(fieldForStep, i, step.ID, err))
+		}
+		cfg.Steps[i] = resolved
+	}
+	for i := range cfg.Handlers {
+		handler := cfg.Handlers[i]
+		resolved, err := handler.Interpolated(scope)
+		if err != nil {
+			return withStepLocation(handler, interpolationError(fieldForHandler, i, handler.ID, err))
+		}
+		cfg.Handlers[i] = resolved
+	}
+	return nil
+}
+
+func interpolationError(fieldFor func(int, string) string, index int, stepID string, err error) error {
+	field := ""
+	var fieldErr *expr.FieldError
+	if errors.As(err, &fieldErr) {
+		field = fieldErr.Field
+	}
+
+	var undefined *expr.UndefinedError
+	if errors.As(err, &undefined) {
+		return streamyerrors.NewValidationError(fieldFor(index, field), fmt.Sprintf("step %q field %q references undefined variable %q", stepID, field, undefined.Ref), err)
+	}
+	return streamyerrors.NewValidationError(fieldFor(index, field), fmt.Sprintf("step %q field %q: %v", stepID, field, err), err)
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func TestParseConfig_Vars(t *testing.T) {
+	t.Parallel()
+
+	const contents = `version: "1.0"
+name: "Vars"
+vars:
+  user: alice
+  packages: [git, curl]
+steps:
+  - id: install
+    name: "Install for ${vars.user}"
+    type: package
+    packages: ["${vars.packages}", jq]
+  - id: greet
+    type: command
+    command: "echo ${vars.user} from $HOME ${SHELL}"
+`
+
+	t.Run("interpolates step fields before decoding", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", contents)
+
+		cfg, err := ParseConfig(path)
+		require.NoError(t, err)
+
+		steps := StepMap(cfg.Steps)
+		require.Equal(t, "Install for alice", steps["install"].Name)
+
+		install := steps["install"]
+		var pkg PackageStep
+		require.NoError(t, install.DecodeConfig(&pkg))
+		require.Equal(t, []string{"git", "curl", "jq"}, pkg.Packages)
+
+		greet := steps["greet"]
+		var cmd CommandStep
+		require.NoError(t, greet.DecodeConfig(&cmd))
+		require.Equal(t, "echo alice from $HOME ${SHELL}", cmd.Command)
+	})
+
+	t.Run("overrides take precedence over declared vars", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", contents)
+
+		cfg, err := ParseConfigWithOptions(path, ParseOptions{Vars: map[string]any{"user": "bob"}})
+		require.NoError(t, err)
+		require.Equal(t, "bob", cfg.Vars["user"])
+		require.Equal(t, "Install for bob", StepMap(cfg.Steps)["install"].Name)
+	})
+
+	t.Run("undefined variables fail validation with step and field", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Vars"
+steps:
+  - id: clone
+    type: repo
+    url: "https://example.com/${vars.org}/repo.git"
+    destination: /tmp/repo
+`)
+
+		_, err := ParseConfig(path)
+		var validationErr *streamyerrors.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, "steps[0].url", validationErr.Field)
+		require.Contains(t, validationErr.Message, `"clone"`)
+		require.Contains(t, validationErr.Message, `"vars.org"`)
+
+		var parseErr *streamyerrors.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, 4, parseErr.Line)
+	})
+
+	t.Run("invalid variable names are rejected", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", contents)
+
+		_, err := ParseConfigWithOptions(path, ParseOptions{Vars: map[string]any{"bad-name": "x"}})
+		var validationErr *streamyerrors.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, "vars", validationErr.Field)
+	})
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexisbeaulieu97/streamy/internal/expr"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+var (
+	loopKeyPattern      = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	loopKeyInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+)
+
+// loopItem is one element a looped step is expanded over.
+type loopItem struct {
+	Key   string
+	Index int
+	Value any
+}
+
+// InstanceID returns the ID of the loop instance of group identified by key, e.g. "link_dotfiles[zshrc]".
+func InstanceID(group, key string) string {
+	return fmt.Sprintf("%s[%s]", group, key)
+}
+
+// LoopGroup returns the ID of the looped declaration a step was generated from, or "" for ordinary steps.
+func (s *Step) LoopGroup() string {
+	if s == nil {
+		return ""
+	}
+	return loopGroupOf(s.ID)
+}
+
+func loopGroupOf(id string) string {
+	if !strings.HasSuffix(id, "]") {
+		return ""
+	}
+	open := strings.LastIndex(id, "[")
+	if open <= 0 {
+		return ""
+	}
+	return id[:open]
+}
+
+// expandLoops replaces every step that declares a loop with one concrete step per item.
+// Instances see the current element as ${item} and its position as ${loop.index} / ${loop.key};
+// depends_on entries naming a looped step are expanded to all of its instances, and a registered
+// name collects the outputs of every instance keyed by loop key.
+func expandLoops(cfg *Config) error {
+	varsScope := expr.Scope{"vars": cfg.Vars}
+	groups := make(map[string][]string)
+	expanded := make([]Step, 0, len(cfg.Steps))
+
+	for i, step := range cfg.Steps {
+		if step.Loop == nil {
+			expanded = append(expanded, step)
+			continue
+		}
+
+		if !step.generated {
+			if err := validateDeclaredID(step, i); err != nil {
+				return err
+			}
+		}
+
+		items, err := loopItems(step.Loop, varsScope)
+		if err != nil {
+			return withStepLocation(step, streamyerrors.NewValidationError(fieldForStep(i, "loop"), fmt.Sprintf("step %q: %v", step.ID, err), err))
+		}
+
+		ids := make([]string, 0, len(items))
+		for _, item := range items {
+			instance, err := loopInstance(step, item, cfg.Vars)
+			if err != nil {
+				return withStepLocation(step, interpolationError(fieldForStep, i, InstanceID(step.ID, item.Key), err))
+			}
+			expanded = append(expanded, instance)
+			ids = append(ids, instance.ID)
+		}
+		groups[step.ID] = ids
+	}
+
+	for i := range expanded {
+		expanded[i].DependsOn = expandGroupDependencies(expanded[i].DependsOn, groups)
+	}
+
+	cfg.Steps = expanded
+	return nil
+}
+
+func loopInstance(step Step, item loopItem, vars map[string]any) (Step, error) {
+	scope := expr.Scope{
+		"vars": vars,
+		"item": item.Value,
+		"loop": map[string]any{"index": item.Index, "key": item.Key},
+	}
+
+	instance, err := step.Interpolated(scope)
+	if err != nil {
+		return Step{}, err
+	}
+
+	when, err := expr.Interpolate(step.When, scope)
+	if err != nil {
+		return Step{}, &expr.FieldError{Field: "when", Err: err}
+	}
+
+	instance.ID = InstanceID(step.ID, item.Key)
+	instance.generated = true
+	instance.When = expr.Stringify(when)
+	instance.Loop = nil
+	if step.Register != "" {
+		instance.registerKey = item.Key
+	}
+	instance.DependsOn = append([]string(nil), step.DependsOn...)
+	return instance, nil
+}
+
+func loopItems(loop any, scope expr.Scope) ([]loopItem, error) {
+	if ref, ok := loop.(string); ok {
+		resolved, err := expr.Interpolate(ref, scope)
+		if err != nil {
+			return nil, err
+		}
+		if _, stillString := resolved.(string); stillString {
+			return nil, fmt.Errorf("loop must be a list, a map, or a reference to one")
+		}
+		loop = resolved
+	}
+
+	var items []loopItem
+	switch typed := loop.(type) {
+	case []any:
+		for i, value := range typed {
+			items = append(items, loopItem{Key: listItemKey(value, i), Index: i, Value: value})
+		}
+	case []string:
+		for i, value := range typed {
+			items = append(items, loopItem{Key: listItemKey(value, i), Index: i, Value: value})
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			items = append(items, loopItem{Key: sanitizeLoopKey(k, i), Index: i, Value: typed[k]})
+		}
+	default:
+		return nil, fmt.Errorf("loop must be a list, a map, or a reference to one")
+	}
+
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if _, dup := seen[item.Key]; dup {
+			return nil, fmt.Errorf("duplicate loop key %q; give each item a unique name", item.Key)
+		}
+		seen[item.Key] = struct{}{}
+	}
+
+	return items, nil
+}
+
+// listItemKey derives a stable key for a list element: scalars use their own value,
+// mappings use their key, name or id field, and anything else falls back to the index.
+func listItemKey(value any, index int) string {
+	switch typed := value.(type) {
+	case map[string]any:
+		for _, field := range []string{"key", "name", "id"} {
+			if v, ok := typed[field]; ok {
+				if _, nested := v.(map[string]any); !nested {
+					return sanitizeLoopKey(expr.Stringify(v), index)
+				}
+			}
+		}
+		return strconv.Itoa(index)
+	case []any, nil:
+		return strconv.Itoa(index)
+	default:
+		return sanitizeLoopKey(expr.Stringify(typed), index)
+	}
+}
+
+func sanitizeLoopKey(key string, index int) string {
+	if loopKeyPattern.MatchString(key) {
+		return key
+	}
+	cleaned := strings.Trim(loopKeyInvalidChars.ReplaceAllString(key, "_"), "_")
+	if cleaned == "" {
+		return strconv.Itoa(index)
+	}
+	return cleaned
+}
+
+func expandGroupDependencies(deps []string, groups map[string][]string) []string {
+	if len(deps) == 0 {
+		return deps
+	}
+	out := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		if instances, ok := groups[dep]; ok {
+			out = append(out, instances...)
+			continue
+		}
+		out = append(out, dep)
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func stepIDs(steps []Step) []string {
+	ids := make([]string, 0, len(steps))
+	for _, step := range steps {
+		ids = append(ids, step.ID)
+	}
+	return ids
+}
+
+func TestParseConfig_Loops(t *testing.T) {
+	t.Parallel()
+
+	t.Run("expands a list into instances with interpolated items", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Loops"
+vars:
+  home: /home/alice
+steps:
+  - id: link_dotfiles
+    name: "Link ${item}"
+    type: symlink
+    loop: [zshrc, gitconfig, .vimrc]
+    source: "dotfiles/${item}"
+    target: "${vars.home}/.${item}"
+  - id: reload
+    type: command
+    depends_on: [link_dotfiles]
+    command: "echo reload"
+  - id: git_only
+    type: command
+    depends_on: ["link_dotfiles[gitconfig]"]
+    command: "echo git"
+`)
+
+		cfg, err := ParseConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"link_dotfiles[zshrc]", "link_dotfiles[gitconfig]", "link_dotfiles[.vimrc]", "reload", "git_only",
+		}, stepIDs(cfg.Steps))
+
+		steps := StepMap(cfg.Steps)
+		zshrc := steps["link_dotfiles[zshrc]"]
+		require.Equal(t, "Link zshrc", zshrc.Name)
+		require.Nil(t, zshrc.Loop)
+		require.Equal(t, "link_dotfiles", zshrc.LoopGroup())
+
+		var link SymlinkStep
+		require.NoError(t, zshrc.DecodeConfig(&link))
+		require.Equal(t, "dotfiles/zshrc", link.Source)
+		require.Equal(t, "/home/alice/.zshrc", link.Target)
+
+		require.Equal(t, []string{"link_dotfiles[zshrc]", "link_dotfiles[gitconfig]", "link_dotfiles[.vimrc]"}, steps["reload"].DependsOn)
+		require.Equal(t, []string{"link_dotfiles[gitconfig]"}, steps["git_only"].DependsOn)
+	})
+
+	t.Run("with_items over a map or a variable reference", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Loops"
+vars:
+  repos:
+    - name: streamy
+      url: https://github.com/example/streamy.git
+    - name: dotfiles
+      url: https://github.com/example/dotfiles.git
+steps:
+  - id: clone
+    type: repo
+    with_items: "${vars.repos}"
+    url: "${item.url}"
+    destination: "/src/${item.name}"
+  - id: env
+    type: command
+    with_items:
+      EDITOR: vim
+      PAGER: less
+    command: "echo ${loop.key}=${item} (${loop.index})"
+`)
+
+		cfg, err := ParseConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, []string{"clone[streamy]", "clone[dotfiles]", "env[EDITOR]", "env[PAGER]"}, stepIDs(cfg.Steps))
+
+		steps := StepMap(cfg.Steps)
+		dotfiles := steps["clone[dotfiles]"]
+		var repo RepoStep
+		require.NoError(t, dotfiles.DecodeConfig(&repo))
+		require.Equal(t, "/src/dotfiles", repo.Destination)
+
+		pager := steps["env[PAGER]"]
+		var cmd CommandStep
+		require.NoError(t, pager.DecodeConfig(&cmd))
+		require.Equal(t, "echo PAGER=less (1)", cmd.Command)
+	})
+
+	t.Run("invalid loops are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		cases := map[string]string{
+			"scalar":         `loop: 3`,
+			"undefined":      `loop: "${vars.nothing}"`,
+			"duplicate keys": `loop: [a, a]`,
+		}
+		for name, loop := range cases {
+			path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Loops"
+steps:
+  - id: looped
+    type: command
+    `+loop+`
+    command: "echo ${item}"
+`)
+			_, err := ParseConfig(path)
+			var validationErr *streamyerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr, name)
+			require.Equal(t, "steps[0].loop", validationErr.Field, name)
+		}
+	})
+
+	t.Run("undefined item fields name the instance", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Loops"
+steps:
+  - id: looped
+    type: command
+    loop: [{name: one}]
+    command: "echo ${item.missing}"
+`)
+		_, err := ParseConfig(path)
+		var validationErr *streamyerrors.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, "steps[0].command", validationErr.Field)
+		require.Contains(t, validationErr.Message, `"looped[one]"`)
+		require.Contains(t, validationErr.Message, `"item.missing"`)
+	})
+}
+
+func TestListItemKey(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "zshrc", listItemKey("zshrc", 0))
+	require.Equal(t, "my_file", listItemKey("my file", 1))
+	require.Equal(t, "2", listItemKey("///", 2))
+	require.Equal(t, "web", listItemKey(map[string]any{"name": "web", "port": 80}, 3))
+	require.Equal(t, "4", listItemKey(map[string]any{"port": 80}, 4))
+	require.Equal(t, "5", listItemKey([]any{"a"}, 5))
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alexisbeaulieu97/streamy/internal/expr"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// OutputsRoot is the reference root under which registered step outputs are exposed, e.g. ${outputs.version.stdout}.
+const OutputsRoot = "outputs"
+
+// RegisterKey returns the loop key a looped step registers its outputs under, or "" for ordinary steps.
+func (s *Step) RegisterKey() string {
+	if s == nil {
+		return ""
+	}
+	return s.registerKey
+}
+
+// OutputReferences lists the registered names the step reads through ${outputs.<name>...}
+// in its name or configuration, or through outputs.<name> in its condition.
+func (s *Step) OutputReferences() []string {
+	if s == nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	collect := func(ref string) {
+		root, rest, ok := strings.Cut(ref, ".")
+		if !ok || root != OutputsRoot {
+			return
+		}
+		name, _, _ := strings.Cut(rest, ".")
+		seen[name] = struct{}{}
+	}
+
+	for _, ref := range expr.References(s.Name) {
+		collect(ref)
+	}
+	collectStringRefs(s.rawConfig, func(str string) {
+		for _, ref := range expr.References(str) {
+			collect(ref)
+		}
+	})
+	if condition, err := s.Condition(); err == nil && condition != nil {
+		for _, ref := range condition.References() {
+			collect(ref)
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveOutputs returns a copy of the step with ${outputs...} references resolved against outputs.
+func (s Step) ResolveOutputs(outputs map[string]any) (Step, error) {
+	if len(s.OutputReferences()) == 0 {
+		return s, nil
+	}
+	return s.Interpolated(expr.Scope{OutputsRoot: outputs})
+}
+
+func collectStringRefs(value any, visit func(string)) {
+	switch typed := value.(type) {
+	case string:
+		visit(typed)
+	case map[string]any:
+		for _, v := range typed {
+			collectStringRefs(v, visit)
+		}
+	case []any:
+		for _, v := range typed {
+			collectStringRefs(v, visit)
+		}
+	}
+}
+
+// validateRegisteredNames rejects register names shared by unrelated steps.
+// Instances of the same looped step share a name and are told apart by their loop key.
+func validateRegisteredNames(steps []Step) error {
+	owners := make(map[string]string)
+	for i, step := range steps {
+		if step.Register == "" {
+			continue
+		}
+		owner := step.ID
+		if step.registerKey != "" {
+			owner = step.LoopGroup()
+		}
+		if existing, ok := owners[step.Register]; ok && existing != owner {
+			return withStepLocation(step, streamyerrors.NewValidationError(fieldForStep(i, "register"), fmt.Sprintf("register name %q is already used by step %q", step.Register, existing), nil))
+		}
+		owners[step.Register] = owner
+	}
+	return nil
+}
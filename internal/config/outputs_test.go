@@ -0,0 +1,103 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func TestParseConfig_Register(t *testing.T) {
+	t.Parallel()
+
+	t.Run("keeps output references for runtime and records loop keys", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Outputs"
+vars:
+  tool: ripgrep
+steps:
+  - id: version
+    type: command
+    register: ver
+    command: "${vars.tool} --version"
+  - id: probe
+    type: command
+    loop: [go, node]
+    register: probes
+    command: "${item} version"
+  - id: report
+    name: "Report ${outputs.ver.stdout}"
+    type: command
+    depends_on: [version, probe]
+    when: outputs.ver.exit_code == 0
+    command: "echo ${outputs.probes.go.stdout} ${HOME}"
+`)
+
+		cfg, err := ParseConfig(path)
+		require.NoError(t, err)
+
+		steps := StepMap(cfg.Steps)
+		version := steps["version"]
+		require.Equal(t, "ver", version.Register)
+		require.Empty(t, version.RegisterKey())
+		_, leaked := version.RawConfig()["register"]
+		require.False(t, leaked)
+
+		goProbe := steps["probe[go]"]
+		require.Equal(t, "probes", goProbe.Register)
+		require.Equal(t, "go", goProbe.RegisterKey())
+
+		report := steps["report"]
+		require.Equal(t, "Report ${outputs.ver.stdout}", report.Name)
+		require.Equal(t, []string{"probes", "ver"}, report.OutputReferences())
+
+		resolved, err := report.ResolveOutputs(map[string]any{
+			"ver":    map[string]any{"stdout": "14.1"},
+			"probes": map[string]any{"go": map[string]any{"stdout": "go1.25"}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Report 14.1", resolved.Name)
+		var cmd CommandStep
+		require.NoError(t, resolved.DecodeConfig(&cmd))
+		require.Equal(t, "echo go1.25 ${HOME}", cmd.Command)
+	})
+
+	t.Run("rejects a register name shared by two steps", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Outputs"
+steps:
+  - id: first
+    type: command
+    register: result
+    command: "echo 1"
+  - id: second
+    type: command
+    register: result
+    command: "echo 2"
+`)
+
+		_, err := ParseConfig(path)
+		var validationErr *streamyerrors.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, "steps[1].register", validationErr.Field)
+		require.Contains(t, validationErr.Message, `already used by step "first"`)
+	})
+
+	t.Run("rejects an invalid register name", func(t *testing.T) {
+		t.Parallel()
+		path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Outputs"
+steps:
+  - id: first
+    type: command
+    register: "Not Valid"
+    command: "echo 1"
+`)
+
+		_, err := ParseConfig(path)
+		require.Error(t, err)
+	})
+}
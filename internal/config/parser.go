@@ -12,8 +12,21 @@ import (
 
 var yamlLineRegex = regexp.MustCompile(`line (\d+)`)
 
-// ParseConfig loads a configuration file from disk, validates it, and returns the resulting model.
+// ParseOptions customises how a configuration file is loaded.
+type ParseOptions struct {
+	// Vars overrides variables declared in the config's vars block.
+	Vars map[string]any
+}
+
+// ParseConfig loads a configuration file from disk, merges any included fragments,
+// validates it, and returns the resulting model.
 func ParseConfig(path string) (*Config, error) {
+	return ParseConfigWithOptions(path, ParseOptions{})
+}
+
+// ParseConfigWithOptions behaves like ParseConfig while applying the supplied options,
+// such as variable overrides from the command line.
+func ParseConfigWithOptions(path string, opts ParseOptions) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, streamyerrors.NewParseError(path, 0, err)
@@ -23,6 +36,23 @@ func ParseConfig(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, streamyerrors.NewParseError(path, extractLine(err), err)
 	}
+	setStepFile(cfg.Steps, path)
+	setStepFile(cfg.Handlers, path)
+
+	if err := resolveIncludes(path, &cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.Vars = mergeVars(cfg.Vars, opts.Vars)
+	if err := validateVarNames(cfg.Vars); err != nil {
+		return nil, err
+	}
+	if err := expandLoops(&cfg); err != nil {
+		return nil, err
+	}
+	if err := interpolateSteps(&cfg); err != nil {
+		return nil, err
+	}
 
 	if err := ValidateConfig(&cfg); err != nil {
 		return nil, err
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backoff strategies accepted by `backoff`.
+const (
+	BackoffConstant    = "constant"
+	BackoffExponential = "exponential"
+)
+
+// Error classes accepted by `retry_on` in addition to numeric exit codes.
+const (
+	RetryOnExecutionError = "execution_error"
+	RetryOnStateError     = "state_error"
+	RetryOnTimeout        = "timeout"
+	RetryOnAny            = "any"
+)
+
+// DefaultRetryDelay is the wait before the first retry when retry_delay is not set.
+const DefaultRetryDelay = time.Second
+
+var retryErrorClasses = map[string]struct{}{
+	RetryOnExecutionError: {},
+	RetryOnStateError:     {},
+	RetryOnTimeout:        {},
+	RetryOnAny:            {},
+}
+
+// defaultRetryConditions apply when a step sets retries without retry_on:
+// every failure except configuration errors is retried.
+var defaultRetryConditions = []string{RetryOnExecutionError, RetryOnStateError, RetryOnTimeout}
+
+// ParseDuration accepts Go duration strings ("500ms", "2m") or a bare number of seconds.
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("duration %q must not be negative", value)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration %q must not be negative", value)
+	}
+	return d, nil
+}
+
+// RetryDelayDuration returns the wait before the first retry.
+func (s *Step) RetryDelayDuration() time.Duration {
+	if s == nil || s.RetryDelay == "" {
+		return DefaultRetryDelay
+	}
+	d, err := ParseDuration(s.RetryDelay)
+	if err != nil {
+		return DefaultRetryDelay
+	}
+	return d
+}
+
+// RetryConditions returns the failures that trigger a retry: error classes and exit codes as strings.
+func (s *Step) RetryConditions() []string {
+	if s == nil || len(s.RetryOn) == 0 {
+		return defaultRetryConditions
+	}
+	return s.RetryOn
+}
+
+// RetryExitCodes returns the exit codes listed in retry_on.
+func (s *Step) RetryExitCodes() []int {
+	var codes []int
+	for _, condition := range s.RetryConditions() {
+		if code, err := strconv.Atoi(condition); err == nil {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+func validRetryCondition(value string) bool {
+	if _, ok := retryErrorClasses[value]; ok {
+		return true
+	}
+	code, err := strconv.Atoi(value)
+	return err == nil && code >= 0 && code <= 255
+}
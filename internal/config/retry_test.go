@@ -0,0 +1,125 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func TestParseConfig_RetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Retries"
+steps:
+  - id: install
+    type: command
+    command: "apt-get install -y curl"
+    retries: 3
+    retry_delay: 500ms
+    backoff: exponential
+    retry_on: [100, timeout]
+`)
+
+	cfg, err := ParseConfig(path)
+	require.NoError(t, err)
+
+	step := cfg.Steps[0]
+	require.Equal(t, 3, step.Retries)
+	require.Equal(t, 500*time.Millisecond, step.RetryDelayDuration())
+	require.Equal(t, BackoffExponential, step.Backoff)
+	require.Equal(t, []string{"100", RetryOnTimeout}, step.RetryConditions())
+	require.Equal(t, []int{100}, step.RetryExitCodes())
+
+	_, leaked := step.RawConfig()["retries"]
+	require.False(t, leaked)
+}
+
+func TestParseConfig_StepTimeoutAndIgnoreErrors(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Overrides"
+steps:
+  - id: compile
+    type: command
+    command: "make"
+    timeout: 1800
+  - id: optional
+    type: command
+    command: "false"
+    ignore_errors: true
+  - id: also_optional
+    type: command
+    command: "false"
+    continue_on_error: true
+`)
+
+	cfg, err := ParseConfig(path)
+	require.NoError(t, err)
+
+	steps := StepMap(cfg.Steps)
+	require.Equal(t, 1800, steps["compile"].Timeout)
+	require.False(t, steps["compile"].ContinueOnError)
+	require.True(t, steps["optional"].ContinueOnError)
+	require.True(t, steps["also_optional"].ContinueOnError)
+
+	var cmd CommandStep
+	compile := steps["compile"]
+	require.NoError(t, compile.DecodeConfig(&cmd))
+	_, leaked := compile.RawConfig()["timeout"]
+	require.False(t, leaked)
+}
+
+func TestParseConfig_RetryPolicyValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		field string
+	}{
+		{name: "retries: 50", field: "retries"},
+		{name: "retry_delay: soon", field: "retrydelay"},
+		{name: "backoff: linear", field: "backoff"},
+		{name: "retry_on: [flaky]", field: "retryon"},
+		{name: "timeout: -5", field: "timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := writeConfigFile(t, t.TempDir(), "streamy.yaml", `version: "1.0"
+name: "Retries"
+steps:
+  - id: install
+    type: command
+    command: "true"
+    `+tt.name+`
+`)
+
+			_, err := ParseConfig(path)
+			var validationErr *streamyerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			require.Contains(t, validationErr.Message, tt.field)
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	t.Parallel()
+
+	d, err := ParseDuration("1.5")
+	require.NoError(t, err)
+	require.Equal(t, 1500*time.Millisecond, d)
+
+	d, err = ParseDuration("2m")
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Minute, d)
+
+	_, err = ParseDuration("-1s")
+	require.Error(t, err)
+	require.Equal(t, DefaultRetryDelay, (&Step{}).RetryDelayDuration())
+}
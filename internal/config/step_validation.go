@@ -9,7 +9,15 @@ import (
 // ValidateStep inspects a single step for structural correctness independent of other steps.
 func ValidateStep(step Step) error {
 	v := validatorInstance()
-	if err := v.Struct(step); err != nil {
+	if step.generated {
+		// The declared parts of generated IDs were checked as step_id while they were built.
+		if err := v.StructExcept(step, "ID"); err != nil {
+			return convertValidationError(err)
+		}
+		if !stepRefPattern.MatchString(step.ID) {
+			return streamyerrors.NewValidationError("step.id", "step.id failed validation for tag 'step_ref'", nil)
+		}
+	} else if err := v.Struct(step); err != nil {
 		return convertValidationError(err)
 	}
 
@@ -22,6 +30,20 @@ func ValidateStep(step Step) error {
 		if err := v.Struct(cfg); err != nil {
 			return convertValidationError(err)
 		}
+		if err := validatePackageConfiguration(step.ID, cfg); err != nil {
+			return err
+		}
+	case "lang_package":
+		var cfg LangPackageStep
+		if err := decodeStepConfig(step, "lang_package", &cfg); err != nil {
+			return err
+		}
+		if err := v.Struct(cfg); err != nil {
+			return convertValidationError(err)
+		}
+		if err := validateLangPackageConfiguration(step.ID, cfg); err != nil {
+			return err
+		}
 	case "repo":
 		var cfg RepoStep
 		if err := decodeStepConfig(step, "repo", &cfg); err != nil {
@@ -30,6 +52,9 @@ func ValidateStep(step Step) error {
 		if err := v.Struct(cfg); err != nil {
 			return convertValidationError(err)
 		}
+		if err := validateRepoConfiguration(step.ID, cfg); err != nil {
+			return err
+		}
 	case "symlink":
 		var cfg SymlinkStep
 		if err := decodeStepConfig(step, "symlink", &cfg); err != nil {
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
@@ -13,33 +14,124 @@ var templateVarNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 
 // Config represents the full Streamy configuration document.
 type Config struct {
-	Version     string       `yaml:"version" validate:"required,semver"`
-	Name        string       `yaml:"name" validate:"required,min=1,max=100"`
-	Description string       `yaml:"description,omitempty"`
-	Settings    Settings     `yaml:"settings,omitempty"`
-	Steps       []Step       `yaml:"steps" validate:"required,min=1,dive"`
-	Validations []Validation `yaml:"validations,omitempty" validate:"omitempty,dive"`
+	Version     string         `yaml:"version" validate:"required,semver"`
+	Name        string         `yaml:"name" validate:"required,min=1,max=100"`
+	Description string         `yaml:"description,omitempty"`
+	Settings    Settings       `yaml:"settings,omitempty"`
+	Vars        map[string]any `yaml:"vars,omitempty"`
+	Include     []Include      `yaml:"include,omitempty" validate:"omitempty,dive"`
+	Imports     []Include      `yaml:"imports,omitempty" validate:"omitempty,dive"`
+	Steps       []Step         `yaml:"steps" validate:"required,min=1,dive"`
+	Handlers    []Step         `yaml:"handlers,omitempty" validate:"omitempty,dive"`
+	Hooks       Hooks          `yaml:"hooks,omitempty"`
+	Validations []Validation   `yaml:"validations,omitempty" validate:"omitempty,dive"`
+
+	sources []string
+}
+
+// SourceFiles returns the configuration file followed by every file pulled in through includes.
+func (c *Config) SourceFiles() []string {
+	if c == nil {
+		return nil
+	}
+	return append([]string(nil), c.sources...)
+}
+
+// Include references a configuration fragment whose steps and validations are merged into the pipeline.
+type Include struct {
+	Path      string `yaml:"path" validate:"required"`
+	Namespace string `yaml:"namespace,omitempty" validate:"omitempty,step_id"`
+
+	line int
+}
+
+// UnmarshalYAML accepts either a bare path string or a mapping with path and namespace keys.
+func (i *Include) UnmarshalYAML(value *yaml.Node) error {
+	i.line = value.Line
+	if value.Kind == yaml.ScalarNode {
+		i.Path = value.Value
+		return nil
+	}
+
+	type rawInclude Include
+	var temp rawInclude
+	if err := value.Decode(&temp); err != nil {
+		return err
+	}
+	i.Path = temp.Path
+	i.Namespace = temp.Namespace
+	return nil
+}
+
+// Location identifies where a step was declared.
+type Location struct {
+	File string
+	Line int
+}
+
+// String renders the location as file:line, omitting unknown parts.
+func (l Location) String() string {
+	switch {
+	case l.File == "":
+		return ""
+	case l.Line > 0:
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	default:
+		return l.File
+	}
 }
 
 // Settings holds global execution parameters.
 type Settings struct {
-	Parallel        int  `yaml:"parallel,omitempty" validate:"omitempty,min=1,max=32"`
-	Timeout         int  `yaml:"timeout,omitempty" validate:"omitempty,min=1,max=360000"`
-	ContinueOnError bool `yaml:"continue_on_error,omitempty"`
-	DryRun          bool `yaml:"dry_run,omitempty"`
-	Verbose         bool `yaml:"verbose,omitempty"`
+	Parallel            int    `yaml:"parallel,omitempty" validate:"omitempty,min=1,max=32"`
+	Timeout             int    `yaml:"timeout,omitempty" validate:"omitempty,min=1,max=360000"`
+	ContinueOnError     bool   `yaml:"continue_on_error,omitempty"`
+	DryRun              bool   `yaml:"dry_run,omitempty"`
+	Verbose             bool   `yaml:"verbose,omitempty"`
+	OnSkippedDependency string `yaml:"on_skipped_dependency,omitempty" validate:"omitempty,oneof=skip run fail"`
 }
 
 // Step describes an individual unit of work in the DAG.
 type Step struct {
-	ID            string   `yaml:"id" validate:"required,step_id"`
-	Name          string   `yaml:"name,omitempty"`
-	Type          string   `yaml:"type" validate:"required,oneof=package repo symlink copy command template line_in_file"`
-	DependsOn     []string `yaml:"depends_on,omitempty"`
-	Enabled       bool     `yaml:"enabled,omitempty"`
-	VerifyTimeout int      `yaml:"verify_timeout,omitempty" validate:"omitempty,min=1,max=600"`
+	ID              string   `yaml:"id" validate:"required,step_id"`
+	Name            string   `yaml:"name,omitempty"`
+	Type            string   `yaml:"type" validate:"required,oneof=package lang_package repo symlink copy command template line_in_file"`
+	DependsOn       []string `yaml:"depends_on,omitempty" validate:"omitempty,dive,step_ref"`
+	Enabled         bool     `yaml:"enabled,omitempty"`
+	When            string   `yaml:"when,omitempty"`
+	Register        string   `yaml:"register,omitempty" validate:"omitempty,step_id"`
+	VerifyTimeout   int      `yaml:"verify_timeout,omitempty" validate:"omitempty,min=1,max=600"`
+	Timeout         int      `yaml:"timeout,omitempty" validate:"omitempty,min=1,max=360000"`
+	ContinueOnError bool     `yaml:"continue_on_error,omitempty"`
+	Priority        int      `yaml:"priority,omitempty" validate:"omitempty,min=-100,max=100"`
+	Retries         int      `yaml:"retries,omitempty" validate:"omitempty,min=0,max=20"`
+	RetryDelay      string   `yaml:"retry_delay,omitempty" validate:"omitempty,duration"`
+	Backoff         string   `yaml:"backoff,omitempty" validate:"omitempty,oneof=constant exponential"`
+	RetryOn         []string `yaml:"retry_on,omitempty" validate:"omitempty,dive,retry_condition"`
+	// Tags group steps for selective runs with --tags and --skip-tags.
+	Tags []string `yaml:"tags,omitempty" validate:"omitempty,dive,required"`
+	// Notify lists handlers to run at the end of the run if this step changed something.
+	Notify []string `yaml:"notify,omitempty" validate:"omitempty,dive,required"`
+	// Locks names resources the step holds while it runs (`lock:` or its alias `resource:`); steps
+	// sharing a resource never run at the same time.
+	Locks []string `yaml:"lock,omitempty" validate:"omitempty,dive,required"`
+	// Loop holds the list or map the step is expanded over (`loop:` or its alias `with_items:`).
+	// It is cleared on the generated instances once the config has been parsed.
+	Loop any `yaml:"loop,omitempty"`
+
+	rawConfig   map[string]any
+	location    Location
+	registerKey string
+	// generated marks IDs built by includes and loops, which carry namespaces and loop keys.
+	generated bool
+}
 
-	rawConfig map[string]any
+// Location reports the file and line the step was declared at, when it was parsed from disk.
+func (s *Step) Location() Location {
+	if s == nil {
+		return Location{}
+	}
+	return s.location
 }
 
 // UnmarshalYAML customises step decoding to populate type-specific structures without conflicts.
@@ -50,7 +142,23 @@ func (s *Step) UnmarshalYAML(value *yaml.Node) error {
 		Type          string   `yaml:"type"`
 		DependsOn     []string `yaml:"depends_on"`
 		Enabled       *bool    `yaml:"enabled"`
+		When          string   `yaml:"when"`
+		Register      string   `yaml:"register"`
 		VerifyTimeout *int     `yaml:"verify_timeout"`
+		Timeout       int      `yaml:"timeout"`
+		ContinueOn    bool     `yaml:"continue_on_error"`
+		IgnoreErrors  bool     `yaml:"ignore_errors"`
+		Priority      int      `yaml:"priority"`
+		Retries       int      `yaml:"retries"`
+		RetryDelay    string   `yaml:"retry_delay"`
+		Backoff       string   `yaml:"backoff"`
+		RetryOn       []string `yaml:"retry_on"`
+		Tags          []string `yaml:"tags"`
+		Notify        []string `yaml:"notify"`
+		Lock          nameList `yaml:"lock"`
+		Resource      nameList `yaml:"resource"`
+		Loop          any      `yaml:"loop"`
+		WithItems     any      `yaml:"with_items"`
 	}
 
 	var base baseStep
@@ -62,6 +170,22 @@ func (s *Step) UnmarshalYAML(value *yaml.Node) error {
 	s.Name = base.Name
 	s.Type = base.Type
 	s.DependsOn = append([]string(nil), base.DependsOn...)
+	s.When = base.When
+	s.Register = base.Register
+	s.Timeout = base.Timeout
+	s.ContinueOnError = base.ContinueOn || base.IgnoreErrors
+	s.Priority = base.Priority
+	s.Retries = base.Retries
+	s.RetryDelay = base.RetryDelay
+	s.Backoff = base.Backoff
+	s.RetryOn = append([]string(nil), base.RetryOn...)
+	s.Tags = append([]string(nil), base.Tags...)
+	s.Notify = append([]string(nil), base.Notify...)
+	s.Locks = append(append([]string(nil), base.Lock...), base.Resource...)
+	s.Loop = base.Loop
+	if s.Loop == nil {
+		s.Loop = base.WithItems
+	}
 	if base.Enabled != nil {
 		s.Enabled = *base.Enabled
 	} else {
@@ -74,6 +198,7 @@ func (s *Step) UnmarshalYAML(value *yaml.Node) error {
 	}
 
 	s.rawConfig = extractRawConfig(value)
+	s.location = Location{Line: value.Line}
 	return nil
 }
 
@@ -114,19 +239,171 @@ func validateTemplateConfiguration(stepID string, cfg TemplateStep) error {
 	return nil
 }
 
-// PackageStep installs one or more system packages.
+// Package states accepted by PackageStep.State.
+const (
+	PackageStatePresent = "present"
+	PackageStateAbsent  = "absent"
+	PackageStateLatest  = "latest"
+)
+
+// PackageStep installs, upgrades or removes one or more system packages.
 type PackageStep struct {
 	Packages []string `yaml:"packages" validate:"required,min=1,dive,min=1,max=100"`
-	Manager  string   `yaml:"manager,omitempty"`
-	Update   bool     `yaml:"update,omitempty"`
+	// Manager picks the package manager; the first one found on the host is used when empty.
+	Manager string `yaml:"manager,omitempty" validate:"omitempty,oneof=apt dnf yum pacman apk zypper brew"`
+	// Update refreshes the manager's package index before installing.
+	Update bool `yaml:"update,omitempty"`
+	// State is present (the default), absent to remove the packages, or latest to also upgrade
+	// them whenever a newer version is available.
+	State string `yaml:"state,omitempty" validate:"omitempty,oneof=present absent latest"`
+	// Versions pins packages, by name, to a version. A trailing "*" accepts any version starting
+	// with the rest, e.g. "8.5*".
+	Versions map[string]string `yaml:"versions,omitempty"`
+	// Hold, when set, holds the packages at their installed version (true) or releases a hold
+	// (false); unset leaves holds alone.
+	Hold *bool `yaml:"hold,omitempty"`
+}
+
+func validatePackageConfiguration(stepID string, cfg PackageStep) error {
+	for name, version := range cfg.Versions {
+		if !slices.Contains(cfg.Packages, name) {
+			return streamyerrors.NewValidationError(stepID, fmt.Sprintf("version pinned for %q, which is not in packages", name), nil)
+		}
+		if strings.TrimSpace(version) == "" {
+			return streamyerrors.NewValidationError(stepID, fmt.Sprintf("version for %q is empty", name), nil)
+		}
+	}
+	if len(cfg.Versions) > 0 && (cfg.State == PackageStateAbsent || cfg.State == PackageStateLatest) {
+		return streamyerrors.NewValidationError(stepID, fmt.Sprintf("versions cannot be pinned with state %s", cfg.State), nil)
+	}
+	if cfg.Hold != nil && cfg.State == PackageStateAbsent {
+		return streamyerrors.NewValidationError(stepID, "hold cannot be combined with state absent", nil)
+	}
+	return nil
+}
+
+// Lang package states accepted by LangPackageStep.State.
+const (
+	LangPackageStatePresent = "present"
+	LangPackageStateLatest  = "latest"
+)
+
+// LangPackageStep installs packages with a language ecosystem's package manager.
+type LangPackageStep struct {
+	// Manager is the ecosystem tool: pipx, pip, npm (global), cargo, go (go install) or gem.
+	Manager string `yaml:"manager" validate:"required,oneof=pipx pip npm cargo go gem"`
+	// Packages are package names, or package paths for go ("golang.org/x/tools/gopls").
+	Packages []string `yaml:"packages" validate:"required,min=1,dive,min=1,max=200"`
+	// State is present (the default), or latest to also upgrade packages when a newer version
+	// is published.
+	State string `yaml:"state,omitempty" validate:"omitempty,oneof=present latest"`
+	// Versions pins packages, by name, to a version. A trailing "*" accepts any version starting
+	// with the rest.
+	Versions map[string]string `yaml:"versions,omitempty"`
+	// Venv installs pip packages into the virtual environment at this path, creating it when
+	// missing, instead of the user's site-packages.
+	Venv string `yaml:"venv,omitempty"`
+}
+
+func validateLangPackageConfiguration(stepID string, cfg LangPackageStep) error {
+	for name, version := range cfg.Versions {
+		if !slices.Contains(cfg.Packages, name) {
+			return streamyerrors.NewValidationError(stepID, fmt.Sprintf("version pinned for %q, which is not in packages", name), nil)
+		}
+		if strings.TrimSpace(version) == "" {
+			return streamyerrors.NewValidationError(stepID, fmt.Sprintf("version for %q is empty", name), nil)
+		}
+	}
+	if len(cfg.Versions) > 0 && cfg.State == LangPackageStateLatest {
+		return streamyerrors.NewValidationError(stepID, "versions cannot be pinned with state latest", nil)
+	}
+	if cfg.Venv != "" && cfg.Manager != "pip" {
+		return streamyerrors.NewValidationError(stepID, "venv is only supported with manager pip", nil)
+	}
+	return nil
 }
 
-// RepoStep clones a git repository.
+// Policies accepted by RepoStep.OnDirty.
+const (
+	RepoOnDirtyFail  = "fail"
+	RepoOnDirtyStash = "stash"
+	RepoOnDirtyReset = "reset"
+)
+
+// RepoStep clones a git repository and keeps it at a branch tip, tag or commit.
 type RepoStep struct {
 	URL         string `yaml:"url" validate:"required,git_url"`
 	Destination string `yaml:"destination" validate:"required"`
 	Branch      string `yaml:"branch,omitempty"`
 	Depth       int    `yaml:"depth,omitempty" validate:"omitempty,min=0"`
+	// Ref pins the checkout to a tag or commit SHA, leaving HEAD detached there.
+	Ref string `yaml:"ref,omitempty"`
+	// Update fast-forwards an existing clone to the tip of the remote branch.
+	Update bool `yaml:"update,omitempty"`
+	// OnDirty says what to do with uncommitted changes before moving HEAD: fail (the default),
+	// stash them, or reset them away.
+	OnDirty string `yaml:"on_dirty,omitempty" validate:"omitempty,oneof=fail stash reset"`
+	// Submodules set to recursive initialises and checks out submodules at every depth.
+	Submodules string `yaml:"submodules,omitempty" validate:"omitempty,oneof=recursive"`
+	// SparsePaths limits the checkout to these directories, relative to the repository root.
+	SparsePaths []string  `yaml:"sparse_paths,omitempty" validate:"omitempty,dive,required"`
+	Auth        *RepoAuth `yaml:"auth,omitempty"`
+}
+
+// RepoAuth holds the credentials used to clone and fetch a repository. Secrets are read from
+// environment variables so they never appear in the configuration.
+type RepoAuth struct {
+	// SSHKey is a private key file for ssh URLs, and PassphraseEnv names the variable holding
+	// its passphrase when it has one.
+	SSHKey        string `yaml:"ssh_key,omitempty"`
+	PassphraseEnv string `yaml:"passphrase_env,omitempty"`
+	// TokenEnv names the variable holding a token sent as the HTTP basic auth password.
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// Username defaults to the user in the URL, then to "git".
+	Username string `yaml:"username,omitempty"`
+	// KnownHosts replaces SSH_KNOWN_HOSTS and ~/.ssh/known_hosts for checking the server's key;
+	// InsecureIgnoreHostKey skips the check.
+	KnownHosts            string `yaml:"known_hosts,omitempty"`
+	InsecureIgnoreHostKey bool   `yaml:"insecure_ignore_host_key,omitempty"`
+}
+
+func validateRepoConfiguration(stepID string, cfg RepoStep) error {
+	if cfg.Ref != "" {
+		if cfg.Update {
+			return streamyerrors.NewValidationError(stepID, "ref cannot be combined with update", nil)
+		}
+		if cfg.Branch != "" {
+			return streamyerrors.NewValidationError(stepID, "ref cannot be combined with branch", nil)
+		}
+		if cfg.Depth > 0 {
+			// A shallow clone may not contain the pinned commit.
+			return streamyerrors.NewValidationError(stepID, "ref cannot be combined with depth", nil)
+		}
+	}
+
+	for _, path := range cfg.SparsePaths {
+		clean := strings.Trim(path, "/")
+		if clean == "" || strings.HasPrefix(path, "/") || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(clean, "/../") {
+			return streamyerrors.NewValidationError(stepID, fmt.Sprintf("sparse path %q must be a directory inside the repository", path), nil)
+		}
+	}
+
+	if auth := cfg.Auth; auth != nil {
+		httpURL := strings.HasPrefix(cfg.URL, "http://") || strings.HasPrefix(cfg.URL, "https://")
+		switch {
+		case auth.SSHKey != "" && auth.TokenEnv != "":
+			return streamyerrors.NewValidationError(stepID, "auth.ssh_key cannot be combined with auth.token_env", nil)
+		case auth.PassphraseEnv != "" && auth.SSHKey == "":
+			return streamyerrors.NewValidationError(stepID, "auth.passphrase_env requires auth.ssh_key", nil)
+		case auth.KnownHosts != "" && auth.InsecureIgnoreHostKey:
+			return streamyerrors.NewValidationError(stepID, "auth.known_hosts cannot be combined with auth.insecure_ignore_host_key", nil)
+		case auth.TokenEnv != "" && !httpURL:
+			return streamyerrors.NewValidationError(stepID, "auth.token_env requires an http or https url", nil)
+		case httpURL && (auth.SSHKey != "" || auth.KnownHosts != "" || auth.InsecureIgnoreHostKey):
+			return streamyerrors.NewValidationError(stepID, "ssh settings in auth require an ssh url", nil)
+		}
+	}
+	return nil
 }
 
 // SymlinkStep creates a symbolic link.
@@ -231,6 +508,22 @@ func StepMap(steps []Step) map[string]Step {
 	return out
 }
 
+// nameList decodes either a single name or a list of names.
+type nameList []string
+
+func (l *nameList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = nameList{value.Value}
+		return nil
+	}
+	var names []string
+	if err := value.Decode(&names); err != nil {
+		return err
+	}
+	*l = names
+	return nil
+}
+
 func hasYAMLKey(node *yaml.Node, key string) bool {
 	if node == nil || node.Kind != yaml.MappingNode {
 		return false
@@ -312,12 +605,28 @@ func extractRawConfig(node *yaml.Node) map[string]any {
 
 	// Base keys to remove case-insensitively
 	baseKeys := map[string]bool{
-		"id":             true,
-		"name":           true,
-		"type":           true,
-		"depends_on":     true,
-		"enabled":        true,
-		"verify_timeout": true,
+		"id":                true,
+		"name":              true,
+		"type":              true,
+		"depends_on":        true,
+		"enabled":           true,
+		"when":              true,
+		"register":          true,
+		"verify_timeout":    true,
+		"timeout":           true,
+		"continue_on_error": true,
+		"ignore_errors":     true,
+		"priority":          true,
+		"retries":           true,
+		"retry_delay":       true,
+		"backoff":           true,
+		"retry_on":          true,
+		"tags":              true,
+		"notify":            true,
+		"lock":              true,
+		"resource":          true,
+		"loop":              true,
+		"with_items":        true,
 	}
 
 	// Remove base keys case-insensitively
@@ -210,6 +210,24 @@ command: echo standalone
 		require.Empty(t, step.DependsOn)
 	})
 
+	t.Run("unmarshals lock and resource names", func(t *testing.T) {
+		t.Parallel()
+		yamlStr := `
+id: append_alias
+type: command
+command: echo alias >> ~/.bashrc
+lock: bashrc
+resource: [dotfiles, shell]
+`
+		var step Step
+		err := yaml.Unmarshal([]byte(yamlStr), &step)
+		require.NoError(t, err)
+		require.Equal(t, []string{"bashrc", "dotfiles", "shell"}, step.Locks)
+		raw := step.RawConfig()
+		require.NotContains(t, raw, "lock")
+		require.NotContains(t, raw, "resource")
+	})
+
 	t.Run("handles malformed yaml", func(t *testing.T) {
 		t.Parallel()
 		yamlStr := `
@@ -243,6 +261,12 @@ func TestStepSetConfigRoundTrip(t *testing.T) {
 			stepType: "package",
 			cfg:      PackageStep{Packages: []string{"git", "curl"}, Manager: "apt", Update: true},
 		},
+		{
+			name:     "lang package step",
+			stepID:   "lang",
+			stepType: "lang_package",
+			cfg:      LangPackageStep{Manager: "pip", Packages: []string{"black"}, Versions: map[string]string{"black": "24.1.0"}, Venv: "/tmp/venv"},
+		},
 		{
 			name:     "repo step",
 			stepID:   "repo",
@@ -778,3 +802,104 @@ mode: 0644
 		require.Equal(t, uint32(0o644), *template.Mode)
 	})
 }
+
+func TestValidatePackageConfiguration(t *testing.T) {
+	t.Parallel()
+
+	hold := true
+	tests := []struct {
+		name    string
+		cfg     PackageStep
+		wantErr string
+	}{
+		{name: "pinned versions", cfg: PackageStep{Packages: []string{"curl", "jq"}, Versions: map[string]string{"curl": "8.5*"}}},
+		{name: "hold with latest", cfg: PackageStep{Packages: []string{"curl"}, State: PackageStateLatest, Hold: &hold}},
+		{name: "version for unlisted package", cfg: PackageStep{Packages: []string{"curl"}, Versions: map[string]string{"jq": "1.7"}}, wantErr: `version pinned for "jq"`},
+		{name: "empty version", cfg: PackageStep{Packages: []string{"curl"}, Versions: map[string]string{"curl": " "}}, wantErr: `version for "curl" is empty`},
+		{name: "versions with absent", cfg: PackageStep{Packages: []string{"curl"}, State: PackageStateAbsent, Versions: map[string]string{"curl": "8.5"}}, wantErr: "cannot be pinned with state absent"},
+		{name: "versions with latest", cfg: PackageStep{Packages: []string{"curl"}, State: PackageStateLatest, Versions: map[string]string{"curl": "8.5"}}, wantErr: "cannot be pinned with state latest"},
+		{name: "hold with absent", cfg: PackageStep{Packages: []string{"curl"}, State: PackageStateAbsent, Hold: &hold}, wantErr: "hold cannot be combined with state absent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validatePackageConfiguration("pkgs", tt.cfg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateLangPackageConfiguration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     LangPackageStep
+		wantErr string
+	}{
+		{name: "pinned versions", cfg: LangPackageStep{Manager: "npm", Packages: []string{"typescript", "prettier"}, Versions: map[string]string{"typescript": "5.4*"}}},
+		{name: "pip venv", cfg: LangPackageStep{Manager: "pip", Packages: []string{"black"}, Venv: "/opt/venvs/tools"}},
+		{name: "version for unlisted package", cfg: LangPackageStep{Manager: "cargo", Packages: []string{"ripgrep"}, Versions: map[string]string{"bat": "0.24.0"}}, wantErr: `version pinned for "bat"`},
+		{name: "empty version", cfg: LangPackageStep{Manager: "gem", Packages: []string{"rake"}, Versions: map[string]string{"rake": ""}}, wantErr: `version for "rake" is empty`},
+		{name: "versions with latest", cfg: LangPackageStep{Manager: "pipx", Packages: []string{"black"}, State: LangPackageStateLatest, Versions: map[string]string{"black": "24.1.0"}}, wantErr: "cannot be pinned with state latest"},
+		{name: "venv without pip", cfg: LangPackageStep{Manager: "pipx", Packages: []string{"black"}, Venv: "/tmp/venv"}, wantErr: "venv is only supported with manager pip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateLangPackageConfiguration("tools", tt.cfg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateRepoConfiguration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     RepoStep
+		wantErr string
+	}{
+		{name: "tag pin", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", Ref: "v1.2.0", OnDirty: RepoOnDirtyStash}},
+		{name: "tracking branch", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", Branch: "main", Depth: 1, Update: true}},
+		{name: "ref with update", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", Ref: "v1.2.0", Update: true}, wantErr: "ref cannot be combined with update"},
+		{name: "ref with branch", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", Ref: "v1.2.0", Branch: "main"}, wantErr: "ref cannot be combined with branch"},
+		{name: "ref with depth", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", Ref: "v1.2.0", Depth: 1}, wantErr: "ref cannot be combined with depth"},
+		{name: "sparse paths", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", SparsePaths: []string{"services/api", "docs/"}}},
+		{name: "absolute sparse path", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", SparsePaths: []string{"/etc"}}, wantErr: `sparse path "/etc" must be a directory inside the repository`},
+		{name: "escaping sparse path", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", SparsePaths: []string{"a/../../b"}}, wantErr: "must be a directory inside the repository"},
+		{name: "ssh key", cfg: RepoStep{URL: "git@example.com:org/repo.git", Destination: "/tmp/repo", Auth: &RepoAuth{SSHKey: "~/.ssh/deploy", PassphraseEnv: "DEPLOY_PASSPHRASE", KnownHosts: "/etc/streamy/known_hosts"}}},
+		{name: "http token", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", Auth: &RepoAuth{TokenEnv: "GIT_TOKEN", Username: "oauth2"}}},
+		{name: "key and token", cfg: RepoStep{URL: "git@example.com:org/repo.git", Destination: "/tmp/repo", Auth: &RepoAuth{SSHKey: "/k", TokenEnv: "T"}}, wantErr: "auth.ssh_key cannot be combined with auth.token_env"},
+		{name: "passphrase without key", cfg: RepoStep{URL: "git@example.com:org/repo.git", Destination: "/tmp/repo", Auth: &RepoAuth{PassphraseEnv: "P"}}, wantErr: "auth.passphrase_env requires auth.ssh_key"},
+		{name: "known hosts and insecure", cfg: RepoStep{URL: "git@example.com:org/repo.git", Destination: "/tmp/repo", Auth: &RepoAuth{KnownHosts: "/k", InsecureIgnoreHostKey: true}}, wantErr: "auth.known_hosts cannot be combined"},
+		{name: "token over ssh", cfg: RepoStep{URL: "git@example.com:org/repo.git", Destination: "/tmp/repo", Auth: &RepoAuth{TokenEnv: "T"}}, wantErr: "auth.token_env requires an http or https url"},
+		{name: "key over https", cfg: RepoStep{URL: "https://example.com/repo.git", Destination: "/tmp/repo", Auth: &RepoAuth{SSHKey: "/k"}}, wantErr: "ssh settings in auth require an ssh url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateRepoConfiguration("repo", tt.cfg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
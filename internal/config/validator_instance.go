@@ -15,6 +15,7 @@ var (
 
 	semverPattern   = regexp.MustCompile(`^\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z-.]+)?(?:\+[0-9A-Za-z-.]+)?$`)
 	stepIDPattern   = regexp.MustCompile(`^[a-z0-9_-]+$`)
+	stepRefPattern  = regexp.MustCompile(`^[a-z0-9_-]+(?:/[a-z0-9_-]+)*(?:\[[A-Za-z0-9_.-]+\])?$`)
 	sshGitPattern   = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+:[a-zA-Z0-9._/~-]+$`)
 	validationTypes = map[string]struct{}{"command_exists": {}, "file_exists": {}, "path_contains": {}}
 )
@@ -32,6 +33,21 @@ func validatorInstance() *validator.Validate {
 			return stepIDPattern.MatchString(fl.Field().String())
 		})
 
+		// step_ref accepts step IDs qualified by include namespaces (e.g. "git/clone")
+		// and loop instances (e.g. "link_dotfiles[zshrc]").
+		_ = v.RegisterValidation("step_ref", func(fl validator.FieldLevel) bool {
+			return stepRefPattern.MatchString(fl.Field().String())
+		})
+
+		_ = v.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
+			_, err := ParseDuration(fl.Field().String())
+			return err == nil
+		})
+
+		_ = v.RegisterValidation("retry_condition", func(fl validator.FieldLevel) bool {
+			return validRetryCondition(fl.Field().String())
+		})
+
 		_ = v.RegisterValidation("git_url", func(fl validator.FieldLevel) bool {
 			urlStr := fl.Field().String()
 			if urlStr == "" {
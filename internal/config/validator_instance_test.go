@@ -160,6 +160,41 @@ func TestStepIDValidation(t *testing.T) {
 	}
 }
 
+func TestStepRefValidation(t *testing.T) {
+	v := GetValidator()
+
+	tests := []struct {
+		name     string
+		stepRef  string
+		expected bool
+	}{
+		{"plain id", "step_1", true},
+		{"namespaced", "git/clone", true},
+		{"nested namespace", "dotfiles/git/clone", true},
+		{"loop instance", "link_dotfiles[zshrc]", true},
+		{"namespaced loop instance", "dotfiles/link[.zshrc]", true},
+
+		{"empty", "", false},
+		{"leading slash", "/clone", false},
+		{"trailing slash", "git/", false},
+		{"empty segment", "git//clone", false},
+		{"uppercase segment", "Git/clone", false},
+		{"empty loop key", "link[]", false},
+		{"loop key mid-path", "link[a]/b", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Var(tt.stepRef, "step_ref")
+			got := err == nil
+
+			if got != tt.expected {
+				t.Errorf("step_ref validation for %q: got %v, expected %v (error: %v)", tt.stepRef, got, tt.expected, err)
+			}
+		})
+	}
+}
+
 func TestIsValidFilePath(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -3,13 +3,21 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/alexisbeaulieu97/streamy/internal/checkpoint"
 	"github.com/alexisbeaulieu97/streamy/internal/config"
 	"github.com/alexisbeaulieu97/streamy/internal/engine"
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
+	"github.com/alexisbeaulieu97/streamy/internal/hooks"
+	"github.com/alexisbeaulieu97/streamy/internal/journal"
 	"github.com/alexisbeaulieu97/streamy/internal/logger"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/planfile"
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+	"github.com/alexisbeaulieu97/streamy/internal/runlock"
 	"github.com/alexisbeaulieu97/streamy/internal/validation"
 )
 
@@ -23,6 +31,10 @@ type Service struct {
 	registry    *plugin.PluginRegistry
 	newExecutor func(log *logger.Logger) executor
 	executePlan func(execCtx *engine.ExecutionContext, plan *engine.ExecutionPlan) ([]model.StepResult, error)
+	runHandlers func(execCtx *engine.ExecutionContext, results []model.StepResult) ([]model.StepResult, error)
+	hooks       *hooks.Registry
+	// runLocks keeps other processes from running the same pipeline concurrently; nil disables locking.
+	runLocks *runlock.Manager
 }
 
 // NewService constructs a domain pipeline service.
@@ -33,20 +45,59 @@ func NewService(reg *plugin.PluginRegistry) *Service {
 			return engine.NewExecutor(log)
 		},
 		executePlan: engine.Execute,
+		runHandlers: engine.RunHandlers,
+		hooks:       hooks.NewRegistry(),
 	}
 }
 
+// Hooks returns the registry of Go functions configurations can run as hooks with `func: <name>`.
+func (s *Service) Hooks() *hooks.Registry {
+	return s.hooks
+}
+
+// SetRunLocks enables pipeline run locks: apply holds a pipeline's lock exclusively and verify
+// shares it, so concurrent Streamy processes cannot change the same pipeline at once.
+func (s *Service) SetRunLocks(locks *runlock.Manager) {
+	s.runLocks = locks
+}
+
+// LockHolders lists the processes currently holding the run lock of the pipeline at configPath.
+func (s *Service) LockHolders(configPath string) ([]runlock.Holder, error) {
+	if s.runLocks == nil {
+		return nil, nil
+	}
+	return s.runLocks.Holders(configPath)
+}
+
 // PreparedPipeline captures configuration and planning artefacts reused across operations.
 type PreparedPipeline struct {
 	Path   string
 	Config *config.Config
 	Graph  *engine.Graph
 	Plan   *engine.ExecutionPlan
+	// Vars are the overrides the config was loaded with.
+	Vars map[string]any
+	// Selection is the part of the pipeline Plan covers; Excluded lists the enabled steps it leaves out.
+	Selection engine.Selection
+	Excluded  []string
+}
+
+// PrepareOptions customises how a pipeline is loaded.
+type PrepareOptions struct {
+	// Vars overrides variables declared in the configuration.
+	Vars map[string]any
+	// Selection narrows the execution plan to part of the pipeline.
+	Selection engine.Selection
 }
 
 // Prepare loads configuration, builds the DAG and execution plan.
 func (s *Service) Prepare(configPath string) (*PreparedPipeline, error) {
-	cfg, err := config.ParseConfig(configPath)
+	return s.PrepareWithOptions(configPath, PrepareOptions{})
+}
+
+// PrepareWithOptions behaves like Prepare while applying variable overrides.
+func (s *Service) PrepareWithOptions(configPath string, opts PrepareOptions) (*PreparedPipeline, error) {
+	cfg, err := config.ParseConfigWithOptions(configPath, config.ParseOptions{Vars: opts.Vars})
 	if err != nil {
 		return nil, err
 	}
@@ -56,16 +107,31 @@ func (s *Service) Prepare(configPath string) (*PreparedPipeline, error) {
 		return nil, err
 	}
 
-	plan, err := engine.GeneratePlan(graph)
+	selected, err := graph.Select(opts.Selection)
 	if err != nil {
 		return nil, err
 	}
 
+	plan, err := engine.GeneratePlan(selected)
+	if err != nil {
+		return nil, err
+	}
+
+	var excluded []string
+	for _, step := range cfg.Steps {
+		if _, ok := selected.Nodes[step.ID]; step.Enabled && !ok {
+			excluded = append(excluded, step.ID)
+		}
+	}
+
 	return &PreparedPipeline{
-		Path:   configPath,
-		Config: cfg,
-		Graph:  graph,
-		Plan:   plan,
+		Path:      configPath,
+		Config:    cfg,
+		Graph:     graph,
+		Plan:      plan,
+		Vars:      opts.Vars,
+		Selection: opts.Selection,
+		Excluded:  excluded,
 	}, nil
 }
 
@@ -77,6 +143,10 @@ type VerifyRequest struct {
 	Verbose        bool
 	PerStepTimeout time.Duration
 	DefaultTimeout time.Duration
+	// Parallel bounds how many steps are evaluated at once; settings.parallel (then 4) applies when zero.
+	Parallel int
+	// WaitForLock blocks until a conflicting run releases the pipeline instead of failing with runlock.ErrLocked.
+	WaitForLock bool
 }
 
 // VerifyOutcome returns verification details.
@@ -96,6 +166,12 @@ func (s *Service) Verify(ctx context.Context, req VerifyRequest) (*VerifyOutcome
 		return nil, fmt.Errorf("logger is required")
 	}
 
+	unlock, err := s.acquireRunLock(ctx, req.Logger, prepared.Path, "verify", runlock.Shared, req.WaitForLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	perStepTimeout := req.PerStepTimeout
 	if perStepTimeout <= 0 {
 		if prepared.Config != nil && prepared.Config.Settings.Timeout > 0 {
@@ -107,18 +183,48 @@ func (s *Service) Verify(ctx context.Context, req VerifyRequest) (*VerifyOutcome
 		}
 	}
 
+	parallel := req.Parallel
+	if parallel <= 0 && prepared.Config != nil {
+		parallel = prepared.Config.Settings.Parallel
+	}
+	if parallel <= 0 {
+		parallel = 4
+	}
+
 	execCtx := &engine.ExecutionContext{
-		Config:   prepared.Config,
-		DryRun:   true,
-		Verbose:  req.Verbose,
-		Logger:   req.Logger,
-		Context:  ctx,
-		Registry: s.registry,
+		Config:     prepared.Config,
+		DryRun:     true,
+		Verbose:    req.Verbose,
+		WorkerPool: make(chan struct{}, parallel),
+		Logger:     req.Logger,
+		Context:    ctx,
+		Registry:   s.registry,
+		Facts:      facts.NewCache(nil),
+		Selection:  prepared.Selection,
+	}
+
+	hookSummary := hooks.Summary{
+		Operation:  "verify",
+		Pipeline:   prepared.Config.Name,
+		ConfigPath: prepared.Path,
+		DryRun:     true,
+		StartedAt:  time.Now(),
+	}
+	if err := s.runHooks(ctx, req.Logger, config.HookBeforeVerify, prepared.Config.Hooks, hookSummary); err != nil {
+		return nil, err
 	}
 
 	executor := s.newExecutor(req.Logger)
 	summary, verifyErr := executor.VerifySteps(execCtx, prepared.Config.Steps, perStepTimeout)
 
+	hookSummary = finishedSummary(hookSummary, verifyErr)
+	if summary != nil {
+		for _, res := range summary.Results {
+			hookSummary.Steps = append(hookSummary.Steps, hooks.StepSummary{ID: res.StepID, Status: string(res.Status), Message: res.Message})
+		}
+	}
+	s.runAfterHooks(ctx, req.Logger, prepared.Config.Hooks, hookSummary, config.HookAfterVerify)
+
 	outcome := &VerifyOutcome{
 		Prepared: prepared,
 		Summary:  summary,
@@ -131,6 +237,95 @@ func (s *Service) Verify(ctx context.Context, req VerifyRequest) (*VerifyOutcome
 	return outcome, nil
 }
 
+// PlanRequest configures a plan run.
+type PlanRequest struct {
+	Prepared   *PreparedPipeline
+	ConfigPath string
+	Logger     *logger.Logger
+}
+
+// Plan evaluates every step as a dry run and captures the outcome, with each step's evaluated
+// state and diff, as a plan that can be saved and applied later.
+func (s *Service) Plan(ctx context.Context, req PlanRequest) (*planfile.Plan, error) {
+	return s.plan(ctx, req, facts.NewCache(nil))
+}
+
+// plan implements Plan with the host facts of factCache, so an apply checking its saved plan
+// evaluates the steps against the same facts it then applies them with.
+func (s *Service) plan(ctx context.Context, req PlanRequest, factCache *facts.Cache) (*planfile.Plan, error) {
+	prepared, err := s.ensurePrepared(req.ConfigPath, req.Prepared)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if prepared.Config == nil {
+		return nil, fmt.Errorf("prepared config is required for plan")
+	}
+
+	parallel := prepared.Config.Settings.Parallel
+	if parallel <= 0 {
+		parallel = 4
+	}
+
+	var evaluationsMu sync.Mutex
+	evaluations := make(map[string]*model.EvaluationResult)
+	events := engine.NewEventBus()
+	events.Subscribe(engine.LoggingSubscriber(req.Logger))
+	events.Subscribe(func(e engine.Event) {
+		if e.Type == engine.StepEvaluated && e.Evaluation != nil {
+			evaluationsMu.Lock()
+			evaluations[e.StepID] = e.Evaluation
+			evaluationsMu.Unlock()
+		}
+	})
+
+	results, err := s.executePlan(&engine.ExecutionContext{
+		Config:          prepared.Config,
+		DryRun:          true,
+		ContinueOnError: prepared.Config.Settings.ContinueOnError,
+		WorkerPool:      make(chan struct{}, parallel),
+		Results:         make(map[string]*model.StepResult),
+		Logger:          req.Logger,
+		Context:         ctx,
+		Registry:        s.registry,
+		Facts:           factCache,
+		Events:          events,
+	}, prepared.Plan)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := config.StepMap(prepared.Config.Steps)
+	plan := &planfile.Plan{
+		FormatVersion: planfile.FormatVersion,
+		ConfigPath:    prepared.Path,
+		ConfigHash:    prepared.Config.Hash(),
+		CreatedAt:     time.Now(),
+		Vars:          prepared.Vars,
+		Steps:         make([]planfile.Step, 0, len(results)),
+	}
+	for _, res := range results {
+		step := planfile.Step{
+			ID:         res.StepID,
+			Type:       steps[res.StepID].Type,
+			Status:     res.Status,
+			Message:    res.Message,
+			SkipReason: res.SkipReason,
+		}
+		if eval := evaluations[res.StepID]; eval != nil {
+			step.CurrentState = eval.CurrentState
+			step.RequiresAction = eval.RequiresAction
+			step.Diff = eval.Diff
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan, nil
+}
+
 // ApplyRequest configures an apply run.
 type ApplyRequest struct {
 	Prepared        *PreparedPipeline
@@ -139,17 +334,46 @@ type ApplyRequest struct {
 	DryRunOverride  bool
 	VerboseOverride bool
 	ContinueOnError bool
-	OnStepResult    func(model.StepResult)
+	// OnStepResult is called as soon as each step finishes.
+	OnStepResult func(model.StepResult)
+	// OnHandlerResult is called as each notified handler finishes at the end of the run.
+	OnHandlerResult func(model.StepResult)
 	OnValidation    func(validation.ValidationResult)
+	// OnEvent receives every step lifecycle event while the run is in progress.
+	OnEvent func(engine.Event)
+	// JournalDir enables the undo journal: each non-dry run records its changes in a
+	// subdirectory named after the run ID.
+	JournalDir string
+	// CheckpointDir enables checkpoints: non-dry runs record each completed step there, keyed by
+	// config path, and a run that finishes cleanly removes its checkpoint.
+	CheckpointDir string
+	// Resume skips steps that completed in the previous, interrupted run with unchanged inputs.
+	Resume bool
+	// Carry holds results from earlier runs, keyed by step ID, to report instead of running those
+	// steps again. Their outputs stay available to the steps that do run; watch carries the
+	// unchanged steps registering outputs that re-applied steps read. Carried steps notify no
+	// handlers; they did when they ran.
+	Carry map[string]model.StepResult
+	// Plan, when set, is a saved plan the run must match: the steps are evaluated again first and
+	// the run is refused with planfile.ErrStale if the config or any step's state has changed.
+	Plan *planfile.Plan
+	// WaitForLock blocks until a conflicting run releases the pipeline instead of failing with runlock.ErrLocked.
+	WaitForLock bool
 }
 
 // ApplyOutcome captures apply execution details.
 type ApplyOutcome struct {
-	Prepared          *PreparedPipeline
-	Results           []model.StepResult
+	Prepared *PreparedPipeline
+	Results  []model.StepResult
+	// HandlerResults holds the handlers run at the end because a notifying step changed something.
+	HandlerResults    []model.StepResult
 	ValidationResults []validation.ValidationResult
 	ExecutionErr      error
 	ValidationErr     error
+	// RunID identifies the run's journal; empty when nothing was journaled.
+	RunID string
+	// Resumed counts the steps carried over from an interrupted run instead of being run again.
+	Resumed int
 }
 
 // Apply executes a pipeline apply operation, returning step and validation results alongside any error.
@@ -171,11 +395,58 @@ func (s *Service) Apply(ctx context.Context, req ApplyRequest) (*ApplyOutcome, e
 	effectiveVerbose := prepared.Config.Settings.Verbose || req.VerboseOverride
 	continueOnError := prepared.Config.Settings.ContinueOnError || req.ContinueOnError
 
+	// A dry run changes nothing, so it only needs to keep a real apply from starting underneath it.
+	lockMode := runlock.Exclusive
+	if effectiveDryRun {
+		lockMode = runlock.Shared
+	}
+	unlock, err := s.acquireRunLock(ctx, req.Logger, prepared.Path, "apply", lockMode, req.WaitForLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Steps, handlers and the saved plan check all see the host as it was when the run started.
+	factCache := facts.NewCache(nil)
+	if req.Plan != nil {
+		current, err := s.plan(ctx, PlanRequest{Prepared: prepared, Logger: req.Logger}, factCache)
+		if err != nil {
+			return nil, err
+		}
+		if err := planfile.Compare(req.Plan, current); err != nil {
+			return nil, err
+		}
+	}
+
+	hookSummary := hooks.Summary{
+		Operation:  "apply",
+		Pipeline:   prepared.Config.Name,
+		ConfigPath: prepared.Path,
+		DryRun:     effectiveDryRun,
+		StartedAt:  time.Now(),
+	}
+	if err := s.runHooks(ctx, req.Logger, config.HookBeforeApply, prepared.Config.Hooks, hookSummary); err != nil {
+		return nil, err
+	}
+
 	parallel := prepared.Config.Settings.Parallel
 	if parallel <= 0 {
 		parallel = 4
 	}
 
+	events := engine.NewEventBus()
+	events.Subscribe(engine.LoggingSubscriber(req.Logger))
+	if req.OnEvent != nil {
+		events.Subscribe(req.OnEvent)
+	}
+	if req.OnStepResult != nil {
+		events.Subscribe(func(e engine.Event) {
+			if e.Type == engine.StepFinished && e.Result != nil {
+				req.OnStepResult(*e.Result)
+			}
+		})
+	}
+
 	execCtx := &engine.ExecutionContext{
 		Config:          prepared.Config,
 		DryRun:          effectiveDryRun,
@@ -186,13 +457,83 @@ func (s *Service) Apply(ctx context.Context, req ApplyRequest) (*ApplyOutcome, e
 		Logger:          req.Logger,
 		Context:         ctx,
 		Registry:        s.registry,
+		Facts:           factCache,
+		Events:          events,
+	}
+
+	var recorder *checkpoint.Recorder
+	if req.CheckpointDir != "" {
+		store := checkpoint.NewStore(req.CheckpointDir)
+		if req.Resume {
+			previous, err := store.Load(prepared.Path)
+			if err != nil {
+				return nil, err
+			}
+			if previous == nil {
+				req.Logger.Warn("no interrupted run to resume; applying all steps")
+			} else {
+				if previous.ConfigHash != prepared.Config.Hash() {
+					req.Logger.Info("configuration changed since the interrupted run; re-running changed steps and their dependents")
+				}
+				execCtx.Resume = previous.Resumable(prepared.Config.Steps)
+			}
+		}
+		if !effectiveDryRun {
+			recorder, err = checkpoint.NewRecorder(store, prepared.Path, prepared.Config)
+			if err != nil {
+				return nil, err
+			}
+			events.Subscribe(func(e engine.Event) {
+				if e.Type != engine.StepFinished || e.Result == nil {
+					return
+				}
+				if err := recorder.Record(*e.Result); err != nil {
+					req.Logger.Warn(fmt.Sprintf("failed to update checkpoint: %v", err))
+				}
+			})
+		}
+	}
+
+	resumed := len(execCtx.Resume)
+	for id, res := range req.Carry {
+		if execCtx.Resume == nil {
+			execCtx.Resume = make(map[string]model.StepResult, len(req.Carry))
+		}
+		res.Carried = true
+		execCtx.Resume[id] = res
+	}
+
+	if req.JournalDir != "" && !effectiveDryRun {
+		runJournal, err := journal.Create(req.JournalDir, prepared.Path)
+		if err != nil {
+			return nil, err
+		}
+		execCtx.Journal = runJournal
 	}
 
 	results, execErr := s.executePlan(execCtx, prepared.Plan)
 
-	for _, res := range results {
-		if req.OnStepResult != nil {
-			req.OnStepResult(res)
+	var handlerResults []model.StepResult
+	if execErr == nil && ctx.Err() == nil {
+		handlerResults, execErr = s.runHandlers(s.handlerContext(execCtx, req), results)
+	} else if notified := engine.NotifiedHandlers(prepared.Config, results); len(notified) > 0 {
+		req.Logger.Warn(fmt.Sprintf("run did not complete; notified handlers not run: %s", strings.Join(notified, ", ")))
+	}
+
+	if recorder != nil && execErr == nil && ctx.Err() == nil {
+		if err := recorder.Discard(); err != nil {
+			req.Logger.Warn(fmt.Sprintf("failed to remove checkpoint: %v", err))
+		}
+	}
+
+	runID := ""
+	if execCtx.Journal != nil {
+		kept, err := execCtx.Journal.Close()
+		if err != nil {
+			req.Logger.Warn(fmt.Sprintf("failed to finalise run journal: %v", err))
+		}
+		if kept {
+			runID = execCtx.Journal.ID()
 		}
 	}
 
@@ -207,12 +548,35 @@ func (s *Service) Apply(ctx context.Context, req ApplyRequest) (*ApplyOutcome, e
 		}
 	}
 
+	runErr := execErr
+	if runErr == nil {
+		runErr = validationErr
+	}
+	if runErr == nil {
+		runErr = ctx.Err()
+	}
+	hookSummary = finishedSummary(hookSummary, runErr)
+	hookSummary.RunID = runID
+	for _, res := range results {
+		hookSummary.Steps = append(hookSummary.Steps, hooks.StepSummary{ID: res.StepID, Status: res.Status, Message: res.Message})
+	}
+	for _, res := range handlerResults {
+		hookSummary.Handlers = append(hookSummary.Handlers, hooks.StepSummary{ID: res.StepID, Status: res.Status, Message: res.Message})
+	}
+	if runErr != nil {
+		s.runAfterHooks(ctx, req.Logger, prepared.Config.Hooks, hookSummary, config.HookOnFailure)
+	}
+	s.runAfterHooks(ctx, req.Logger, prepared.Config.Hooks, hookSummary, config.HookAfterApply)
+
 	outcome := &ApplyOutcome{
 		Prepared:          prepared,
 		Results:           results,
+		HandlerResults:    handlerResults,
 		ValidationResults: validationResults,
 		ExecutionErr:      execErr,
 		ValidationErr:     validationErr,
+		RunID:             runID,
+		Resumed:           resumed,
 	}
 
 	if execErr != nil {
@@ -225,6 +589,76 @@ func (s *Service) Apply(ctx context.Context, req ApplyRequest) (*ApplyOutcome, e
 	return outcome, nil
 }
 
+// acquireRunLock takes the pipeline's run lock when run locks are enabled and returns the function
+// releasing it.
+func (s *Service) acquireRunLock(ctx context.Context, log *logger.Logger, configPath, operation string, mode runlock.Mode, wait bool) (func(), error) {
+	if s.runLocks == nil {
+		return func() {}, nil
+	}
+	if wait {
+		if holders, err := s.runLocks.Holders(configPath); err == nil && len(holders) > 0 {
+			log.Info(fmt.Sprintf("waiting for the pipeline lock held by %s", holders[0]))
+		}
+	}
+	lock, err := s.runLocks.Acquire(ctx, configPath, operation, mode, wait)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := lock.Release(); err != nil {
+			log.Warn(fmt.Sprintf("failed to release pipeline lock: %v", err))
+		}
+	}, nil
+}
+
+// runHooks runs the hooks configured for event. A failure is returned so before_* hooks can abort the run.
+func (s *Service) runHooks(ctx context.Context, log *logger.Logger, event string, configured config.Hooks, summary hooks.Summary) error {
+	list := configured.ByEvent(event)
+	if len(list) == 0 {
+		return nil
+	}
+	summary.Event = event
+	return hooks.Run(ctx, s.hooks, log, list, summary)
+}
+
+// runAfterHooks runs hooks once the run is over. They still run when ctx was cancelled, so an
+// interrupted run is reported too, and their failures are logged rather than changing the outcome.
+func (s *Service) runAfterHooks(ctx context.Context, log *logger.Logger, configured config.Hooks, summary hooks.Summary, event string) {
+	if err := s.runHooks(context.WithoutCancel(ctx), log, event, configured, summary); err != nil {
+		log.Warn(err.Error())
+	}
+}
+
+// finishedSummary records the result of a run in a hook summary.
+func finishedSummary(summary hooks.Summary, err error) hooks.Summary {
+	summary.Status = "success"
+	if err != nil {
+		summary.Status = "failed"
+		summary.Error = err.Error()
+	}
+	summary.DurationMS = time.Since(summary.StartedAt).Milliseconds()
+	return summary
+}
+
+// handlerContext derives the context handlers run in. Their events go to the logger and
+// OnHandlerResult only, so they are not mistaken for pipeline steps or checkpointed: a run
+// interrupted before its handlers finish notifies them again when resumed.
+func (s *Service) handlerContext(execCtx *engine.ExecutionContext, req ApplyRequest) *engine.ExecutionContext {
+	events := engine.NewEventBus()
+	events.Subscribe(engine.LoggingSubscriber(req.Logger))
+	if req.OnHandlerResult != nil {
+		events.Subscribe(func(e engine.Event) {
+			if e.Type == engine.StepFinished && e.Result != nil {
+				req.OnHandlerResult(*e.Result)
+			}
+		})
+	}
+
+	handlerCtx := *execCtx
+	handlerCtx.Events = events
+	return &handlerCtx
+}
+
 func (s *Service) ensurePrepared(configPath string, prepared *PreparedPipeline) (*PreparedPipeline, error) {
 	if prepared != nil {
 		return prepared, nil
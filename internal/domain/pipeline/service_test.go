@@ -9,11 +9,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alexisbeaulieu97/streamy/internal/checkpoint"
 	"github.com/alexisbeaulieu97/streamy/internal/config"
 	"github.com/alexisbeaulieu97/streamy/internal/engine"
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
+	"github.com/alexisbeaulieu97/streamy/internal/hooks"
 	"github.com/alexisbeaulieu97/streamy/internal/logger"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/planfile"
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+	"github.com/alexisbeaulieu97/streamy/internal/runlock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -90,6 +95,41 @@ steps:
 	}
 }
 
+func TestService_PrepareWithSelection(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "streamy.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+version: 0.1.0
+name: test
+steps:
+  - id: base
+    type: command
+    command: "true"
+  - id: dotfiles
+    type: command
+    command: "true"
+    depends_on: [base]
+    tags: [dotfiles]
+  - id: packages
+    type: command
+    command: "true"
+    tags: [slow]
+`), 0644))
+
+	svc := NewService(nil)
+	prepared, err := svc.PrepareWithOptions(configPath, PrepareOptions{Selection: engine.Selection{Tags: []string{"dotfiles"}}})
+	require.NoError(t, err)
+
+	assert.Len(t, prepared.Config.Steps, 3)
+	assert.Len(t, prepared.Graph.Nodes, 3)
+	require.Len(t, prepared.Plan.Levels, 2)
+	assert.Equal(t, []string{"base"}, prepared.Plan.Levels[0].StepIDs)
+	assert.Equal(t, []string{"dotfiles"}, prepared.Plan.Levels[1].StepIDs)
+	assert.Equal(t, []string{"packages"}, prepared.Excluded)
+
+	_, err = svc.PrepareWithOptions(configPath, PrepareOptions{Selection: engine.Selection{Only: []string{"missing"}}})
+	assert.ErrorContains(t, err, `unknown step "missing"`)
+}
+
 func TestService_Verify(t *testing.T) {
 	// Setup a valid prepared pipeline for reuse
 	cfg := &config.Config{
@@ -248,3 +288,340 @@ func TestService_Apply(t *testing.T) {
 		})
 	}
 }
+
+func TestService_ApplyStreamsEvents(t *testing.T) {
+	cfg := &config.Config{
+		Steps: []config.Step{{ID: "step1", Type: "command"}},
+	}
+	graph, _ := engine.BuildDAG(cfg.Steps)
+	plan, _ := engine.GeneratePlan(graph)
+	prepared := &PreparedPipeline{Path: "/fake/path.yaml", Config: cfg, Graph: graph, Plan: plan}
+
+	log, err := logger.New(logger.Options{Writer: io.Discard})
+	require.NoError(t, err)
+
+	var streamed []model.StepResult
+	var events []engine.EventType
+	svc := NewService(&plugin.PluginRegistry{})
+	svc.executePlan = func(ctx *engine.ExecutionContext, plan *engine.ExecutionPlan) ([]model.StepResult, error) {
+		require.NotNil(t, ctx.Events)
+		res := model.StepResult{StepID: "step1", Status: model.StatusSuccess}
+		ctx.Events.Publish(engine.Event{Type: engine.StepStarted, StepID: "step1"})
+		ctx.Events.Publish(engine.Event{Type: engine.StepFinished, StepID: "step1", Result: &res})
+		// Results reach the caller while the run is still in progress.
+		require.Len(t, streamed, 1)
+		return []model.StepResult{res}, nil
+	}
+
+	_, err = svc.Apply(context.Background(), ApplyRequest{
+		Prepared:     prepared,
+		Logger:       log,
+		OnStepResult: func(res model.StepResult) { streamed = append(streamed, res) },
+		OnEvent:      func(e engine.Event) { events = append(events, e.Type) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []engine.EventType{engine.StepStarted, engine.StepFinished}, events)
+	assert.Len(t, streamed, 1)
+}
+
+func TestService_ApplyRunsNotifiedHandlers(t *testing.T) {
+	cfg := &config.Config{
+		Steps:    []config.Step{{ID: "render", Type: "command", Notify: []string{"restart"}}},
+		Handlers: []config.Step{{ID: "restart", Type: "command", Enabled: true}},
+	}
+	graph, _ := engine.BuildDAG(cfg.Steps)
+	plan, _ := engine.GeneratePlan(graph)
+	prepared := &PreparedPipeline{Path: "/fake/path.yaml", Config: cfg, Graph: graph, Plan: plan}
+
+	log, err := logger.New(logger.Options{Writer: io.Discard})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		execErr     error
+		wantHandler bool
+	}{
+		{name: "after a successful run", wantHandler: true},
+		{name: "not after a failed run", execErr: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled, streamed []model.StepResult
+			svc := NewService(&plugin.PluginRegistry{})
+			svc.executePlan = func(*engine.ExecutionContext, *engine.ExecutionPlan) ([]model.StepResult, error) {
+				return []model.StepResult{{StepID: "render", Status: model.StatusSuccess}}, tt.execErr
+			}
+			svc.runHandlers = func(ctx *engine.ExecutionContext, results []model.StepResult) ([]model.StepResult, error) {
+				assert.Equal(t, []string{"restart"}, engine.NotifiedHandlers(ctx.Config, results))
+				res := model.StepResult{StepID: "restart", Status: model.StatusSuccess}
+				ctx.Events.Publish(engine.Event{Type: engine.StepFinished, StepID: "restart", Result: &res})
+				return []model.StepResult{res}, nil
+			}
+
+			outcome, err := svc.Apply(context.Background(), ApplyRequest{
+				Prepared:        prepared,
+				Logger:          log,
+				OnStepResult:    func(res model.StepResult) { streamed = append(streamed, res) },
+				OnHandlerResult: func(res model.StepResult) { handled = append(handled, res) },
+			})
+			require.NotNil(t, outcome)
+			assert.Empty(t, streamed, "handler results are not reported as steps")
+			if !tt.wantHandler {
+				assert.Error(t, err)
+				assert.Empty(t, outcome.HandlerResults)
+				assert.Empty(t, handled)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, outcome.HandlerResults, 1)
+			assert.Equal(t, "restart", outcome.HandlerResults[0].StepID)
+			assert.Len(t, handled, 1)
+		})
+	}
+}
+
+func TestService_ApplyRunsLifecycleHooks(t *testing.T) {
+	log, err := logger.New(logger.Options{Writer: io.Discard})
+	require.NoError(t, err)
+
+	newPrepared := func() *PreparedPipeline {
+		cfg := &config.Config{
+			Name:  "hooked",
+			Steps: []config.Step{{ID: "step1", Type: "command"}},
+			Hooks: config.Hooks{
+				BeforeApply: []config.Hook{{Func: "record"}},
+				OnFailure:   []config.Hook{{Func: "record"}},
+				AfterApply:  []config.Hook{{Func: "record"}},
+			},
+		}
+		graph, _ := engine.BuildDAG(cfg.Steps)
+		plan, _ := engine.GeneratePlan(graph)
+		return &PreparedPipeline{Path: "/fake/path.yaml", Config: cfg, Graph: graph, Plan: plan}
+	}
+
+	t.Run("after hooks receive the outcome", func(t *testing.T) {
+		var seen []hooks.Summary
+		svc := NewService(&plugin.PluginRegistry{})
+		require.NoError(t, svc.Hooks().Register("record", func(_ context.Context, summary hooks.Summary) error {
+			seen = append(seen, summary)
+			return nil
+		}))
+		svc.executePlan = func(*engine.ExecutionContext, *engine.ExecutionPlan) ([]model.StepResult, error) {
+			return []model.StepResult{{StepID: "step1", Status: model.StatusFailed}}, errors.New("boom")
+		}
+
+		_, err := svc.Apply(context.Background(), ApplyRequest{Prepared: newPrepared(), Logger: log})
+		require.Error(t, err)
+		require.Len(t, seen, 3)
+		assert.Equal(t, config.HookBeforeApply, seen[0].Event)
+		assert.Empty(t, seen[0].Status)
+		assert.Equal(t, config.HookOnFailure, seen[1].Event)
+		assert.Equal(t, config.HookAfterApply, seen[2].Event)
+		assert.Equal(t, "failed", seen[2].Status)
+		assert.Equal(t, "boom", seen[2].Error)
+		assert.Equal(t, "hooked", seen[2].Pipeline)
+		assert.Equal(t, []hooks.StepSummary{{ID: "step1", Status: model.StatusFailed}}, seen[2].Steps)
+	})
+
+	t.Run("failing before hook aborts the run", func(t *testing.T) {
+		svc := NewService(&plugin.PluginRegistry{})
+		require.NoError(t, svc.Hooks().Register("record", func(context.Context, hooks.Summary) error {
+			return errors.New("not today")
+		}))
+		svc.executePlan = func(*engine.ExecutionContext, *engine.ExecutionPlan) ([]model.StepResult, error) {
+			t.Fatal("steps must not run when a before_apply hook fails")
+			return nil, nil
+		}
+
+		outcome, err := svc.Apply(context.Background(), ApplyRequest{Prepared: newPrepared(), Logger: log})
+		require.Error(t, err)
+		assert.Nil(t, outcome)
+		assert.Contains(t, err.Error(), `before_apply hook "record": not today`)
+	})
+}
+
+func TestService_RunLocks(t *testing.T) {
+	cfg := &config.Config{Steps: []config.Step{{ID: "step1", Type: "command"}}}
+	graph, _ := engine.BuildDAG(cfg.Steps)
+	plan, _ := engine.GeneratePlan(graph)
+	prepared := &PreparedPipeline{Path: filepath.Join(t.TempDir(), "streamy.yaml"), Config: cfg, Graph: graph, Plan: plan}
+
+	log, err := logger.New(logger.Options{Writer: io.Discard})
+	require.NoError(t, err)
+
+	locks := runlock.NewManager(filepath.Join(t.TempDir(), "locks"))
+	svc := NewService(&plugin.PluginRegistry{})
+	svc.SetRunLocks(locks)
+
+	var holders []runlock.Holder
+	svc.executePlan = func(*engine.ExecutionContext, *engine.ExecutionPlan) ([]model.StepResult, error) {
+		holders, err = svc.LockHolders(prepared.Path)
+		require.NoError(t, err)
+		return []model.StepResult{{StepID: "step1", Status: model.StatusSuccess}}, nil
+	}
+	_, err = svc.Apply(context.Background(), ApplyRequest{Prepared: prepared, Logger: log})
+	require.NoError(t, err)
+	require.Len(t, holders, 1)
+	assert.Equal(t, "apply", holders[0].Operation)
+	assert.Equal(t, runlock.Exclusive, holders[0].Mode)
+
+	released, err := svc.LockHolders(prepared.Path)
+	require.NoError(t, err)
+	assert.Empty(t, released, "the lock is released when the run ends")
+
+	other, err := locks.Acquire(context.Background(), prepared.Path, "verify", runlock.Shared, false)
+	require.NoError(t, err)
+	defer func() { _ = other.Release() }()
+
+	_, err = svc.Apply(context.Background(), ApplyRequest{Prepared: prepared, Logger: log})
+	require.ErrorIs(t, err, runlock.ErrLocked)
+
+	_, err = svc.Apply(context.Background(), ApplyRequest{Prepared: prepared, Logger: log, DryRunOverride: true})
+	require.NoError(t, err, "a dry run shares the lock")
+}
+
+func TestService_ApplyResumesFromCheckpoint(t *testing.T) {
+	cfg := &config.Config{
+		Steps: []config.Step{
+			{ID: "first", Type: "command"},
+			{ID: "second", Type: "command", DependsOn: []string{"first"}},
+		},
+	}
+	graph, _ := engine.BuildDAG(cfg.Steps)
+	plan, _ := engine.GeneratePlan(graph)
+	prepared := &PreparedPipeline{Path: "/fake/path.yaml", Config: cfg, Graph: graph, Plan: plan}
+
+	log, err := logger.New(logger.Options{Writer: io.Discard})
+	require.NoError(t, err)
+	checkpointDir := t.TempDir()
+
+	svc := NewService(&plugin.PluginRegistry{})
+	svc.executePlan = func(ctx *engine.ExecutionContext, plan *engine.ExecutionPlan) ([]model.StepResult, error) {
+		require.Empty(t, ctx.Resume)
+		res := model.StepResult{StepID: "first", Status: model.StatusSuccess, Outputs: map[string]any{"path": "/tmp/x"}}
+		ctx.Events.Publish(engine.Event{Type: engine.StepFinished, StepID: "first", Result: &res})
+		return []model.StepResult{res}, context.Canceled
+	}
+	_, err = svc.Apply(context.Background(), ApplyRequest{Prepared: prepared, Logger: log, CheckpointDir: checkpointDir})
+	require.ErrorIs(t, err, context.Canceled)
+
+	svc.executePlan = func(ctx *engine.ExecutionContext, plan *engine.ExecutionPlan) ([]model.StepResult, error) {
+		require.Len(t, ctx.Resume, 1)
+		require.Equal(t, map[string]any{"path": "/tmp/x"}, ctx.Resume["first"].Outputs)
+		return nil, nil
+	}
+	outcome, err := svc.Apply(context.Background(), ApplyRequest{Prepared: prepared, Logger: log, CheckpointDir: checkpointDir, Resume: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, outcome.Resumed)
+
+	// A run that completes leaves nothing to resume.
+	remaining, err := checkpoint.NewStore(checkpointDir).Load(prepared.Path)
+	require.NoError(t, err)
+	assert.Nil(t, remaining)
+}
+
+func TestService_PlanAndApplySavedPlan(t *testing.T) {
+	cfg := &config.Config{
+		Steps: []config.Step{
+			{ID: "dotfiles", Type: "copy"},
+			{ID: "git", Type: "package"},
+		},
+	}
+	graph, _ := engine.BuildDAG(cfg.Steps)
+	plan, _ := engine.GeneratePlan(graph)
+	prepared := &PreparedPipeline{Path: "/fake/path.yaml", Config: cfg, Graph: graph, Plan: plan, Vars: map[string]any{"env": "prod"}}
+
+	log, err := logger.New(logger.Options{Writer: io.Discard})
+	require.NoError(t, err)
+
+	gitState := model.StatusSatisfied
+	var applied bool
+	svc := NewService(&plugin.PluginRegistry{})
+	svc.executePlan = func(ctx *engine.ExecutionContext, plan *engine.ExecutionPlan) ([]model.StepResult, error) {
+		if !ctx.DryRun {
+			applied = true
+			return nil, nil
+		}
+		ctx.Events.Publish(engine.Event{Type: engine.StepEvaluated, StepID: "dotfiles", Evaluation: &model.EvaluationResult{
+			StepID: "dotfiles", CurrentState: model.StatusDrifted, RequiresAction: true, Diff: "-a\n+b",
+		}})
+		ctx.Events.Publish(engine.Event{Type: engine.StepEvaluated, StepID: "git", Evaluation: &model.EvaluationResult{
+			StepID: "git", CurrentState: gitState, RequiresAction: gitState != model.StatusSatisfied,
+		}})
+		gitStatus := model.StatusSkipped
+		if gitState != model.StatusSatisfied {
+			gitStatus = model.StatusWouldUpdate
+		}
+		return []model.StepResult{
+			{StepID: "dotfiles", Status: model.StatusWouldUpdate, Message: "copy"},
+			{StepID: "git", Status: gitStatus},
+		}, nil
+	}
+
+	saved, err := svc.Plan(context.Background(), PlanRequest{Prepared: prepared, Logger: log})
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Hash(), saved.ConfigHash)
+	assert.Equal(t, map[string]any{"env": "prod"}, saved.Vars)
+	require.Len(t, saved.Steps, 2)
+	assert.Equal(t, planfile.Step{
+		ID: "dotfiles", Type: "copy", Status: model.StatusWouldUpdate, CurrentState: model.StatusDrifted,
+		RequiresAction: true, Message: "copy", Diff: "-a\n+b",
+	}, saved.Steps[0])
+	assert.Equal(t, 1, saved.Changes())
+
+	_, err = svc.Apply(context.Background(), ApplyRequest{Prepared: prepared, Logger: log, Plan: saved})
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	// The machine drifted after planning: apply refuses before changing anything.
+	applied = false
+	gitState = model.StatusMissing
+	_, err = svc.Apply(context.Background(), ApplyRequest{Prepared: prepared, Logger: log, Plan: saved})
+	require.ErrorIs(t, err, planfile.ErrStale)
+	assert.False(t, applied)
+}
+
+func TestService_ApplySharesFactsAcrossTheRun(t *testing.T) {
+	cfg := &config.Config{
+		Steps:    []config.Step{{ID: "render", Type: "command", Notify: []string{"restart"}}},
+		Handlers: []config.Step{{ID: "restart", Type: "command", Enabled: true}},
+	}
+	graph, _ := engine.BuildDAG(cfg.Steps)
+	plan, _ := engine.GeneratePlan(graph)
+	prepared := &PreparedPipeline{Path: "/fake/path.yaml", Config: cfg, Graph: graph, Plan: plan}
+
+	log, err := logger.New(logger.Options{Writer: io.Discard})
+	require.NoError(t, err)
+
+	var caches []*facts.Cache
+	svc := NewService(&plugin.PluginRegistry{})
+	svc.executePlan = func(ctx *engine.ExecutionContext, plan *engine.ExecutionPlan) ([]model.StepResult, error) {
+		caches = append(caches, ctx.Facts)
+		return []model.StepResult{{StepID: "render", Status: model.StatusSuccess}}, nil
+	}
+	svc.runHandlers = func(ctx *engine.ExecutionContext, results []model.StepResult) ([]model.StepResult, error) {
+		caches = append(caches, ctx.Facts)
+		return nil, nil
+	}
+
+	saved, err := svc.Plan(context.Background(), PlanRequest{Prepared: prepared, Logger: log})
+	require.NoError(t, err)
+	caches = nil
+
+	_, err = svc.Apply(context.Background(), ApplyRequest{Prepared: prepared, Logger: log, Plan: saved})
+	require.NoError(t, err)
+	// The saved plan check, the steps and the handlers.
+	require.Len(t, caches, 3)
+	require.NotNil(t, caches[0])
+	assert.Same(t, caches[0], caches[1])
+	assert.Same(t, caches[0], caches[2])
+
+	// Each run gathers its own facts.
+	first := caches[0]
+	caches = nil
+	_, err = svc.Apply(context.Background(), ApplyRequest{Prepared: prepared, Logger: log})
+	require.NoError(t, err)
+	require.Len(t, caches, 2)
+	assert.NotSame(t, first, caches[0])
+}
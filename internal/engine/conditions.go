@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/expr"
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
+)
+
+// gateDecision describes whether a step may run once its condition and dependencies are considered.
+type gateDecision struct {
+	Run        bool
+	SkipReason string
+	Err        error
+}
+
+// conditionGate evaluates `when` expressions and applies the skipped-dependency policy.
+type conditionGate struct {
+	ctx     context.Context
+	execCtx *ExecutionContext
+	facts   *facts.Cache
+	outputs *OutputStore
+	policy  string
+
+	once  sync.Once
+	scope expr.Scope
+	funcs expr.Functions
+}
+
+func newConditionGate(ctx context.Context, execCtx *ExecutionContext, cache *facts.Cache, outputs *OutputStore) *conditionGate {
+	policy := config.SkippedDependencySkip
+	if execCtx.Config != nil {
+		policy = execCtx.Config.Settings.SkippedDependencyPolicy()
+	}
+	return &conditionGate{ctx: ctx, execCtx: execCtx, facts: cache, outputs: outputs, policy: policy}
+}
+
+// Scope returns the run-wide values conditions are evaluated against, gathering host facts on first use.
+func (g *conditionGate) Scope() expr.Scope {
+	g.once.Do(func() {
+		vars := map[string]any{}
+		if g.execCtx.Config != nil && g.execCtx.Config.Vars != nil {
+			vars = g.execCtx.Config.Vars
+		}
+		g.scope = expr.Scope{
+			"facts": g.facts.Get(g.ctx).Map(),
+			"env":   facts.Environment(),
+			"vars":  vars,
+		}
+		g.funcs = facts.Functions()
+	})
+	return g.scope
+}
+
+// Check decides whether step should run. skipped reports whether a dependency was skipped
+// because of a condition, along with the reason.
+func (g *conditionGate) Check(step *config.Step, skipped func(id string) (string, bool)) gateDecision {
+	condition, err := step.Condition()
+	if err != nil {
+		return gateDecision{Err: err}
+	}
+	if condition != nil {
+		scope := g.scopeWithOutputs()
+		ok, err := condition.EvalBool(scope, g.funcs)
+		if err != nil {
+			return gateDecision{Err: fmt.Errorf("evaluate condition %q: %w", step.When, err)}
+		}
+		if !ok {
+			return gateDecision{SkipReason: fmt.Sprintf("condition not met: %s", step.When)}
+		}
+	}
+
+	for _, dep := range step.DependsOn {
+		if _, wasSkipped := skipped(dep); !wasSkipped {
+			continue
+		}
+		switch g.policy {
+		case config.SkippedDependencyRun:
+			continue
+		case config.SkippedDependencyFail:
+			return gateDecision{Err: fmt.Errorf("dependency %q was skipped", dep)}
+		default:
+			return gateDecision{SkipReason: fmt.Sprintf("dependency %q was skipped", dep)}
+		}
+	}
+
+	return gateDecision{Run: true}
+}
+
+// scopeWithOutputs extends the cached scope with the outputs registered so far.
+func (g *conditionGate) scopeWithOutputs() expr.Scope {
+	base := g.Scope()
+	scope := make(expr.Scope, len(base)+1)
+	for k, v := range base {
+		scope[k] = v
+	}
+	scope[config.OutputsRoot] = g.outputs.Snapshot()
+	return scope
+}
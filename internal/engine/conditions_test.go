@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func conditionalSteps(t *testing.T) []config.Step {
+	t.Helper()
+
+	fedora := config.Step{ID: "dnf_setup", Type: "command", Enabled: true, When: `facts.distro == "fedora"`}
+	require.NoError(t, fedora.SetConfig(config.CommandStep{Command: "echo dnf"}))
+	ubuntu := config.Step{ID: "apt_setup", Type: "command", Enabled: true, When: `facts.distro in ["ubuntu", "debian"] && vars.profile != "ci"`}
+	require.NoError(t, ubuntu.SetConfig(config.CommandStep{Command: "echo apt"}))
+	after := config.Step{ID: "after_dnf", Type: "command", Enabled: true, DependsOn: []string{"dnf_setup"}}
+	require.NoError(t, after.SetConfig(config.CommandStep{Command: "echo after"}))
+	return []config.Step{fedora, ubuntu, after}
+}
+
+func runConditional(t *testing.T, policy string) ([]model.StepResult, *fakePlugin, error) {
+	t.Helper()
+
+	fp := &fakePlugin{}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(fp))
+
+	cfg := &config.Config{
+		Version:  "1.0",
+		Name:     "conditional",
+		Settings: config.Settings{OnSkippedDependency: policy},
+		Vars:     map[string]any{"profile": "laptop"},
+		Steps:    conditionalSteps(t),
+	}
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	plan, err := GeneratePlan(graph)
+	require.NoError(t, err)
+
+	results, err := Execute(&ExecutionContext{
+		Config:     cfg,
+		WorkerPool: make(chan struct{}, 2),
+		Results:    make(map[string]*model.StepResult),
+		Context:    context.Background(),
+		Registry:   registry,
+		Facts:      facts.NewCache(&facts.Facts{OS: "linux", Distro: "ubuntu"}),
+	}, plan)
+	return results, fp, err
+}
+
+func resultByID(results []model.StepResult, id string) model.StepResult {
+	for _, res := range results {
+		if res.StepID == id {
+			return res
+		}
+	}
+	return model.StepResult{}
+}
+
+func TestExecute_Conditions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("skips steps whose condition is false and their dependents", func(t *testing.T) {
+		t.Parallel()
+
+		results, fp, err := runConditional(t, "")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"apt_setup"}, fp.applyOrder())
+
+		dnf := resultByID(results, "dnf_setup")
+		require.Equal(t, model.StatusSkipped, dnf.Status)
+		require.Contains(t, dnf.SkipReason, "condition not met")
+
+		after := resultByID(results, "after_dnf")
+		require.Equal(t, model.StatusSkipped, after.Status)
+		require.Equal(t, `dependency "dnf_setup" was skipped`, after.SkipReason)
+
+		require.Equal(t, model.StatusSuccess, resultByID(results, "apt_setup").Status)
+	})
+
+	t.Run("run policy executes dependents", func(t *testing.T) {
+		t.Parallel()
+
+		results, fp, err := runConditional(t, config.SkippedDependencyRun)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"apt_setup", "after_dnf"}, fp.applyOrder())
+		require.Empty(t, resultByID(results, "after_dnf").SkipReason)
+	})
+
+	t.Run("fail policy fails dependents", func(t *testing.T) {
+		t.Parallel()
+
+		results, _, err := runConditional(t, config.SkippedDependencyFail)
+		var execErr *streamyerrors.ExecutionError
+		require.ErrorAs(t, err, &execErr)
+		require.Equal(t, "after_dnf", execErr.StepID)
+		require.Equal(t, model.StatusFailed, resultByID(results, "after_dnf").Status)
+	})
+}
+
+func TestVerifySteps_Conditions(t *testing.T) {
+	t.Parallel()
+
+	fp := &fakePlugin{verifyStatuses: map[string]model.VerificationStatus{
+		"apt_setup": model.StatusSatisfied,
+	}}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(fp))
+
+	cfg := &config.Config{Vars: map[string]any{"profile": "laptop"}}
+	summary, err := NewExecutor(nil).VerifySteps(&ExecutionContext{
+		Config:   cfg,
+		Registry: registry,
+		Context:  context.Background(),
+		Facts:    facts.NewCache(&facts.Facts{OS: "linux", Distro: "ubuntu"}),
+	}, conditionalSteps(t), time.Second)
+	require.NoError(t, err)
+
+	require.Equal(t, 3, summary.TotalSteps)
+	require.Equal(t, 1, summary.Satisfied)
+	require.Equal(t, 2, summary.Skipped)
+	require.True(t, summary.AllSatisfied())
+	require.Equal(t, []string{"apt_setup"}, fp.verifyOrder())
+
+	for _, res := range summary.Results {
+		if res.StepID == "apt_setup" {
+			continue
+		}
+		require.Equal(t, model.StatusVerificationSkipped, res.Status)
+		require.NotEmpty(t, res.SkipReason)
+	}
+}
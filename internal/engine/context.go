@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
+	"github.com/alexisbeaulieu97/streamy/internal/journal"
 	"github.com/alexisbeaulieu97/streamy/internal/logger"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
@@ -20,4 +22,20 @@ type ExecutionContext struct {
 	Logger          *logger.Logger
 	Context         context.Context
 	Registry        *plugin.PluginRegistry
+	// Facts caches the host facts conditions and templates read, gathered on first use. Sharing one
+	// cache across the Execute and VerifySteps calls of a run keeps them consistent; each call
+	// creates its own when nil.
+	Facts *facts.Cache
+	// Outputs collects values registered by steps; created per run when nil.
+	Outputs *OutputStore
+	// Events receives step lifecycle events as the run progresses; nil disables them.
+	Events *EventBus
+	// Journal records how to undo each applied step; nil disables journaling.
+	Journal *journal.Journal
+	// Resume holds results carried over from an interrupted or earlier run, keyed by step ID.
+	// Those steps are reported with their recorded status and outputs instead of being evaluated
+	// again.
+	Resume map[string]model.StepResult
+	// Selection limits VerifySteps to part of the pipeline; Execute runs whatever plan it is given.
+	Selection Selection
 }
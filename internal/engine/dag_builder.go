@@ -18,6 +18,9 @@ func BuildDAG(steps []config.Step) (*Graph, error) {
 		if !step.Enabled {
 			continue
 		}
+		if step.Loop != nil {
+			return nil, streamyerrors.NewValidationError("steps", fmt.Sprintf("step %q declares a loop that has not been expanded; load the config with config.ParseConfig", step.ID), nil)
+		}
 		if _, err := graph.AddNode(step); err != nil {
 			return nil, err
 		}
@@ -48,6 +51,10 @@ func BuildDAG(steps []config.Step) (*Graph, error) {
 	// Ensure every step appears in levels even when no dependencies.
 	ensureLevelsContainAll(graph, steps)
 
+	if err := validateOutputDependencies(graph, steps); err != nil {
+		return nil, err
+	}
+
 	return graph, nil
 }
 
@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -28,6 +30,39 @@ func TestBuildDAG_GeneratesLevels(t *testing.T) {
 	require.ElementsMatch(t, []string{"configure"}, graph.Levels[2])
 }
 
+func TestBuildDAG_LoopInstances(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "streamy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`version: "1.0"
+name: "Loops"
+steps:
+  - id: link
+    type: symlink
+    loop: [zshrc, gitconfig]
+    source: "dotfiles/${item}"
+    target: "/tmp/.${item}"
+  - id: reload
+    type: command
+    depends_on: [link]
+    command: "echo reload"
+`), 0o600))
+
+	cfg, err := config.ParseConfig(path)
+	require.NoError(t, err)
+
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	require.Len(t, graph.Levels, 2)
+	require.ElementsMatch(t, []string{"link[zshrc]", "link[gitconfig]"}, graph.Levels[0])
+	require.Equal(t, []string{"reload"}, graph.Levels[1])
+
+	looped := config.Step{ID: "raw", Type: "command", Enabled: true, Loop: []any{"a"}}
+	_, err = BuildDAG([]config.Step{looped})
+	var validationErr *streamyerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+}
+
 func TestBuildDAG_AllowsParallelSteps(t *testing.T) {
 	t.Parallel()
 
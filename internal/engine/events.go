@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alexisbeaulieu97/streamy/internal/logger"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+)
+
+// EventType identifies a point in a step's lifecycle.
+type EventType string
+
+const (
+	// StepQueued fires when all of a step's dependencies have completed and it is waiting to start.
+	StepQueued EventType = "step_queued"
+	// StepStarted fires when a step is dispatched to a worker.
+	StepStarted EventType = "step_started"
+	// StepEvaluated fires once a plugin has reported whether the step requires action.
+	StepEvaluated EventType = "step_evaluated"
+	// StepOutput fires for every line a step's subprocesses write.
+	StepOutput EventType = "step_output"
+	// StepFinished fires when a step's final result is known.
+	StepFinished EventType = "step_finished"
+)
+
+// Event describes progress of a single step during a run.
+type Event struct {
+	Type   EventType
+	StepID string
+	Time   time.Time
+	// Evaluation is set for StepEvaluated events.
+	Evaluation *model.EvaluationResult
+	// Stream ("stdout" or "stderr") and Line are set for StepOutput events.
+	Stream string
+	Line   string
+	// Result is set for StepFinished events.
+	Result *model.StepResult
+}
+
+// EventBus fans run events out to subscribers. Subscribers are called synchronously
+// from the goroutine that published the event and must not block.
+type EventBus struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers []subscriber
+}
+
+type subscriber struct {
+	id int
+	fn func(Event)
+}
+
+// NewEventBus creates an event bus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers fn for every future event and returns a function that removes it.
+func (b *EventBus) Subscribe(fn func(Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers = append(b.subscribers, subscriber{id: id, fn: fn})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subscribers = slices.DeleteFunc(b.subscribers, func(s subscriber) bool { return s.id == id })
+	}
+}
+
+// Publish delivers e to every subscriber in subscription order. Publishing on a nil bus is a no-op.
+func (b *EventBus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	subscribers := slices.Clone(b.subscribers)
+	b.mu.RUnlock()
+
+	for _, s := range subscribers {
+		s.fn(e)
+	}
+}
+
+// LoggingSubscriber returns a subscriber that writes step lifecycle events to log at debug level.
+func LoggingSubscriber(log *logger.Logger) func(Event) {
+	return func(e Event) {
+		entry := log.WithFields(map[string]any{"step_id": e.StepID, "event": string(e.Type)})
+		switch e.Type {
+		case StepEvaluated:
+			if e.Evaluation != nil {
+				entry.Debug(fmt.Sprintf("evaluated: requires_action=%t %s", e.Evaluation.RequiresAction, e.Evaluation.Message))
+			}
+		case StepOutput:
+			entry.Debug(fmt.Sprintf("%s: %s", e.Stream, e.Line))
+		case StepFinished:
+			if e.Result != nil {
+				entry.Debug(fmt.Sprintf("finished: %s", e.Result.Status))
+			}
+		default:
+			entry.Debug(string(e.Type))
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+// streamingPlugin writes one output line per step through the context's output sink.
+type streamingPlugin struct{}
+
+func (streamingPlugin) PluginMetadata() plugin.PluginMetadata {
+	return plugin.PluginMetadata{Name: "command", Version: "1.0.0", Type: "command"}
+}
+
+func (streamingPlugin) Schema() any { return nil }
+
+func (streamingPlugin) Evaluate(_ context.Context, step *config.Step) (*model.EvaluationResult, error) {
+	return &model.EvaluationResult{StepID: step.ID, CurrentState: model.StatusMissing, RequiresAction: true, Message: "run"}, nil
+}
+
+func (streamingPlugin) Apply(ctx context.Context, _ *model.EvaluationResult, step *config.Step) (*model.StepResult, error) {
+	if sink := plugin.OutputFromContext(ctx); sink != nil {
+		sink("stdout", "hello from "+step.ID)
+	}
+	return &model.StepResult{StepID: step.ID, Status: model.StatusSuccess}, nil
+}
+
+func TestEventBus_SubscribeAndUnsubscribe(t *testing.T) {
+	t.Parallel()
+
+	bus := NewEventBus()
+	var first, second []EventType
+	unsubscribe := bus.Subscribe(func(e Event) { first = append(first, e.Type) })
+	bus.Subscribe(func(e Event) { second = append(second, e.Type) })
+
+	bus.Publish(Event{Type: StepQueued, StepID: "a"})
+	unsubscribe()
+	bus.Publish(Event{Type: StepStarted, StepID: "a"})
+
+	require.Equal(t, []EventType{StepQueued}, first)
+	require.Equal(t, []EventType{StepQueued, StepStarted}, second)
+
+	var nilBus *EventBus
+	require.NotPanics(t, func() { nilBus.Publish(Event{Type: StepQueued}) })
+}
+
+func TestExecute_PublishesStepEvents(t *testing.T) {
+	t.Parallel()
+
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(streamingPlugin{}))
+
+	steps := []config.Step{
+		{ID: "first", Type: "command", Enabled: true},
+		{ID: "second", Type: "command", Enabled: true, DependsOn: []string{"first"}},
+	}
+	for i := range steps {
+		require.NoError(t, steps[i].SetConfig(config.CommandStep{Command: "true"}))
+	}
+	cfg := &config.Config{Version: "1.0", Name: "events", Steps: steps}
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	plan, err := GeneratePlan(graph)
+	require.NoError(t, err)
+
+	bus := NewEventBus()
+	var mu sync.Mutex
+	var events []Event
+	bus.Subscribe(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+
+	_, err = Execute(&ExecutionContext{
+		Config:     cfg,
+		WorkerPool: make(chan struct{}, 2),
+		Context:    context.Background(),
+		Registry:   registry,
+		Events:     bus,
+	}, plan)
+	require.NoError(t, err)
+
+	type entry struct {
+		Type   EventType
+		StepID string
+	}
+	var got []entry
+	for _, e := range events {
+		got = append(got, entry{e.Type, e.StepID})
+		require.False(t, e.Time.IsZero())
+		switch e.Type {
+		case StepEvaluated:
+			require.NotNil(t, e.Evaluation)
+		case StepOutput:
+			require.Equal(t, "stdout", e.Stream)
+			require.Equal(t, "hello from "+e.StepID, e.Line)
+		case StepFinished:
+			require.Equal(t, model.StatusSuccess, e.Result.Status)
+		}
+	}
+
+	require.Equal(t, []entry{
+		{StepQueued, "first"},
+		{StepStarted, "first"},
+		{StepEvaluated, "first"},
+		{StepOutput, "first"},
+		{StepFinished, "first"},
+		{StepQueued, "second"},
+		{StepStarted, "second"},
+		{StepEvaluated, "second"},
+		{StepOutput, "second"},
+		{StepFinished, "second"},
+	}, got)
+}
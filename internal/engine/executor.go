@@ -9,13 +9,16 @@ import (
 	"time"
 
 	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
 	"github.com/alexisbeaulieu97/streamy/internal/logger"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
 	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
 )
 
-// Execute runs the execution plan and returns step results in plan order.
+// Execute runs the execution plan and returns step results in plan order. Each step starts as
+// soon as its own dependencies have completed, bounded by the worker pool and by the resources
+// held by running steps.
 func Execute(execCtx *ExecutionContext, plan *ExecutionPlan) ([]model.StepResult, error) {
 	if execCtx == nil {
 		return nil, streamyerrors.NewExecutionError("", fmt.Errorf("execution context is nil"))
@@ -34,6 +37,12 @@ func Execute(execCtx *ExecutionContext, plan *ExecutionPlan) ([]model.StepResult
 	ctx, cancel := context.WithCancel(baseCtx)
 	defer cancel()
 
+	factCache := execCtx.Facts
+	if factCache == nil {
+		factCache = facts.NewCache(nil)
+	}
+	ctx = facts.NewContext(ctx, factCache)
+
 	timeoutDuration := time.Duration(execCtx.Config.Settings.Timeout)
 	if timeoutDuration > 0 {
 		timeoutDuration = timeoutDuration * time.Second
@@ -49,64 +58,124 @@ func Execute(execCtx *ExecutionContext, plan *ExecutionPlan) ([]model.StepResult
 		execCtx.Results = make(map[string]*model.StepResult)
 	}
 
-	var resultsMu sync.Mutex
-	var allResults []model.StepResult
-	var firstErr error
+	queue, err := newReadyQueue(plan, stepLookup)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, level := range plan.Levels {
-		levelResults := make([]model.StepResult, len(level.StepIDs))
-		var levelErr error
-		var once sync.Once
-		var wg sync.WaitGroup
+	var resultsMu sync.Mutex
 
-		for idx, stepID := range level.StepIDs {
-			step, ok := stepLookup[stepID]
-			if !ok {
-				return allResults, streamyerrors.NewExecutionError(stepID, fmt.Errorf("step not found"))
-			}
+	if execCtx.Outputs == nil {
+		execCtx.Outputs = NewOutputStore()
+	}
+	gate := newConditionGate(ctx, execCtx, factCache, execCtx.Outputs)
+	skippedByCondition := func(id string) (string, bool) {
+		resultsMu.Lock()
+		defer resultsMu.Unlock()
+		res, ok := execCtx.Results[id]
+		if !ok || res == nil || res.SkipReason == "" {
+			return "", false
+		}
+		return res.SkipReason, true
+	}
 
-			wg.Add(1)
-			go func(idx int, step *config.Step) {
-				defer wg.Done()
+	type completion struct {
+		id  string
+		res *model.StepResult
+		err error
+	}
+	done := make(chan completion)
+
+	run := func(step *config.Step) {
+		var res *model.StepResult
+		var err error
+		if prior, ok := execCtx.Resume[step.ID]; ok {
+			res = resumedResult(step.ID, prior)
+		} else if decision := gate.Check(step, skippedByCondition); !decision.Run {
+			res, err = gatedResult(step.ID, decision)
+		} else if resolved, resolveErr := resolveOutputs(step, execCtx.Outputs); resolveErr != nil {
+			res, err = gatedResult(step.ID, gateDecision{Err: resolveErr})
+		} else {
+			res, err = executeWithRetry(ctx, execCtx, resolved, stepTimeout(step, timeoutDuration))
+		}
+		if err != nil && step.ContinueOnError && ctx.Err() == nil {
+			res = ignoredFailure(step.ID, res, err)
+			execCtx.Logger.WithFields(map[string]any{"step_id": step.ID}).Warn(fmt.Sprintf("optional step failed, continuing: %v", err))
+			err = nil
+		}
+		if res != nil {
+			execCtx.Outputs.Record(step, stepOutputs(res))
+			resultsMu.Lock()
+			execCtx.Results[step.ID] = res
+			resultsMu.Unlock()
+			finished := *res
+			execCtx.Events.Publish(Event{Type: StepFinished, StepID: step.ID, Result: &finished})
+		}
+		done <- completion{id: step.ID, res: res, err: err}
+	}
 
-				res, err := executeStep(ctx, execCtx, step, timeoutDuration)
-				if res != nil {
-					levelResults[idx] = *res
-					resultsMu.Lock()
-					execCtx.Results[step.ID] = res
-					resultsMu.Unlock()
-				}
+	// The worker pool bounds concurrency; dispatching no more steps than it holds means the
+	// queue, not a race for pool slots, decides which ready step starts next.
+	limit := cap(execCtx.WorkerPool)
+	results := make(map[string]model.StepResult)
+	var firstErr error
+	running := 0
+	stopped := false
 
-				if err != nil {
-					once.Do(func() {
-						levelErr = err
-						if !execCtx.ContinueOnError {
-							cancel()
-						}
-					})
-				}
-			}(idx, step)
-		}
+	for _, id := range queue.ready {
+		execCtx.Events.Publish(Event{Type: StepQueued, StepID: id})
+	}
 
-		wg.Wait()
+	// Ready steps whose resources are held wait for them while later ready steps go ahead.
+	locks := newResourceLocks(stepLookup, execCtx.Registry)
 
-		if levelErr != nil {
-			for _, res := range levelResults {
-				if res.StepID != "" {
-					allResults = append(allResults, res)
-				}
+	for {
+		for !stopped && (limit <= 0 || running < limit) {
+			id, ok := queue.PopFirst(locks.Free)
+			if !ok {
+				break
 			}
+			running++
+			locks.Hold(id)
+			execCtx.Events.Publish(Event{Type: StepStarted, StepID: id})
+			go run(stepLookup[id])
+		}
+		if running == 0 {
+			break
+		}
+
+		c := <-done
+		running--
+		locks.Release(c.id)
+		if c.res != nil {
+			results[c.id] = *c.res
+		}
+		if c.err != nil {
 			if firstErr == nil {
-				firstErr = levelErr
+				firstErr = c.err
 			}
 			if !execCtx.ContinueOnError {
-				return allResults, levelErr
+				stopped = true
+				cancel()
+			}
+		}
+		if baseCtx.Err() != nil {
+			stopped = true
+		}
+		for _, id := range queue.Complete(c.id) {
+			if !stopped {
+				execCtx.Events.Publish(Event{Type: StepQueued, StepID: id})
 			}
-			levelErr = nil
-			continue
 		}
+	}
 
-		allResults = append(allResults, levelResults...)
+	completed := make([]string, 0, len(results))
+	for id := range results {
+		completed = append(completed, id)
+	}
+	allResults := make([]model.StepResult, 0, len(results))
+	for _, id := range queue.Ordered(completed) {
+		allResults = append(allResults, results[id])
 	}
 
 	return allResults, firstErr
@@ -123,6 +192,11 @@ func executeStep(ctx context.Context, execCtx *ExecutionContext, step *config.St
 		stepCtx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
+	if execCtx.Events != nil {
+		stepCtx = plugin.ContextWithOutput(stepCtx, func(stream, line string) {
+			execCtx.Events.Publish(Event{Type: StepOutput, StepID: step.ID, Stream: stream, Line: line})
+		})
+	}
 
 	if execCtx.WorkerPool != nil {
 		select {
@@ -156,6 +230,7 @@ func executeStep(ctx context.Context, execCtx *ExecutionContext, step *config.St
 		}
 		return nil, fmt.Errorf("evaluation failed for step %s: %w", step.ID, err)
 	}
+	execCtx.Events.Publish(Event{Type: StepEvaluated, StepID: step.ID, Evaluation: evalResult})
 
 	var result *model.StepResult
 	if execCtx.DryRun {
@@ -165,6 +240,7 @@ func executeStep(ctx context.Context, execCtx *ExecutionContext, step *config.St
 				StepID:    evalResult.StepID,
 				Status:    model.StatusWouldUpdate,
 				Message:   evalResult.Message,
+				Outputs:   evalResult.Outputs,
 				Duration:  time.Since(start),
 				Timestamp: time.Now(),
 			}
@@ -173,6 +249,7 @@ func executeStep(ctx context.Context, execCtx *ExecutionContext, step *config.St
 				StepID:    evalResult.StepID,
 				Status:    model.StatusSkipped,
 				Message:   evalResult.Message,
+				Outputs:   evalResult.Outputs,
 				Duration:  time.Since(start),
 				Timestamp: time.Now(),
 			}
@@ -180,18 +257,27 @@ func executeStep(ctx context.Context, execCtx *ExecutionContext, step *config.St
 	} else {
 		// For apply mode, only call Apply() if action is required
 		if evalResult.RequiresAction {
-			result, err = impl.Apply(stepCtx, evalResult, step)
+			applyCtx := stepCtx
+			if execCtx.Journal != nil {
+				meta := impl.PluginMetadata()
+				applyCtx = plugin.ContextWithJournal(stepCtx, execCtx.Journal.Step(step.ID, step.Type, meta.Irreversible))
+			}
+			result, err = impl.Apply(applyCtx, evalResult, step)
 		} else {
 			// Skip the step since it's already satisfied
 			result = &model.StepResult{
 				StepID:    evalResult.StepID,
 				Status:    model.StatusSkipped,
 				Message:   evalResult.Message,
+				Outputs:   evalResult.Outputs,
 				Duration:  time.Since(start),
 				Timestamp: time.Now(),
 			}
 		}
 	}
+	if result != nil && len(evalResult.Outputs) > 0 {
+		result.Outputs = mergeOutputs(evalResult.Outputs, result.Outputs)
+	}
 	duration := time.Since(start)
 
 	if result == nil {
@@ -226,6 +312,76 @@ func executeStep(ctx context.Context, execCtx *ExecutionContext, step *config.St
 	return result, nil
 }
 
+// stepTimeout returns the per-attempt apply timeout: the step's own timeout when set, otherwise the global one.
+func stepTimeout(step *config.Step, global time.Duration) time.Duration {
+	if step.Timeout > 0 {
+		return time.Duration(step.Timeout) * time.Second
+	}
+	return global
+}
+
+// ignoredFailure reports a failed continue_on_error step without failing the run.
+func ignoredFailure(stepID string, res *model.StepResult, err error) *model.StepResult {
+	if res == nil {
+		res = &model.StepResult{StepID: stepID, Message: err.Error(), Timestamp: time.Now()}
+	}
+	res.Status = model.StatusFailedIgnored
+	if res.Error == nil {
+		res.Error = err
+	}
+	return res
+}
+
+// mergeOutputs layers apply-time outputs over those known from evaluation.
+func mergeOutputs(evaluated, applied map[string]any) map[string]any {
+	merged := make(map[string]any, len(evaluated)+len(applied))
+	for k, v := range evaluated {
+		merged[k] = v
+	}
+	for k, v := range applied {
+		merged[k] = v
+	}
+	return merged
+}
+
+// gatedResult builds the result for a step that did not run because of its condition or a skipped dependency.
+func gatedResult(stepID string, decision gateDecision) (*model.StepResult, error) {
+	if decision.Err != nil {
+		return &model.StepResult{
+			StepID:    stepID,
+			Status:    model.StatusFailed,
+			Message:   decision.Err.Error(),
+			Error:     decision.Err,
+			Timestamp: time.Now(),
+		}, streamyerrors.NewExecutionError(stepID, decision.Err)
+	}
+	return &model.StepResult{
+		StepID:     stepID,
+		Status:     model.StatusSkipped,
+		Message:    decision.SkipReason,
+		SkipReason: decision.SkipReason,
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// resumedResult reports a step that completed in an interrupted or earlier run and is not run
+// again. It keeps the earlier status so conditions on the step's outputs (such as `changed`) see
+// the same values, and whether the result was carried so handlers are not notified twice.
+func resumedResult(stepID string, prior model.StepResult) *model.StepResult {
+	status := prior.Status
+	if status == "" {
+		status = model.StatusSkipped
+	}
+	return &model.StepResult{
+		StepID:    stepID,
+		Status:    status,
+		Message:   "completed in an earlier run",
+		Outputs:   prior.Outputs,
+		Carried:   prior.Carried,
+		Timestamp: time.Now(),
+	}
+}
+
 func finalizeFailure(result *model.StepResult, stepCtx context.Context, stepID string, err error) (*model.StepResult, error) {
 	if result.Status == "" {
 		result.Status = model.StatusFailed
@@ -269,14 +425,26 @@ func NewExecutor(log *logger.Logger) *Executor {
 	}
 }
 
-// VerifySteps performs verification on all steps and returns a summary
+// VerifySteps performs verification on all steps and returns a summary. Each step is evaluated as
+// soon as its dependencies have been verified, with up to cap(WorkerPool) steps in flight (one at a
+// time when the pool is nil) and no more per plugin than its MaxConcurrency allows. Results are
+// reported in plan order regardless of completion order.
 func (e *Executor) VerifySteps(ctx *ExecutionContext, steps []config.Step, defaultTimeout time.Duration) (*model.VerificationSummary, error) {
 	start := time.Now()
 
-	stepIndex := make(map[string]*config.Step, len(steps))
+	graph, err := BuildDAG(steps)
+	if err != nil {
+		return nil, err
+	}
+	graph, err = graph.Select(ctx.Selection)
+	if err != nil {
+		return nil, err
+	}
+
+	stepIndex := make(map[string]*config.Step, len(graph.Nodes))
 	enabledSteps := 0
 	for i := range steps {
-		if !steps[i].Enabled {
+		if _, selected := graph.Nodes[steps[i].ID]; !selected || !steps[i].Enabled {
 			continue
 		}
 		step := &steps[i]
@@ -284,7 +452,11 @@ func (e *Executor) VerifySteps(ctx *ExecutionContext, steps []config.Step, defau
 		enabledSteps++
 	}
 
-	graph, err := BuildDAG(steps)
+	plan, err := GeneratePlan(graph)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := newReadyQueue(plan, stepIndex)
 	if err != nil {
 		return nil, err
 	}
@@ -298,161 +470,276 @@ func (e *Executor) VerifySteps(ctx *ExecutionContext, steps []config.Step, defau
 		defaultTimeout = 30 * time.Second
 	}
 
-	resultsByID := make(map[string]*model.VerificationResult, enabledSteps)
+	baseCtx := ctx.Context
+	if baseCtx == nil {
+		baseCtx = context.Background()
+	}
+	runCtx, cancel := context.WithCancel(baseCtx)
+	defer cancel()
 
-	for _, level := range graph.Levels {
-		for _, stepID := range level {
-			step, ok := stepIndex[stepID]
-			if !ok {
-				continue
-			}
+	var resultsMu sync.Mutex
+	resultsByID := make(map[string]*model.VerificationResult, enabledSteps)
+	lookup := func(id string) (*model.VerificationResult, bool) {
+		resultsMu.Lock()
+		defer resultsMu.Unlock()
+		res, ok := resultsByID[id]
+		return res, ok
+	}
 
-			if ctx.Context.Err() != nil {
-				return summary, ctx.Context.Err()
-			}
+	factCache := ctx.Facts
+	if factCache == nil {
+		factCache = facts.NewCache(nil)
+	}
+	runCtx = facts.NewContext(runCtx, factCache)
+	outputs := NewOutputStore()
+	v := &stepVerifier{
+		execCtx:        ctx,
+		runCtx:         runCtx,
+		gate:           newConditionGate(runCtx, ctx, factCache, outputs),
+		outputs:        outputs,
+		lookup:         lookup,
+		defaultTimeout: defaultTimeout,
+	}
 
-			unsatisfied := make([]string, 0, len(step.DependsOn))
-			for _, depID := range step.DependsOn {
-				depResult, exists := resultsByID[depID]
-				if !exists {
-					continue
-				}
-				if depResult == nil || depResult.Status != model.StatusSatisfied {
-					status := model.StatusUnknown
-					if depResult != nil {
-						status = depResult.Status
-					}
-					unsatisfied = append(unsatisfied, fmt.Sprintf("%s (%s)", depID, status))
-				}
-			}
+	type verification struct {
+		id  string
+		err error
+	}
+	done := make(chan verification)
 
-			if len(unsatisfied) > 0 {
-				msg := fmt.Sprintf("blocked: dependencies not satisfied: %s", strings.Join(unsatisfied, ", "))
-				err := fmt.Errorf("dependencies not satisfied: %s", strings.Join(unsatisfied, ", "))
-				result := &model.VerificationResult{
-					StepID:    step.ID,
-					Status:    model.StatusBlocked,
-					Message:   msg,
-					Error:     err,
-					Duration:  0,
-					Timestamp: time.Now(),
-				}
-				summary.Results = append(summary.Results, result)
-				summary.Blocked++
-				resultsByID[step.ID] = result
-				continue
-			}
+	workers := max(cap(ctx.WorkerPool), 1)
+	running := 0
+	var fatalErr error
 
-			p, err := ctx.Registry.Get(step.Type)
-			if err != nil {
-				result := &model.VerificationResult{
-					StepID:    step.ID,
-					Status:    model.StatusBlocked,
-					Message:   fmt.Sprintf("plugin not found for type %s", step.Type),
-					Error:     err,
-					Duration:  0,
-					Timestamp: time.Now(),
-				}
-				summary.Results = append(summary.Results, result)
-				summary.Blocked++
-				resultsByID[step.ID] = result
-				continue
-			}
+	// Ready steps whose plugin is at its limit wait for a slot while later ready steps go ahead.
+	limits := newPluginLimits(stepIndex, ctx.Registry)
 
-			timeout := defaultTimeout
-			if step.VerifyTimeout > 0 {
-				timeout = time.Duration(step.VerifyTimeout) * time.Second
+	for {
+		for fatalErr == nil && baseCtx.Err() == nil && running < workers {
+			id, ok := queue.PopFirst(limits.Free)
+			if !ok {
+				break
 			}
+			running++
+			limits.Hold(id)
+			go func(step *config.Step) {
+				result, err := v.verify(step)
+				if result != nil {
+					resultsMu.Lock()
+					resultsByID[step.ID] = result
+					resultsMu.Unlock()
+				}
+				done <- verification{id: step.ID, err: err}
+			}(stepIndex[id])
+		}
+		if running == 0 {
+			break
+		}
 
-			stepStart := time.Now()
-			stepCtx, cancel := context.WithTimeout(ctx.Context, timeout)
-
-			// Use new Evaluate() method for verification
-			evalResult, verifyErr := p.Evaluate(stepCtx, step)
+		c := <-done
+		running--
+		limits.Release(c.id)
+		if c.err != nil && fatalErr == nil {
+			fatalErr = c.err
 			cancel()
+		}
+		queue.Complete(c.id)
+	}
 
-			if verifyErr != nil {
-				summary.Duration = time.Since(start)
-
-				// Handle new structured error types
-				var pluginErr plugin.PluginError
-				if errors.As(verifyErr, &pluginErr) {
-					switch pluginErr.(type) {
-					case *plugin.ValidationError:
-						// Configuration error - always fatal
-						return summary, verifyErr
-					case *plugin.ExecutionError:
-						// Execution error - always fatal in verify mode
-						return summary, verifyErr
-					case *plugin.StateError:
-						// State detection error - treat as Unknown status
-						result := &model.VerificationResult{
-							StepID:    step.ID,
-							Status:    model.StatusUnknown,
-							Message:   pluginErr.Error(),
-							Error:     pluginErr.Unwrap(),
-							Duration:  time.Since(stepStart),
-							Timestamp: time.Now(),
-						}
-						summary.Results = append(summary.Results, result)
-						summary.Unknown++
-						resultsByID[step.ID] = result
-						continue
-					}
-				}
+	verified := make([]string, 0, len(resultsByID))
+	for id := range resultsByID {
+		verified = append(verified, id)
+	}
+	for _, id := range queue.Ordered(verified) {
+		result := resultsByID[id]
+		summary.Results = append(summary.Results, result)
+		switch result.Status {
+		case model.StatusSatisfied:
+			summary.Satisfied++
+		case model.StatusMissing:
+			summary.Missing++
+		case model.StatusDrifted:
+			summary.Drifted++
+		case model.StatusBlocked:
+			summary.Blocked++
+		case model.StatusUnknown:
+			summary.Unknown++
+		case model.StatusVerificationSkipped:
+			summary.Skipped++
+		}
+	}
+	summary.Duration = time.Since(start)
 
-				// Handle legacy error types
-				var validationErr *streamyerrors.ValidationError
-				if errors.As(verifyErr, &validationErr) {
-					return summary, verifyErr
-				}
+	if err := baseCtx.Err(); err != nil {
+		return summary, err
+	}
+	if fatalErr != nil {
+		return summary, fatalErr
+	}
+	return summary, nil
+}
 
-				var parseErr *streamyerrors.ParseError
-				if errors.As(verifyErr, &parseErr) {
-					return summary, verifyErr
-				}
+// stepVerifier evaluates individual steps for VerifySteps. It is shared by concurrent workers.
+type stepVerifier struct {
+	execCtx        *ExecutionContext
+	runCtx         context.Context
+	gate           *conditionGate
+	outputs        *OutputStore
+	lookup         func(id string) (*model.VerificationResult, bool)
+	defaultTimeout time.Duration
+}
 
-				var execErr *streamyerrors.ExecutionError
-				if errors.As(verifyErr, &execErr) {
-					return summary, verifyErr
-				}
+// verify evaluates one step whose dependencies have all been verified. A non-nil error aborts
+// the whole verification.
+func (v *stepVerifier) verify(step *config.Step) (*model.VerificationResult, error) {
+	skippedByCondition := func(id string) (string, bool) {
+		res, ok := v.lookup(id)
+		if !ok || res == nil || res.Status != model.StatusVerificationSkipped {
+			return "", false
+		}
+		return res.SkipReason, true
+	}
 
-				var legacyPluginErr *streamyerrors.PluginError
-				if errors.As(verifyErr, &legacyPluginErr) {
-					return summary, verifyErr
-				}
+	decision := v.gate.Check(step, skippedByCondition)
+	if decision.Run {
+		resolved, resolveErr := resolveOutputs(step, v.outputs)
+		if resolveErr != nil {
+			decision = gateDecision{Err: resolveErr}
+		} else {
+			step = resolved
+		}
+	}
+	if !decision.Run {
+		result := &model.VerificationResult{
+			StepID:     step.ID,
+			Status:     model.StatusVerificationSkipped,
+			Message:    decision.SkipReason,
+			SkipReason: decision.SkipReason,
+			Timestamp:  time.Now(),
+		}
+		if decision.Err != nil {
+			result.Status = model.StatusBlocked
+			result.Message = fmt.Sprintf("blocked: %v", decision.Err)
+			result.SkipReason = ""
+			result.Error = decision.Err
+		}
+		v.outputs.Record(step, verificationOutputs(result.Status, nil))
+		return result, nil
+	}
 
-				return summary, streamyerrors.NewExecutionError(step.ID, verifyErr)
+	unsatisfied := make([]string, 0, len(step.DependsOn))
+	for _, depID := range step.DependsOn {
+		depResult, exists := v.lookup(depID)
+		if !exists {
+			continue
+		}
+		// Skipped dependencies were already handled by the condition gate's policy.
+		if depResult != nil && depResult.Status == model.StatusVerificationSkipped {
+			continue
+		}
+		if depResult == nil || depResult.Status != model.StatusSatisfied {
+			status := model.StatusUnknown
+			if depResult != nil {
+				status = depResult.Status
 			}
+			unsatisfied = append(unsatisfied, fmt.Sprintf("%s (%s)", depID, status))
+		}
+	}
 
-			// Convert EvaluationResult to VerificationResult for compatibility
-			result := &model.VerificationResult{
-				StepID:    evalResult.StepID,
-				Status:    evalResult.CurrentState,
-				Message:   evalResult.Message,
-				Details:   evalResult.Diff, // Use Diff as Details for compatibility
-				Duration:  time.Since(stepStart),
-				Timestamp: time.Now(),
-			}
+	if len(unsatisfied) > 0 {
+		msg := fmt.Sprintf("blocked: dependencies not satisfied: %s", strings.Join(unsatisfied, ", "))
+		err := fmt.Errorf("dependencies not satisfied: %s", strings.Join(unsatisfied, ", "))
+		return &model.VerificationResult{
+			StepID:    step.ID,
+			Status:    model.StatusBlocked,
+			Message:   msg,
+			Error:     err,
+			Duration:  0,
+			Timestamp: time.Now(),
+		}, nil
+	}
 
-			summary.Results = append(summary.Results, result)
-			resultsByID[step.ID] = result
-
-			switch result.Status {
-			case model.StatusSatisfied:
-				summary.Satisfied++
-			case model.StatusMissing:
-				summary.Missing++
-			case model.StatusDrifted:
-				summary.Drifted++
-			case model.StatusBlocked:
-				summary.Blocked++
-			case model.StatusUnknown:
-				summary.Unknown++
+	p, err := v.execCtx.Registry.Get(step.Type)
+	if err != nil {
+		return &model.VerificationResult{
+			StepID:    step.ID,
+			Status:    model.StatusBlocked,
+			Message:   fmt.Sprintf("plugin not found for type %s", step.Type),
+			Error:     err,
+			Duration:  0,
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	timeout := v.defaultTimeout
+	if step.VerifyTimeout > 0 {
+		timeout = time.Duration(step.VerifyTimeout) * time.Second
+	}
+
+	stepStart := time.Now()
+	stepCtx, cancel := context.WithTimeout(v.runCtx, timeout)
+
+	// Use new Evaluate() method for verification
+	evalResult, verifyErr := p.Evaluate(stepCtx, step)
+	cancel()
+
+	if verifyErr != nil {
+		// Handle new structured error types
+		var pluginErr plugin.PluginError
+		if errors.As(verifyErr, &pluginErr) {
+			switch pluginErr.(type) {
+			case *plugin.ValidationError:
+				// Configuration error - always fatal
+				return nil, verifyErr
+			case *plugin.ExecutionError:
+				// Execution error - always fatal in verify mode
+				return nil, verifyErr
+			case *plugin.StateError:
+				// State detection error - treat as Unknown status
+				return &model.VerificationResult{
+					StepID:    step.ID,
+					Status:    model.StatusUnknown,
+					Message:   pluginErr.Error(),
+					Error:     pluginErr.Unwrap(),
+					Duration:  time.Since(stepStart),
+					Timestamp: time.Now(),
+				}, nil
 			}
 		}
+
+		// Handle legacy error types
+		var validationErr *streamyerrors.ValidationError
+		if errors.As(verifyErr, &validationErr) {
+			return nil, verifyErr
+		}
+
+		var parseErr *streamyerrors.ParseError
+		if errors.As(verifyErr, &parseErr) {
+			return nil, verifyErr
+		}
+
+		var execErr *streamyerrors.ExecutionError
+		if errors.As(verifyErr, &execErr) {
+			return nil, verifyErr
+		}
+
+		var legacyPluginErr *streamyerrors.PluginError
+		if errors.As(verifyErr, &legacyPluginErr) {
+			return nil, verifyErr
+		}
+
+		return nil, streamyerrors.NewExecutionError(step.ID, verifyErr)
 	}
 
-	summary.Duration = time.Since(start)
-	return summary, nil
+	// Convert EvaluationResult to VerificationResult for compatibility
+	result := &model.VerificationResult{
+		StepID:    evalResult.StepID,
+		Status:    evalResult.CurrentState,
+		Message:   evalResult.Message,
+		Details:   evalResult.Diff, // Use Diff as Details for compatibility
+		Duration:  time.Since(stepStart),
+		Timestamp: time.Now(),
+	}
+	v.outputs.Record(step, verificationOutputs(result.Status, evalResult.Outputs))
+	return result, nil
 }
@@ -335,6 +335,63 @@ func TestExecute_ContinueOnError(t *testing.T) {
 	require.Contains(t, statuses, model.StatusSuccess)
 }
 
+func TestExecute_StepContinueOnError(t *testing.T) {
+	fp := &fakePlugin{failStep: "optional"}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(fp))
+
+	optional := config.Step{ID: "optional", Type: "command", Enabled: true, ContinueOnError: true}
+	require.NoError(t, optional.SetConfig(config.CommandStep{Command: "fail"}))
+	after := config.Step{ID: "after", Type: "command", Enabled: true, DependsOn: []string{"optional"}}
+	require.NoError(t, after.SetConfig(config.CommandStep{Command: "echo"}))
+	cfg := &config.Config{Version: "1.0", Name: "optional", Steps: []config.Step{optional, after}}
+
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	plan, err := GeneratePlan(graph)
+	require.NoError(t, err)
+
+	results, err := Execute(&ExecutionContext{
+		Config:     cfg,
+		WorkerPool: make(chan struct{}, 1),
+		Context:    context.Background(),
+		Registry:   registry,
+	}, plan)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, model.StatusFailedIgnored, results[0].Status)
+	require.Error(t, results[0].Error)
+	require.Equal(t, model.StatusSuccess, results[1].Status)
+}
+
+func TestExecute_StepTimeoutOverridesGlobal(t *testing.T) {
+	fp := &fakePlugin{delay: 2 * time.Second}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(fp))
+
+	step := config.Step{ID: "slow", Type: "command", Enabled: true, Timeout: 1}
+	require.NoError(t, step.SetConfig(config.CommandStep{Command: "sleep"}))
+	cfg := &config.Config{Version: "1.0", Name: "timeout", Steps: []config.Step{step}}
+
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	plan, err := GeneratePlan(graph)
+	require.NoError(t, err)
+
+	results, err := Execute(&ExecutionContext{
+		Config:     cfg,
+		WorkerPool: make(chan struct{}, 1),
+		Context:    context.Background(),
+		Registry:   registry,
+	}, plan)
+	require.Error(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, model.StatusFailed, results[0].Status)
+
+	require.Equal(t, 30*time.Minute, stepTimeout(&config.Step{Timeout: 1800}, time.Minute))
+	require.Equal(t, time.Minute, stepTimeout(&config.Step{}, time.Minute))
+}
+
 func TestTimeoutResult(t *testing.T) {
 	t.Run("creates timeout result with nil error", func(t *testing.T) {
 		stepID := "test-step"
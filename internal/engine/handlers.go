@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+)
+
+// NotifiedHandlers returns, sorted, the IDs of the enabled handlers notified by steps that changed
+// something: steps that applied successfully, or in a dry run steps that would have. Steps that
+// were already satisfied, skipped or failed notify nothing, and neither do results carried from an
+// earlier watch cycle, whose handlers ran then. Steps resumed from an interrupted run still
+// notify: that run stopped before its handlers.
+func NotifiedHandlers(cfg *config.Config, results []model.StepResult) []string {
+	if cfg == nil || len(cfg.Handlers) == 0 {
+		return nil
+	}
+
+	steps := config.StepMap(cfg.Steps)
+	handlers := cfg.HandlerMap()
+	notified := make(map[string]struct{})
+	for _, res := range results {
+		if res.Carried || !changed(res.Status) {
+			continue
+		}
+		for _, id := range steps[res.StepID].Notify {
+			if handler, ok := handlers[id]; ok && handler.Enabled {
+				notified[id] = struct{}{}
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(notified))
+	for id := range notified {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func changed(status string) bool {
+	switch status {
+	case model.StatusSuccess, model.StatusWouldCreate, model.StatusWouldUpdate:
+		return true
+	default:
+		return false
+	}
+}
+
+// RunHandlers runs the handlers notified by results, each once, ordered by the depends_on
+// links between them. Dependencies on handlers that were not notified are ignored. Handlers run
+// through Execute with the settings of execCtx, but keep their results apart from the steps'.
+func RunHandlers(execCtx *ExecutionContext, results []model.StepResult) ([]model.StepResult, error) {
+	if execCtx == nil || execCtx.Config == nil {
+		return nil, nil
+	}
+	notified := NotifiedHandlers(execCtx.Config, results)
+	if len(notified) == 0 {
+		return nil, nil
+	}
+
+	handlers := execCtx.Config.HandlerMap()
+	graph := NewGraph()
+	steps := make([]config.Step, 0, len(notified))
+	for _, id := range notified {
+		steps = append(steps, handlers[id])
+	}
+	for i := range steps {
+		if _, err := graph.AddNode(&steps[i]); err != nil {
+			return nil, err
+		}
+	}
+	for _, step := range steps {
+		for _, dep := range step.DependsOn {
+			if _, ok := graph.Nodes[dep]; !ok {
+				continue
+			}
+			if err := graph.AddEdge(dep, step.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := graph.TopologicalSort(); err != nil {
+		return nil, err
+	}
+	plan, err := GeneratePlan(graph)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := *execCtx.Config
+	cfg.Steps = steps
+	handlerCtx := *execCtx
+	handlerCtx.Config = &cfg
+	handlerCtx.Results = make(map[string]*model.StepResult, len(steps))
+	handlerCtx.Resume = nil
+	return Execute(&handlerCtx, plan)
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+func TestRunHandlers_RunsNotifiedHandlersOnceInOrder(t *testing.T) {
+	fp := &fakePlugin{verifyStatuses: map[string]model.VerificationStatus{"unchanged": model.StatusSatisfied}}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(fp))
+
+	cfg := &config.Config{
+		Version: "1.0",
+		Name:    "handlers",
+		Steps: []config.Step{
+			commandStep(t, config.Step{ID: "conf", Notify: []string{"reload", "restart"}}, "echo conf"),
+			commandStep(t, config.Step{ID: "other", Notify: []string{"restart"}}, "echo other"),
+			commandStep(t, config.Step{ID: "unchanged", Notify: []string{"unused"}}, "echo unchanged"),
+		},
+		Handlers: []config.Step{
+			commandStep(t, config.Step{ID: "reload", DependsOn: []string{"restart"}}, "echo reload"),
+			commandStep(t, config.Step{ID: "restart", DependsOn: []string{"unused"}}, "echo restart"),
+			commandStep(t, config.Step{ID: "unused"}, "echo unused"),
+		},
+	}
+
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	plan, err := GeneratePlan(graph)
+	require.NoError(t, err)
+
+	execCtx := &ExecutionContext{
+		Config:     cfg,
+		WorkerPool: make(chan struct{}, 1),
+		Results:    make(map[string]*model.StepResult),
+		Context:    context.Background(),
+		Registry:   registry,
+	}
+	results, err := Execute(execCtx, plan)
+	require.NoError(t, err)
+	require.Equal(t, []string{"reload", "restart"}, NotifiedHandlers(cfg, results))
+
+	handlerResults, err := RunHandlers(execCtx, results)
+	require.NoError(t, err)
+	require.Len(t, handlerResults, 2)
+	require.Equal(t, "restart", handlerResults[0].StepID)
+	require.Equal(t, model.StatusSuccess, handlerResults[0].Status)
+	require.Equal(t, "reload", handlerResults[1].StepID)
+
+	require.Equal(t, []string{"conf", "other", "restart", "reload"}, fp.applyOrder())
+	require.NotContains(t, execCtx.Results, "restart", "handler results are kept apart from step results")
+}
+
+func TestNotifiedHandlers(t *testing.T) {
+	cfg := &config.Config{
+		Steps: []config.Step{
+			{ID: "applied", Notify: []string{"a"}},
+			{ID: "skipped", Notify: []string{"b"}},
+			{ID: "failed", Notify: []string{"c"}},
+			{ID: "would", Notify: []string{"d"}},
+			{ID: "disabled", Notify: []string{"e"}},
+			{ID: "carried", Notify: []string{"f"}},
+		},
+		Handlers: []config.Step{
+			{ID: "a", Enabled: true},
+			{ID: "b", Enabled: true},
+			{ID: "c", Enabled: true},
+			{ID: "d", Enabled: true},
+			{ID: "e", Enabled: false},
+			{ID: "f", Enabled: true},
+		},
+	}
+
+	results := []model.StepResult{
+		{StepID: "applied", Status: model.StatusSuccess},
+		{StepID: "skipped", Status: model.StatusSkipped},
+		{StepID: "failed", Status: model.StatusFailed},
+		{StepID: "would", Status: model.StatusWouldUpdate},
+		{StepID: "disabled", Status: model.StatusSuccess},
+		{StepID: "carried", Status: model.StatusSuccess, Carried: true},
+	}
+
+	require.Equal(t, []string{"a", "d"}, NotifiedHandlers(cfg, results))
+	require.Empty(t, NotifiedHandlers(&config.Config{Steps: cfg.Steps}, results))
+}
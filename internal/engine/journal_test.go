@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/journal"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+// writingPlugin journals and then writes the file named by the step's command.
+type writingPlugin struct {
+	irreversible bool
+}
+
+func (p writingPlugin) PluginMetadata() plugin.PluginMetadata {
+	return plugin.PluginMetadata{Name: "command", Version: "1.0.0", Type: "command", Irreversible: p.irreversible}
+}
+
+func (writingPlugin) Schema() any { return nil }
+
+func (writingPlugin) Evaluate(_ context.Context, step *config.Step) (*model.EvaluationResult, error) {
+	return &model.EvaluationResult{StepID: step.ID, CurrentState: model.StatusMissing, RequiresAction: true}, nil
+}
+
+func (writingPlugin) Apply(ctx context.Context, _ *model.EvaluationResult, step *config.Step) (*model.StepResult, error) {
+	var cfg config.CommandStep
+	if err := step.DecodeConfig(&cfg); err != nil {
+		return nil, err
+	}
+	if err := plugin.JournalFromContext(ctx).SavePath(cfg.Command); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(cfg.Command, []byte(step.ID), 0o600); err != nil {
+		return nil, err
+	}
+	return &model.StepResult{StepID: step.ID, Status: model.StatusSuccess}, nil
+}
+
+func TestExecute_RecordsJournal(t *testing.T) {
+	t.Parallel()
+
+	for _, irreversible := range []bool{false, true} {
+		registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+		require.NoError(t, registry.Register(writingPlugin{irreversible: irreversible}))
+
+		work := t.TempDir()
+		existing := filepath.Join(work, "existing")
+		require.NoError(t, os.WriteFile(existing, []byte("before"), 0o600))
+
+		steps := []config.Step{
+			stepWithConfig(t, config.Step{ID: "overwrite", Type: "command", Enabled: true}, config.CommandStep{Command: existing}),
+			stepWithConfig(t, config.Step{ID: "create", Type: "command", Enabled: true, DependsOn: []string{"overwrite"}}, config.CommandStep{Command: filepath.Join(work, "created")}),
+		}
+		cfg := &config.Config{Version: "1.0", Name: "journal", Steps: steps}
+		graph, err := BuildDAG(cfg.Steps)
+		require.NoError(t, err)
+		plan, err := GeneratePlan(graph)
+		require.NoError(t, err)
+
+		root := t.TempDir()
+		runJournal, err := journal.Create(root, "streamy.yaml")
+		require.NoError(t, err)
+
+		_, err = Execute(&ExecutionContext{
+			Config:     cfg,
+			WorkerPool: make(chan struct{}, 1),
+			Context:    context.Background(),
+			Registry:   registry,
+			Journal:    runJournal,
+		}, plan)
+		require.NoError(t, err)
+		_, err = runJournal.Close()
+		require.NoError(t, err)
+
+		run, err := journal.Load(root, runJournal.ID())
+		require.NoError(t, err)
+		require.Len(t, run.Steps, 2)
+		require.Equal(t, "overwrite", run.Steps[0].StepID)
+		require.Equal(t, journal.OpRestoreFile, run.Steps[0].Operations[0].Kind)
+		require.Equal(t, "create", run.Steps[1].StepID)
+		require.Equal(t, journal.OpRemove, run.Steps[1].Operations[0].Kind)
+		require.Equal(t, irreversible, run.Steps[0].Irreversible)
+
+		_, err = journal.Rollback(root, run, false)
+		require.NoError(t, err)
+		data, err := os.ReadFile(existing)
+		require.NoError(t, err)
+		require.Equal(t, "before", string(data))
+		_, err = os.Stat(filepath.Join(work, "created"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	}
+}
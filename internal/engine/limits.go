@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+// pluginLimits caps how many steps of each plugin run at once, as declared by
+// PluginMetadata.MaxConcurrency. Plugins without a limit are not throttled. Like resourceLocks,
+// it is only used by the goroutine dispatching steps, so it needs no locking of its own.
+type pluginLimits struct {
+	plugins map[string]string
+	max     map[string]int
+	running map[string]int
+}
+
+func newPluginLimits(steps map[string]*config.Step, registry *plugin.PluginRegistry) *pluginLimits {
+	l := &pluginLimits{
+		plugins: make(map[string]string),
+		max:     make(map[string]int),
+		running: make(map[string]int),
+	}
+	if registry == nil {
+		return l
+	}
+	for id, step := range steps {
+		p, err := registry.Get(step.Type)
+		if err != nil {
+			continue
+		}
+		if meta := p.PluginMetadata(); meta.MaxConcurrency > 0 {
+			l.plugins[id] = meta.Name
+			l.max[meta.Name] = meta.MaxConcurrency
+		}
+	}
+	return l
+}
+
+// Free reports whether the step's plugin may run another step.
+func (l *pluginLimits) Free(id string) bool {
+	name, limited := l.plugins[id]
+	return !limited || l.running[name] < l.max[name]
+}
+
+// Hold takes one of the step's plugin slots until Release.
+func (l *pluginLimits) Hold(id string) {
+	if name, limited := l.plugins[id]; limited {
+		l.running[name]++
+	}
+}
+
+// Release frees the slot held by the step.
+func (l *pluginLimits) Release(id string) {
+	if name, limited := l.plugins[id]; limited {
+		l.running[name]--
+	}
+}
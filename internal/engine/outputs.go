@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// OutputStore holds the outputs of steps that declare `register` for the duration of a run.
+// Values are keyed by registered name; looped steps nest each instance's outputs under its loop key.
+type OutputStore struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+// NewOutputStore creates an empty output store.
+func NewOutputStore() *OutputStore {
+	return &OutputStore{values: make(map[string]any)}
+}
+
+// Record stores outputs under the step's registered name. Steps without `register` are ignored.
+func (s *OutputStore) Record(step *config.Step, outputs map[string]any) {
+	if s == nil || step == nil || step.Register == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := step.RegisterKey()
+	if key == "" {
+		s.values[step.Register] = outputs
+		return
+	}
+	instances, _ := s.values[step.Register].(map[string]any)
+	if instances == nil {
+		instances = make(map[string]any)
+		s.values[step.Register] = instances
+	}
+	instances[key] = outputs
+}
+
+// Get returns the outputs registered under name.
+func (s *OutputStore) Get(name string) (any, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Snapshot returns a copy of the registered outputs that is safe to read while steps keep running.
+func (s *OutputStore) Snapshot() map[string]any {
+	snapshot := make(map[string]any)
+	if s == nil {
+		return snapshot
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name, value := range s.values {
+		if instances, ok := value.(map[string]any); ok {
+			copied := make(map[string]any, len(instances))
+			for k, v := range instances {
+				copied[k] = v
+			}
+			value = copied
+		}
+		snapshot[name] = value
+	}
+	return snapshot
+}
+
+// stepOutputs combines plugin outputs with the fields every registered step exposes.
+func stepOutputs(result *model.StepResult) map[string]any {
+	outputs := make(map[string]any, len(result.Outputs)+2)
+	for k, v := range result.Outputs {
+		outputs[k] = v
+	}
+	outputs["status"] = result.Status
+	outputs["changed"] = result.Status == model.StatusSuccess
+	return outputs
+}
+
+// verificationOutputs exposes what verification learned about a registered step.
+func verificationOutputs(status model.VerificationStatus, evalOutputs map[string]any) map[string]any {
+	outputs := make(map[string]any, len(evalOutputs)+2)
+	for k, v := range evalOutputs {
+		outputs[k] = v
+	}
+	outputs["status"] = string(status)
+	outputs["changed"] = false
+	return outputs
+}
+
+// resolveOutputs substitutes ${outputs...} references in a step once its producers have run.
+func resolveOutputs(step *config.Step, store *OutputStore) (*config.Step, error) {
+	if len(step.OutputReferences()) == 0 {
+		return step, nil
+	}
+	resolved, err := step.ResolveOutputs(store.Snapshot())
+	if err != nil {
+		return nil, fmt.Errorf("resolve outputs: %w", err)
+	}
+	return &resolved, nil
+}
+
+// validateOutputDependencies ensures every step reading a registered output depends, directly or
+// transitively, on each step that registers it, so the value exists by the time the consumer runs.
+func validateOutputDependencies(graph *Graph, steps []config.Step) error {
+	producers := make(map[string][]string)
+	for _, step := range steps {
+		if step.Enabled && step.Register != "" {
+			producers[step.Register] = append(producers[step.Register], step.ID)
+		}
+	}
+
+	for _, step := range steps {
+		if !step.Enabled {
+			continue
+		}
+		for _, name := range step.OutputReferences() {
+			ids, ok := producers[name]
+			if !ok {
+				return streamyerrors.NewValidationError("steps", fmt.Sprintf("step %q references outputs.%s but no enabled step registers %q", step.ID, name, name), nil)
+			}
+			var missing []string
+			for _, id := range ids {
+				if !graph.dependsOn(step.ID, id) {
+					missing = append(missing, id)
+				}
+			}
+			if len(missing) > 0 {
+				sort.Strings(missing)
+				return streamyerrors.NewValidationError("steps", fmt.Sprintf("step %q references outputs.%s but does not depend on %s; add it to depends_on", step.ID, name, strings.Join(missing, ", ")), nil)
+			}
+		}
+	}
+	return nil
+}
+
+// dependsOn reports whether the node id reaches ancestor by following its dependencies.
+func (g *Graph) dependsOn(id, ancestor string) bool {
+	start, ok := g.Nodes[id]
+	if !ok {
+		return false
+	}
+
+	visited := make(map[string]struct{})
+	stack := append([]*Node(nil), start.DependsOn...)
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node.ID == ancestor {
+			return true
+		}
+		if _, seen := visited[node.ID]; seen {
+			continue
+		}
+		visited[node.ID] = struct{}{}
+		stack = append(stack, node.DependsOn...)
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// echoPlugin "runs" commands by reporting the command text as stdout.
+type echoPlugin struct {
+	mu       sync.Mutex
+	commands map[string]string
+}
+
+func (p *echoPlugin) PluginMetadata() plugin.PluginMetadata {
+	return plugin.PluginMetadata{Name: "command", Version: "1.0.0", Type: "command"}
+}
+
+func (p *echoPlugin) Schema() any { return config.CommandStep{} }
+
+func (p *echoPlugin) Evaluate(_ context.Context, step *config.Step) (*model.EvaluationResult, error) {
+	return &model.EvaluationResult{StepID: step.ID, CurrentState: model.StatusMissing, RequiresAction: true, Message: "run"}, nil
+}
+
+func (p *echoPlugin) Apply(_ context.Context, _ *model.EvaluationResult, step *config.Step) (*model.StepResult, error) {
+	var cmd config.CommandStep
+	if err := step.DecodeConfig(&cmd); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.commands[step.ID] = cmd.Command
+	p.mu.Unlock()
+	return &model.StepResult{
+		StepID:  step.ID,
+		Status:  model.StatusSuccess,
+		Outputs: map[string]any{"stdout": cmd.Command, "exit_code": 0},
+	}, nil
+}
+
+func commandStep(t *testing.T, base config.Step, command string) config.Step {
+	t.Helper()
+	base.Type = "command"
+	base.Enabled = true
+	require.NoError(t, base.SetConfig(config.CommandStep{Command: command}))
+	return base
+}
+
+func writeTempConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "streamy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestExecute_RegisteredOutputs(t *testing.T) {
+	t.Parallel()
+
+	steps := []config.Step{
+		commandStep(t, config.Step{ID: "version", Register: "ver"}, "1.2.3"),
+		commandStep(t, config.Step{ID: "noop"}, "true"),
+		commandStep(t, config.Step{
+			ID:        "install",
+			DependsOn: []string{"noop"},
+			When:      `outputs.ver.exit_code == 0 && outputs.ver.changed`,
+		}, "install tool@${outputs.ver.stdout}"),
+		commandStep(t, config.Step{ID: "skipped", DependsOn: []string{"version"}, When: `outputs.ver.stdout == "9.9.9"`}, "never"),
+	}
+	steps[1].DependsOn = []string{"version"}
+
+	ep := &echoPlugin{commands: make(map[string]string)}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(ep))
+
+	cfg := &config.Config{Version: "1.0", Name: "outputs", Steps: steps}
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	plan, err := GeneratePlan(graph)
+	require.NoError(t, err)
+
+	execCtx := &ExecutionContext{
+		Config:     cfg,
+		WorkerPool: make(chan struct{}, 2),
+		Context:    context.Background(),
+		Registry:   registry,
+	}
+	results, err := Execute(execCtx, plan)
+	require.NoError(t, err)
+
+	require.Equal(t, "install tool@1.2.3", ep.commands["install"])
+	require.NotContains(t, ep.commands, "skipped")
+	require.Equal(t, model.StatusSkipped, resultByID(results, "skipped").Status)
+
+	registered, ok := execCtx.Outputs.Get("ver")
+	require.True(t, ok)
+	require.Equal(t, map[string]any{"stdout": "1.2.3", "exit_code": 0, "status": model.StatusSuccess, "changed": true}, registered)
+}
+
+func TestExecute_LoopedRegisterNestsByKey(t *testing.T) {
+	t.Parallel()
+
+	path := writeTempConfig(t, `version: "1.0"
+name: "outputs"
+steps:
+  - id: probe
+    type: command
+    loop: [go, node]
+    register: versions
+    command: "${item}-1.0"
+  - id: report
+    type: command
+    depends_on: [probe]
+    command: "${outputs.versions.go.stdout} ${outputs.versions.node.stdout}"
+`)
+	cfg, err := config.ParseConfig(path)
+	require.NoError(t, err)
+
+	ep := &echoPlugin{commands: make(map[string]string)}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(ep))
+
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	plan, err := GeneratePlan(graph)
+	require.NoError(t, err)
+
+	_, err = Execute(&ExecutionContext{Config: cfg, Context: context.Background(), Registry: registry}, plan)
+	require.NoError(t, err)
+	require.Equal(t, "go-1.0 node-1.0", ep.commands["report"])
+}
+
+func TestBuildDAG_OutputDependencies(t *testing.T) {
+	t.Parallel()
+
+	producer := commandStep(t, config.Step{ID: "producer", Register: "data"}, "echo")
+	middle := commandStep(t, config.Step{ID: "middle", DependsOn: []string{"producer"}}, "echo")
+
+	tests := []struct {
+		name     string
+		consumer config.Step
+		wantErr  string
+	}{
+		{
+			name:     "direct dependency",
+			consumer: commandStep(t, config.Step{ID: "consumer", DependsOn: []string{"producer"}}, "${outputs.data.stdout}"),
+		},
+		{
+			name:     "transitive dependency",
+			consumer: commandStep(t, config.Step{ID: "consumer", DependsOn: []string{"middle"}}, "${outputs.data.stdout}"),
+		},
+		{
+			name:     "missing dependency",
+			consumer: commandStep(t, config.Step{ID: "consumer", When: "outputs.data.changed"}, "echo"),
+			wantErr:  `step "consumer" references outputs.data but does not depend on producer`,
+		},
+		{
+			name:     "unknown registered name",
+			consumer: commandStep(t, config.Step{ID: "consumer", DependsOn: []string{"producer"}}, "${outputs.other.stdout}"),
+			wantErr:  `no enabled step registers "other"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := BuildDAG([]config.Step{producer, middle, tt.consumer})
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			var validationErr *streamyerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			require.Contains(t, validationErr.Message, tt.wantErr)
+		})
+	}
+}
+
+func TestVerifySteps_OutputsUnavailableBlockConsumer(t *testing.T) {
+	t.Parallel()
+
+	fp := &fakePlugin{verifyStatuses: map[string]model.VerificationStatus{"producer": model.StatusSatisfied}}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(fp))
+
+	steps := []config.Step{
+		commandStep(t, config.Step{ID: "producer", Register: "data"}, "echo"),
+		commandStep(t, config.Step{ID: "gated", DependsOn: []string{"producer"}, When: `outputs.data.status == "satisfied"`}, "echo"),
+		commandStep(t, config.Step{ID: "consumer", DependsOn: []string{"producer"}}, "${outputs.data.stdout}"),
+	}
+
+	summary, err := NewExecutor(nil).VerifySteps(&ExecutionContext{
+		Config:   &config.Config{},
+		Registry: registry,
+		Context:  context.Background(),
+	}, steps, time.Second)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"producer", "gated"}, fp.verifyOrder())
+	for _, res := range summary.Results {
+		if res.StepID == "consumer" {
+			require.Equal(t, model.StatusBlocked, res.Status)
+			require.Contains(t, res.Message, "outputs.data.stdout")
+		}
+	}
+}
+
+func TestExecute_ResumeSkipsCompletedSteps(t *testing.T) {
+	t.Parallel()
+
+	steps := []config.Step{
+		commandStep(t, config.Step{ID: "version", Register: "ver"}, "1.2.3"),
+		commandStep(t, config.Step{ID: "install", DependsOn: []string{"version"}, When: `outputs.ver.changed`}, "install tool@${outputs.ver.stdout}"),
+	}
+
+	ep := &echoPlugin{commands: make(map[string]string)}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(ep))
+
+	cfg := &config.Config{Version: "1.0", Name: "resume", Steps: steps}
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	plan, err := GeneratePlan(graph)
+	require.NoError(t, err)
+
+	results, err := Execute(&ExecutionContext{
+		Config:   cfg,
+		Context:  context.Background(),
+		Registry: registry,
+		Resume: map[string]model.StepResult{
+			"version": {StepID: "version", Status: model.StatusSuccess, Outputs: map[string]any{"stdout": "2.0.0"}},
+		},
+	}, plan)
+	require.NoError(t, err)
+
+	require.NotContains(t, ep.commands, "version")
+	require.Equal(t, "install tool@2.0.0", ep.commands["install"])
+	resumed := resultByID(results, "version")
+	require.Equal(t, model.StatusSuccess, resumed.Status)
+	require.Equal(t, "completed in an earlier run", resumed.Message)
+}
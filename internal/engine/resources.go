@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+// resourceLocks keeps steps that share a resource from running at the same time. A step holds
+// the resources it names with `lock:` plus those its plugin declares in PluginMetadata.Resources.
+// It is only used by the goroutine dispatching steps, so it needs no locking of its own.
+type resourceLocks struct {
+	needs map[string][]string
+	held  map[string]string
+}
+
+func newResourceLocks(steps map[string]*config.Step, registry *plugin.PluginRegistry) *resourceLocks {
+	l := &resourceLocks{
+		needs: make(map[string][]string),
+		held:  make(map[string]string),
+	}
+	for id, step := range steps {
+		resources := append([]string(nil), step.Locks...)
+		if registry != nil {
+			if p, err := registry.Get(step.Type); err == nil {
+				resources = append(resources, p.PluginMetadata().Resources...)
+			}
+		}
+		if len(resources) > 0 {
+			l.needs[id] = resources
+		}
+	}
+	return l
+}
+
+// Free reports whether none of the step's resources are held by a running step.
+func (l *resourceLocks) Free(id string) bool {
+	for _, resource := range l.needs[id] {
+		if _, busy := l.held[resource]; busy {
+			return false
+		}
+	}
+	return true
+}
+
+// Hold marks the step's resources as taken until Release.
+func (l *resourceLocks) Hold(id string) {
+	for _, resource := range l.needs[id] {
+		l.held[resource] = id
+	}
+}
+
+// Release frees the resources held by the step.
+func (l *resourceLocks) Release(id string) {
+	for _, resource := range l.needs[id] {
+		if l.held[resource] == id {
+			delete(l.held, resource)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// maxRetryDelay caps exponential backoff so a long retry chain cannot stall a run indefinitely.
+const maxRetryDelay = 5 * time.Minute
+
+// executeWithRetry runs a step, re-running Evaluate/Apply according to its retry policy.
+// Every attempt is recorded on the returned result.
+func executeWithRetry(ctx context.Context, execCtx *ExecutionContext, step *config.Step, timeout time.Duration) (*model.StepResult, error) {
+	maxAttempts := step.Retries + 1
+	var attempts []model.Attempt
+
+	for attempt := 1; ; attempt++ {
+		res, err := executeStep(ctx, execCtx, step, timeout)
+		attempts = append(attempts, attemptRecord(attempt, res, err))
+
+		if err == nil || attempt >= maxAttempts || !shouldRetry(ctx, step, res, err) {
+			return withAttempts(step.ID, res, err, attempts), err
+		}
+
+		delay := retryDelay(step, attempt)
+		execCtx.Logger.WithFields(map[string]any{
+			"step_id": step.ID,
+			"attempt": attempt,
+			"delay":   delay.String(),
+		}).Warn(fmt.Sprintf("step failed, retrying: %v", err))
+
+		select {
+		case <-ctx.Done():
+			return withAttempts(step.ID, res, err, attempts), err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func attemptRecord(number int, res *model.StepResult, err error) model.Attempt {
+	attempt := model.Attempt{Number: number, Error: err, Timestamp: time.Now()}
+	if res != nil {
+		attempt.Status = res.Status
+		attempt.Message = res.Message
+		attempt.Duration = res.Duration
+		if !res.Timestamp.IsZero() {
+			attempt.Timestamp = res.Timestamp
+		}
+	}
+	if attempt.Status == "" && err != nil {
+		attempt.Status = model.StatusFailed
+	}
+	if attempt.Message == "" && err != nil {
+		attempt.Message = err.Error()
+	}
+	return attempt
+}
+
+// withAttempts attaches the attempt history to the final result. Retried failures that produced
+// no result of their own still get one so the history is not lost.
+func withAttempts(stepID string, res *model.StepResult, err error, attempts []model.Attempt) *model.StepResult {
+	if res == nil {
+		if len(attempts) < 2 {
+			return nil
+		}
+		last := attempts[len(attempts)-1]
+		res = &model.StepResult{
+			StepID:    stepID,
+			Status:    model.StatusFailed,
+			Message:   last.Message,
+			Error:     err,
+			Timestamp: last.Timestamp,
+		}
+	}
+	res.Attempts = attempts
+	if len(attempts) > 1 {
+		var total time.Duration
+		for _, a := range attempts {
+			total += a.Duration
+		}
+		res.Duration = total
+	}
+	return res
+}
+
+// shouldRetry reports whether a failed attempt matches the step's retry_on conditions.
+// Configuration errors and cancellation of the whole run are never retried.
+func shouldRetry(ctx context.Context, step *config.Step, res *model.StepResult, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var pluginValidation *plugin.ValidationError
+	var legacyValidation *streamyerrors.ValidationError
+	if errors.As(err, &pluginValidation) || errors.As(err, &legacyValidation) {
+		return false
+	}
+
+	class := errorClass(err)
+	exitCode, hasExitCode := failureExitCode(res, err)
+	for _, condition := range step.RetryConditions() {
+		switch condition {
+		case config.RetryOnAny:
+			return true
+		case class:
+			return true
+		}
+	}
+	return hasExitCode && slices.Contains(step.RetryExitCodes(), exitCode)
+}
+
+// errorClass maps a failure onto the retry_on error classes.
+func errorClass(err error) string {
+	var stateErr *plugin.StateError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return config.RetryOnTimeout
+	case errors.As(err, &stateErr):
+		return config.RetryOnStateError
+	default:
+		return config.RetryOnExecutionError
+	}
+}
+
+func failureExitCode(res *model.StepResult, err error) (int, bool) {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), true
+	}
+	if res != nil {
+		if code, ok := res.Outputs["exit_code"].(int); ok {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// retryDelay returns the wait before the retry that follows attempt. Exponential backoff doubles
+// the delay per attempt and adds up to 50% jitter so parallel steps do not retry in lockstep.
+func retryDelay(step *config.Step, attempt int) time.Duration {
+	base := step.RetryDelayDuration()
+	if step.Backoff != config.BackoffExponential || base <= 0 {
+		return base
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryDelay)
+	return delay/2 + rand.N(delay/2+1) //nolint:gosec // jitter does not need a secure source
+}
@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+// flakyPlugin fails Apply with the configured errors before succeeding.
+type flakyPlugin struct {
+	mu       sync.Mutex
+	failures []error
+	applies  int
+}
+
+func (p *flakyPlugin) PluginMetadata() plugin.PluginMetadata {
+	return plugin.PluginMetadata{Name: "command", Version: "1.0.0", Type: "command"}
+}
+
+func (p *flakyPlugin) Schema() any { return nil }
+
+func (p *flakyPlugin) Evaluate(_ context.Context, step *config.Step) (*model.EvaluationResult, error) {
+	return &model.EvaluationResult{StepID: step.ID, CurrentState: model.StatusMissing, RequiresAction: true, Message: "run"}, nil
+}
+
+func (p *flakyPlugin) Apply(_ context.Context, _ *model.EvaluationResult, step *config.Step) (*model.StepResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.applies++
+	if p.applies <= len(p.failures) {
+		err := p.failures[p.applies-1]
+		return &model.StepResult{StepID: step.ID, Status: model.StatusFailed, Message: err.Error()}, err
+	}
+	return &model.StepResult{StepID: step.ID, Status: model.StatusSuccess, Message: "ok"}, nil
+}
+
+func exitError(t *testing.T, code string) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", "exit "+code).Run()
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	return plugin.NewExecutionError("step", err)
+}
+
+func TestExecuteWithRetry(t *testing.T) {
+	t.Parallel()
+
+	mirrorDown := plugin.NewExecutionError("step", errors.New("mirror unavailable"))
+
+	tests := []struct {
+		name         string
+		step         config.Step
+		failures     []error
+		wantErr      bool
+		wantAttempts int
+	}{
+		{
+			name:         "succeeds after transient failures",
+			step:         config.Step{Retries: 3, RetryDelay: "1ms"},
+			failures:     []error{mirrorDown, mirrorDown},
+			wantAttempts: 3,
+		},
+		{
+			name:         "gives up when retries are exhausted",
+			step:         config.Step{Retries: 1, RetryDelay: "1ms", Backoff: config.BackoffExponential},
+			failures:     []error{mirrorDown, mirrorDown, mirrorDown},
+			wantErr:      true,
+			wantAttempts: 2,
+		},
+		{
+			name:         "does not retry without a policy",
+			failures:     []error{mirrorDown},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "does not retry validation errors",
+			step:         config.Step{Retries: 3, RetryDelay: "1ms", RetryOn: []string{config.RetryOnAny}},
+			failures:     []error{plugin.NewValidationError("step", errors.New("bad config"))},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries listed exit codes only",
+			step:         config.Step{Retries: 3, RetryDelay: "1ms", RetryOn: []string{"100"}},
+			failures:     []error{exitError(t, "100"), exitError(t, "1")},
+			wantErr:      true,
+			wantAttempts: 2,
+		},
+		{
+			name:         "error class filter skips other classes",
+			step:         config.Step{Retries: 3, RetryDelay: "1ms", RetryOn: []string{config.RetryOnStateError}},
+			failures:     []error{mirrorDown},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fp := &flakyPlugin{failures: tt.failures}
+			registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+			require.NoError(t, registry.Register(fp))
+
+			step := tt.step
+			step.ID = "step"
+			step.Type = "command"
+			step.Enabled = true
+
+			res, err := executeWithRetry(context.Background(), &ExecutionContext{Registry: registry}, &step, 0)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, model.StatusSuccess, res.Status)
+			}
+			require.NotNil(t, res)
+			require.Len(t, res.Attempts, tt.wantAttempts)
+			require.Equal(t, tt.wantAttempts, fp.applies)
+			for i, attempt := range res.Attempts {
+				require.Equal(t, i+1, attempt.Number)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	constant := &config.Step{RetryDelay: "2s"}
+	require.Equal(t, 2*time.Second, retryDelay(constant, 1))
+	require.Equal(t, 2*time.Second, retryDelay(constant, 4))
+
+	exponential := &config.Step{RetryDelay: "1s", Backoff: config.BackoffExponential}
+	for attempt, ceiling := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second} {
+		delay := retryDelay(exponential, attempt)
+		require.GreaterOrEqual(t, delay, ceiling/2)
+		require.LessOrEqual(t, delay, ceiling)
+	}
+	require.LessOrEqual(t, retryDelay(exponential, 30), maxRetryDelay)
+
+	require.Equal(t, config.DefaultRetryDelay, retryDelay(&config.Step{}, 1))
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// readyQueue releases steps as soon as their own dependencies, and the steps registering outputs
+// they read, have completed, instead of waiting for the whole plan level. Ready steps are started by priority, then by the length of
+// the dependency chain they unblock (critical path first), then in plan order.
+type readyQueue struct {
+	order      map[string]int
+	priority   map[string]int
+	pathLength map[string]int
+	remaining  map[string]int
+	dependents map[string][]string
+	ready      []string
+}
+
+func newReadyQueue(plan *ExecutionPlan, steps map[string]*config.Step) (*readyQueue, error) {
+	q := &readyQueue{
+		order:      make(map[string]int),
+		priority:   make(map[string]int),
+		pathLength: make(map[string]int),
+		remaining:  make(map[string]int),
+		dependents: make(map[string][]string),
+	}
+
+	for _, level := range plan.Levels {
+		for _, id := range level.StepIDs {
+			step, ok := steps[id]
+			if !ok {
+				return nil, streamyerrors.NewExecutionError(id, fmt.Errorf("step not found"))
+			}
+			q.order[id] = len(q.order)
+			q.priority[id] = step.Priority
+		}
+	}
+
+	// Steps also wait for the planned steps registering outputs they read, which a selection may
+	// keep without the steps linking them.
+	producers := make(map[string][]string)
+	for id := range q.order {
+		if name := steps[id].Register; name != "" {
+			producers[name] = append(producers[name], id)
+		}
+	}
+	for id := range q.order {
+		deps := append([]string(nil), steps[id].DependsOn...)
+		for _, name := range steps[id].OutputReferences() {
+			deps = append(deps, producers[name]...)
+		}
+		counted := make(map[string]bool, len(deps))
+		for _, dep := range deps {
+			if _, planned := q.order[dep]; !planned || counted[dep] {
+				continue
+			}
+			counted[dep] = true
+			q.remaining[id]++
+			q.dependents[dep] = append(q.dependents[dep], id)
+		}
+	}
+
+	// Walk the plan backwards so every dependent's chain length is known before its dependencies.
+	for i := len(plan.Levels) - 1; i >= 0; i-- {
+		for _, id := range plan.Levels[i].StepIDs {
+			longest := 0
+			for _, dependent := range q.dependents[id] {
+				longest = max(longest, q.pathLength[dependent])
+			}
+			q.pathLength[id] = longest + 1
+		}
+	}
+
+	for id := range q.order {
+		if q.remaining[id] == 0 {
+			q.ready = append(q.ready, id)
+		}
+	}
+	q.sortReady()
+	return q, nil
+}
+
+// PopFirst removes and returns the most urgent ready step for which runnable returns true.
+func (q *readyQueue) PopFirst(runnable func(id string) bool) (string, bool) {
+	for i, id := range q.ready {
+		if runnable(id) {
+			q.ready = append(q.ready[:i], q.ready[i+1:]...)
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// Complete marks a step finished, queues dependents whose dependencies are now all done and
+// returns them.
+func (q *readyQueue) Complete(id string) []string {
+	var released []string
+	for _, dependent := range q.dependents[id] {
+		q.remaining[dependent]--
+		if q.remaining[dependent] == 0 {
+			released = append(released, dependent)
+		}
+	}
+	q.ready = append(q.ready, released...)
+	q.sortReady()
+	return released
+}
+
+// Ordered returns ids sorted by their position in the plan.
+func (q *readyQueue) Ordered(ids []string) []string {
+	sorted := append([]string(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return q.order[sorted[i]] < q.order[sorted[j]] })
+	return sorted
+}
+
+func (q *readyQueue) sortReady() {
+	sort.SliceStable(q.ready, func(i, j int) bool {
+		a, b := q.ready[i], q.ready[j]
+		if q.priority[a] != q.priority[b] {
+			return q.priority[a] > q.priority[b]
+		}
+		if q.pathLength[a] != q.pathLength[b] {
+			return q.pathLength[a] > q.pathLength[b]
+		}
+		return q.order[a] < q.order[b]
+	})
+}
@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+// timedPlugin sleeps per step during Apply and records the order steps started and finished in.
+type timedPlugin struct {
+	delays    map[string]time.Duration
+	resources []string
+
+	mu       sync.Mutex
+	started  []string
+	finished []string
+	timeline []string
+}
+
+func (p *timedPlugin) PluginMetadata() plugin.PluginMetadata {
+	return plugin.PluginMetadata{Name: "command", Version: "1.0.0", Type: "command", Resources: p.resources}
+}
+
+func (p *timedPlugin) Schema() any { return nil }
+
+func (p *timedPlugin) Evaluate(_ context.Context, step *config.Step) (*model.EvaluationResult, error) {
+	return &model.EvaluationResult{StepID: step.ID, CurrentState: model.StatusMissing, RequiresAction: true, Message: "run"}, nil
+}
+
+func (p *timedPlugin) Apply(ctx context.Context, _ *model.EvaluationResult, step *config.Step) (*model.StepResult, error) {
+	p.mu.Lock()
+	p.started = append(p.started, step.ID)
+	p.timeline = append(p.timeline, "+"+step.ID)
+	p.mu.Unlock()
+
+	select {
+	case <-time.After(p.delays[step.ID]):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	p.finished = append(p.finished, step.ID)
+	p.timeline = append(p.timeline, "-"+step.ID)
+	p.mu.Unlock()
+	return &model.StepResult{StepID: step.ID, Status: model.StatusSuccess}, nil
+}
+
+func runScheduled(t *testing.T, tp *timedPlugin, parallel int, steps ...config.Step) []model.StepResult {
+	t.Helper()
+
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(tp))
+
+	for i := range steps {
+		steps[i].Type = "command"
+		steps[i].Enabled = true
+		require.NoError(t, steps[i].SetConfig(config.CommandStep{Command: "true"}))
+	}
+	cfg := &config.Config{Version: "1.0", Name: "scheduler", Steps: steps}
+	graph, err := BuildDAG(cfg.Steps)
+	require.NoError(t, err)
+	plan, err := GeneratePlan(graph)
+	require.NoError(t, err)
+
+	results, err := Execute(&ExecutionContext{
+		Config:     cfg,
+		WorkerPool: make(chan struct{}, parallel),
+		Context:    context.Background(),
+		Registry:   registry,
+	}, plan)
+	require.NoError(t, err)
+	return results
+}
+
+func TestExecute_StartsStepsWhenTheirDependenciesFinish(t *testing.T) {
+	t.Parallel()
+
+	tp := &timedPlugin{delays: map[string]time.Duration{"slow_clone": 300 * time.Millisecond}}
+	results := runScheduled(t, tp, 4,
+		config.Step{ID: "slow_clone"},
+		config.Step{ID: "fetch"},
+		config.Step{ID: "after_clone", DependsOn: []string{"slow_clone"}},
+		config.Step{ID: "after_fetch", DependsOn: []string{"fetch"}},
+	)
+
+	// after_fetch shares a plan level with after_clone but must not wait for slow_clone.
+	require.Less(t, indexOf(tp.finished, "after_fetch"), indexOf(tp.finished, "slow_clone"))
+
+	ids := make([]string, 0, len(results))
+	for _, res := range results {
+		ids = append(ids, res.StepID)
+	}
+	require.Equal(t, []string{"fetch", "slow_clone", "after_clone", "after_fetch"}, ids)
+}
+
+func TestExecute_ReadyStepsStartByPriorityThenCriticalPath(t *testing.T) {
+	t.Parallel()
+
+	tp := &timedPlugin{}
+	runScheduled(t, tp, 1,
+		config.Step{ID: "a_leaf"},
+		config.Step{ID: "b_chain_start"},
+		config.Step{ID: "c_urgent", Priority: 10},
+		config.Step{ID: "d_chain_end", DependsOn: []string{"b_chain_start"}},
+	)
+
+	require.Equal(t, []string{"c_urgent", "b_chain_start", "a_leaf", "d_chain_end"}, tp.started)
+}
+
+func TestExecute_StepsSharingALockRunOneAtATime(t *testing.T) {
+	t.Parallel()
+
+	delay := 100 * time.Millisecond
+	tp := &timedPlugin{delays: map[string]time.Duration{"a_write": delay, "b_write": delay, "c_other": delay}}
+	runScheduled(t, tp, 4,
+		config.Step{ID: "a_write", Locks: []string{"bashrc"}},
+		config.Step{ID: "b_write", Locks: []string{"bashrc"}},
+		config.Step{ID: "c_other"},
+	)
+
+	// b_write waits for a_write to release the lock, while c_other runs alongside a_write.
+	require.Less(t, indexOf(tp.timeline, "-a_write"), indexOf(tp.timeline, "+b_write"))
+	require.Less(t, indexOf(tp.timeline, "+c_other"), indexOf(tp.timeline, "-a_write"))
+}
+
+func TestExecute_PluginResourcesSerialiseItsSteps(t *testing.T) {
+	t.Parallel()
+
+	delay := 50 * time.Millisecond
+	tp := &timedPlugin{
+		delays:    map[string]time.Duration{"a": delay, "b": delay, "c": delay},
+		resources: []string{"apt"},
+	}
+	runScheduled(t, tp, 4, config.Step{ID: "a"}, config.Step{ID: "b"}, config.Step{ID: "c"})
+
+	require.Equal(t, []string{"+a", "-a", "+b", "-b", "+c", "-c"}, tp.timeline)
+}
+
+func indexOf(ids []string, id string) int {
+	for i, candidate := range ids {
+		if candidate == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+// Selection narrows a run to part of the pipeline. The zero value selects every step.
+type Selection struct {
+	// Only lists the IDs of steps to run.
+	Only []string
+	// Tags selects steps carrying any of these tags; combined with Only, either match selects a step.
+	Tags []string
+	// SkipTags excludes steps carrying any of these tags, even when they are dependencies.
+	SkipTags []string
+	// From starts the run at a step: it and every step that depends on it, directly or not. Steps
+	// it depends on are treated as done and are not pulled back in, unless they register outputs
+	// the run reads.
+	From string
+	// NoDeps stops dependencies of the selected steps from being added to the run, except steps
+	// registering outputs they read.
+	NoDeps bool
+}
+
+// IsZero reports whether the selection keeps every step.
+func (s Selection) IsZero() bool {
+	return len(s.Only) == 0 && len(s.Tags) == 0 && len(s.SkipTags) == 0 && s.From == ""
+}
+
+// Select returns the subgraph of the steps chosen by sel, with its own levels. Dependencies on
+// steps left out of the subgraph are dropped, so those steps are treated as already done. Steps
+// registering outputs that chosen steps read are always kept, since their outputs exist only
+// once they run.
+func (g *Graph) Select(sel Selection) (*Graph, error) {
+	if sel.IsZero() {
+		return g, nil
+	}
+
+	for _, id := range sel.Only {
+		if _, ok := g.Nodes[id]; !ok {
+			return nil, streamyerrors.NewValidationError("only", fmt.Sprintf("unknown step %q", id), nil)
+		}
+	}
+	if sel.From != "" {
+		if _, ok := g.Nodes[sel.From]; !ok {
+			return nil, streamyerrors.NewValidationError("from", fmt.Sprintf("unknown step %q", sel.From), nil)
+		}
+	}
+
+	selected := make(map[string]bool, len(g.Nodes))
+	if len(sel.Only) == 0 && len(sel.Tags) == 0 {
+		for id := range g.Nodes {
+			selected[id] = true
+		}
+	} else {
+		for _, id := range sel.Only {
+			selected[id] = true
+		}
+		for id, node := range g.Nodes {
+			if hasAnyTag(node, sel.Tags) {
+				selected[id] = true
+			}
+		}
+	}
+
+	dependencies := func(n *Node) []*Node { return n.DependsOn }
+	dependents := func(n *Node) []*Node { return n.Dependents }
+
+	var beforeFrom map[string]bool
+	if sel.From != "" {
+		downstream := g.walk(sel.From, dependents)
+		for id := range selected {
+			if !downstream[id] {
+				delete(selected, id)
+			}
+		}
+		beforeFrom = g.walk(sel.From, dependencies)
+		delete(beforeFrom, sel.From)
+	}
+
+	if !sel.NoDeps {
+		roots := make([]string, 0, len(selected))
+		for id := range selected {
+			roots = append(roots, id)
+		}
+		for _, id := range roots {
+			for dep := range g.walk(id, dependencies) {
+				if !beforeFrom[dep] {
+					selected[dep] = true
+				}
+			}
+		}
+	}
+
+	// Steps reading registered outputs need the steps registering them, even those the selection
+	// otherwise treats as done, or the outputs would be undefined.
+	producers := g.producers()
+	pending := make([]string, 0, len(selected))
+	for id := range selected {
+		pending = append(pending, id)
+	}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, producer := range producers.of(g.Nodes[id]) {
+			if !selected[producer] {
+				selected[producer] = true
+				pending = append(pending, producer)
+			}
+		}
+	}
+
+	for id := range selected {
+		if hasAnyTag(g.Nodes[id], sel.SkipTags) {
+			delete(selected, id)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, streamyerrors.NewValidationError("steps", "no steps match the selection", nil)
+	}
+
+	ids := make([]string, 0, len(selected))
+	for id := range selected {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if g.Nodes[id].Step == nil {
+			continue
+		}
+		for _, name := range g.Nodes[id].Step.OutputReferences() {
+			for _, producer := range producers[name] {
+				if !selected[producer] {
+					return nil, streamyerrors.NewValidationError("skip_tags", fmt.Sprintf("step %q reads outputs.%s, but %q, which registers it, is skipped by tag", id, name, producer), nil)
+				}
+			}
+		}
+	}
+
+	sub := NewGraph()
+	for _, id := range ids {
+		if _, err := sub.AddNode(g.Nodes[id].Step); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range ids {
+		linked := make(map[string]bool)
+		for _, dep := range g.Nodes[id].DependsOn {
+			if selected[dep.ID] {
+				linked[dep.ID] = true
+				if err := sub.AddEdge(dep.ID, id); err != nil {
+					return nil, err
+				}
+			}
+		}
+		// Producers may only be reached through steps left out, so order them explicitly.
+		for _, producer := range producers.of(g.Nodes[id]) {
+			if !linked[producer] {
+				linked[producer] = true
+				if err := sub.AddEdge(producer, id); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := sub.TopologicalSort(); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Downstream returns the given steps and every step depending on them, directly or not, sorted.
+// Unknown IDs are ignored.
+func (g *Graph) Downstream(ids ...string) []string {
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if _, ok := g.Nodes[id]; !ok {
+			continue
+		}
+		for dep := range g.walk(id, func(n *Node) []*Node { return n.Dependents }) {
+			seen[dep] = true
+		}
+	}
+
+	out := make([]string, 0, len(seen))
+	for id := range seen {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// registerProducers maps each registered output name to the IDs of the steps registering it.
+type registerProducers map[string][]string
+
+func (g *Graph) producers() registerProducers {
+	producers := make(registerProducers)
+	for id, node := range g.Nodes {
+		if node.Step != nil && node.Step.Register != "" {
+			producers[node.Step.Register] = append(producers[node.Step.Register], id)
+		}
+	}
+	for _, ids := range producers {
+		sort.Strings(ids)
+	}
+	return producers
+}
+
+// of returns the IDs of the steps registering outputs the node's step reads.
+func (p registerProducers) of(node *Node) []string {
+	if node == nil || node.Step == nil {
+		return nil
+	}
+	var ids []string
+	for _, name := range node.Step.OutputReferences() {
+		ids = append(ids, p[name]...)
+	}
+	return ids
+}
+
+// walk returns start and every node reachable from it through next.
+func (g *Graph) walk(start string, next func(*Node) []*Node) map[string]bool {
+	seen := map[string]bool{start: true}
+	stack := []*Node{g.Nodes[start]}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, n := range next(node) {
+			if !seen[n.ID] {
+				seen[n.ID] = true
+				stack = append(stack, n)
+			}
+		}
+	}
+	return seen
+}
+
+func hasAnyTag(node *Node, tags []string) bool {
+	if node == nil || node.Step == nil {
+		return false
+	}
+	for _, tag := range tags {
+		if slices.Contains(node.Step.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+func TestGraphSelect(t *testing.T) {
+	t.Parallel()
+
+	steps := []config.Step{
+		{ID: "base", Type: "command", Enabled: true, Tags: []string{"core"}},
+		{ID: "dotfiles", Type: "symlink", Enabled: true, DependsOn: []string{"base"}, Tags: []string{"dotfiles"}},
+		{ID: "zsh", Type: "symlink", Enabled: true, DependsOn: []string{"dotfiles"}, Tags: []string{"dotfiles", "shell"}},
+		{ID: "packages", Type: "package", Enabled: true, DependsOn: []string{"base"}, Tags: []string{"slow"}},
+		{ID: "editor", Type: "command", Enabled: true, DependsOn: []string{"packages", "dotfiles"}},
+	}
+	graph, err := BuildDAG(steps)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		sel     Selection
+		want    []string
+		wantErr string
+	}{
+		{name: "zero selection keeps everything", want: []string{"base", "dotfiles", "editor", "packages", "zsh"}},
+		{name: "tags pull in dependencies", sel: Selection{Tags: []string{"dotfiles"}}, want: []string{"base", "dotfiles", "zsh"}},
+		{name: "no-deps keeps only tagged steps", sel: Selection{Tags: []string{"dotfiles"}, NoDeps: true}, want: []string{"dotfiles", "zsh"}},
+		{name: "only pulls in the dependency chain", sel: Selection{Only: []string{"zsh"}}, want: []string{"base", "dotfiles", "zsh"}},
+		{name: "only and tags combine", sel: Selection{Only: []string{"packages"}, Tags: []string{"shell"}, NoDeps: true}, want: []string{"packages", "zsh"}},
+		{
+			name: "from keeps downstream steps and their other dependencies",
+			sel:  Selection{From: "dotfiles"},
+			want: []string{"dotfiles", "editor", "packages", "zsh"},
+		},
+		{name: "from with no-deps", sel: Selection{From: "dotfiles", NoDeps: true}, want: []string{"dotfiles", "editor", "zsh"}},
+		{name: "skip-tags removes steps", sel: Selection{SkipTags: []string{"slow"}}, want: []string{"base", "dotfiles", "editor", "zsh"}},
+		{name: "skip-tags wins over dependencies", sel: Selection{Only: []string{"editor"}, SkipTags: []string{"slow"}}, want: []string{"base", "dotfiles", "editor"}},
+		{name: "unknown only step", sel: Selection{Only: []string{"nope"}}, wantErr: `unknown step "nope"`},
+		{name: "unknown from step", sel: Selection{From: "nope"}, wantErr: `unknown step "nope"`},
+		{name: "empty selection", sel: Selection{Tags: []string{"missing"}}, wantErr: "no steps match the selection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sub, err := graph.Select(tt.sel)
+			if tt.wantErr != "" {
+				var validationErr *streamyerrors.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var got []string
+			for id := range sub.Nodes {
+				got = append(got, id)
+			}
+			sort.Strings(got)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGraphSelect_PlansSubgraph(t *testing.T) {
+	t.Parallel()
+
+	steps := []config.Step{
+		{ID: "base", Type: "command", Enabled: true},
+		{ID: "dotfiles", Type: "symlink", Enabled: true, DependsOn: []string{"base"}, Tags: []string{"dotfiles"}},
+		{ID: "zsh", Type: "symlink", Enabled: true, DependsOn: []string{"dotfiles"}, Tags: []string{"dotfiles"}},
+	}
+	graph, err := BuildDAG(steps)
+	require.NoError(t, err)
+
+	sub, err := graph.Select(Selection{Tags: []string{"dotfiles"}, NoDeps: true})
+	require.NoError(t, err)
+	plan, err := GeneratePlan(sub)
+	require.NoError(t, err)
+
+	require.Len(t, plan.Levels, 2)
+	require.Equal(t, []string{"dotfiles"}, plan.Levels[0].StepIDs)
+	require.Equal(t, []string{"zsh"}, plan.Levels[1].StepIDs)
+	// The full graph is left untouched.
+	require.Len(t, graph.Nodes, 3)
+}
+
+func TestGraphSelect_KeepsOutputProducers(t *testing.T) {
+	t.Parallel()
+
+	steps := []config.Step{
+		commandStep(t, config.Step{ID: "producer", Register: "p", Tags: []string{"slow"}}, "1.2.3"),
+		commandStep(t, config.Step{ID: "middle", DependsOn: []string{"producer"}}, "true"),
+		commandStep(t, config.Step{ID: "consumer", DependsOn: []string{"middle"}}, "echo ${outputs.p.stdout}"),
+	}
+	graph, err := BuildDAG(steps)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		sel  Selection
+		want [][]string
+	}{
+		{name: "from", sel: Selection{From: "consumer"}, want: [][]string{{"producer"}, {"consumer"}}},
+		{name: "only with no-deps", sel: Selection{Only: []string{"consumer"}, NoDeps: true}, want: [][]string{{"producer"}, {"consumer"}}},
+		{name: "from with no-deps", sel: Selection{From: "middle", NoDeps: true}, want: [][]string{{"producer"}, {"middle"}, {"consumer"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sub, err := graph.Select(tt.sel)
+			require.NoError(t, err)
+			plan, err := GeneratePlan(sub)
+			require.NoError(t, err)
+			var levels [][]string
+			for _, level := range plan.Levels {
+				levels = append(levels, level.StepIDs)
+			}
+			require.Equal(t, tt.want, levels)
+		})
+	}
+
+	_, err = graph.Select(Selection{Only: []string{"consumer"}, SkipTags: []string{"slow"}})
+	var validationErr *streamyerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.ErrorContains(t, err, `step "consumer" reads outputs.p, but "producer", which registers it, is skipped by tag`)
+
+	// The consumer runs with the producer's output rather than failing on an undefined variable.
+	ep := &echoPlugin{commands: make(map[string]string)}
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(ep))
+	sub, err := graph.Select(Selection{From: "consumer"})
+	require.NoError(t, err)
+	plan, err := GeneratePlan(sub)
+	require.NoError(t, err)
+	_, err = Execute(&ExecutionContext{
+		Config:     &config.Config{Version: "1.0", Name: "outputs", Steps: steps},
+		WorkerPool: make(chan struct{}, 2),
+		Context:    context.Background(),
+		Registry:   registry,
+	}, plan)
+	require.NoError(t, err)
+	require.Equal(t, "echo 1.2.3", ep.commands["consumer"])
+	require.NotContains(t, ep.commands, "middle")
+}
+
+func TestGraphDownstream(t *testing.T) {
+	t.Parallel()
+
+	steps := []config.Step{
+		{ID: "base", Type: "command", Enabled: true},
+		{ID: "dotfiles", Type: "symlink", Enabled: true, DependsOn: []string{"base"}},
+		{ID: "zsh", Type: "symlink", Enabled: true, DependsOn: []string{"dotfiles"}},
+		{ID: "packages", Type: "package", Enabled: true, DependsOn: []string{"base"}},
+	}
+	graph, err := BuildDAG(steps)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"dotfiles", "zsh"}, graph.Downstream("dotfiles"))
+	require.Equal(t, []string{"dotfiles", "packages", "zsh"}, graph.Downstream("packages", "dotfiles", "missing"))
+	require.Empty(t, graph.Downstream())
+}
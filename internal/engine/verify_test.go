@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+// concurrencyPlugin records the peak number of overlapping evaluations.
+type concurrencyPlugin struct {
+	maxConcurrency int
+	statuses       map[string]model.VerificationStatus
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (p *concurrencyPlugin) PluginMetadata() plugin.PluginMetadata {
+	return plugin.PluginMetadata{Name: "command", Version: "1.0.0", Type: "command", MaxConcurrency: p.maxConcurrency}
+}
+
+func (p *concurrencyPlugin) Schema() any { return nil }
+
+func (p *concurrencyPlugin) Evaluate(_ context.Context, step *config.Step) (*model.EvaluationResult, error) {
+	p.mu.Lock()
+	p.inFlight++
+	p.peak = max(p.peak, p.inFlight)
+	p.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	p.mu.Lock()
+	p.inFlight--
+	p.mu.Unlock()
+
+	status := model.StatusSatisfied
+	if s, ok := p.statuses[step.ID]; ok {
+		status = s
+	}
+	return &model.EvaluationResult{StepID: step.ID, CurrentState: status, RequiresAction: status != model.StatusSatisfied}, nil
+}
+
+func (p *concurrencyPlugin) Apply(context.Context, *model.EvaluationResult, *config.Step) (*model.StepResult, error) {
+	return nil, nil
+}
+
+func verifyConcurrently(t *testing.T, cp *concurrencyPlugin, parallel int, steps []config.Step) *model.VerificationSummary {
+	t.Helper()
+
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(cp))
+	for i := range steps {
+		steps[i].Type = "command"
+		steps[i].Enabled = true
+		require.NoError(t, steps[i].SetConfig(config.CommandStep{Command: "true"}))
+	}
+
+	summary, err := NewExecutor(nil).VerifySteps(&ExecutionContext{
+		Config:     &config.Config{Version: "1.0", Name: "verify", Steps: steps},
+		WorkerPool: make(chan struct{}, parallel),
+		Context:    context.Background(),
+		Registry:   registry,
+	}, steps, time.Second)
+	require.NoError(t, err)
+	return summary
+}
+
+func TestVerifySteps_EvaluatesIndependentStepsConcurrently(t *testing.T) {
+	t.Parallel()
+
+	var steps []config.Step
+	for i := range 6 {
+		steps = append(steps, config.Step{ID: fmt.Sprintf("tool_%d", i)})
+	}
+	steps = append(steps,
+		config.Step{ID: "configure", DependsOn: []string{"tool_2"}},
+		config.Step{ID: "finish", DependsOn: []string{"configure", "tool_5"}},
+	)
+	cp := &concurrencyPlugin{statuses: map[string]model.VerificationStatus{"tool_2": model.StatusMissing}}
+
+	summary := verifyConcurrently(t, cp, 3, steps)
+
+	require.Equal(t, 3, cp.peak)
+	ids := make([]string, 0, len(summary.Results))
+	for _, res := range summary.Results {
+		ids = append(ids, res.StepID)
+	}
+	require.Equal(t, []string{"tool_0", "tool_1", "tool_2", "tool_3", "tool_4", "tool_5", "configure", "finish"}, ids)
+	require.Equal(t, model.StatusBlocked, summary.Results[6].Status)
+	require.Equal(t, model.StatusBlocked, summary.Results[7].Status)
+	require.Equal(t, 5, summary.Satisfied)
+	require.Equal(t, 1, summary.Missing)
+	require.Equal(t, 2, summary.Blocked)
+}
+
+func TestVerifySteps_HonoursPluginConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	steps := []config.Step{{ID: "git"}, {ID: "curl"}, {ID: "jq"}, {ID: "zsh"}}
+	cp := &concurrencyPlugin{maxConcurrency: 1}
+
+	summary := verifyConcurrently(t, cp, 4, steps)
+
+	require.Equal(t, 1, cp.peak)
+	require.Equal(t, 4, summary.Satisfied)
+}
+
+// startLogPlugin records, in a log shared between plugins, the order in which steps start evaluating.
+type startLogPlugin struct {
+	name           string
+	maxConcurrency int
+	mu             *sync.Mutex
+	started        *[]string
+}
+
+func (p *startLogPlugin) PluginMetadata() plugin.PluginMetadata {
+	return plugin.PluginMetadata{Name: p.name, Version: "1.0.0", Type: p.name, MaxConcurrency: p.maxConcurrency}
+}
+
+func (p *startLogPlugin) Schema() any { return nil }
+
+func (p *startLogPlugin) Evaluate(_ context.Context, step *config.Step) (*model.EvaluationResult, error) {
+	p.mu.Lock()
+	*p.started = append(*p.started, step.ID)
+	p.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	return &model.EvaluationResult{StepID: step.ID, CurrentState: model.StatusSatisfied}, nil
+}
+
+func (p *startLogPlugin) Apply(context.Context, *model.EvaluationResult, *config.Step) (*model.StepResult, error) {
+	return nil, nil
+}
+
+func TestVerifySteps_PluginLimitDoesNotHoldWorkers(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var started []string
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), nil)
+	require.NoError(t, registry.Register(&startLogPlugin{name: "package", maxConcurrency: 1, mu: &mu, started: &started}))
+	require.NoError(t, registry.Register(&startLogPlugin{name: "command", mu: &mu, started: &started}))
+
+	steps := []config.Step{
+		{ID: "git", Type: "package"}, {ID: "curl", Type: "package"}, {ID: "jq", Type: "package"},
+		{ID: "hello", Type: "command"}, {ID: "world", Type: "command"},
+	}
+	for i := range steps {
+		steps[i].Enabled = true
+		require.NoError(t, steps[i].SetConfig(config.CommandStep{Command: "true"}))
+	}
+
+	summary, err := NewExecutor(nil).VerifySteps(&ExecutionContext{
+		Config:     &config.Config{Version: "1.0", Name: "verify", Steps: steps},
+		WorkerPool: make(chan struct{}, 2),
+		Context:    context.Background(),
+		Registry:   registry,
+	}, steps, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 5, summary.Satisfied)
+
+	// With one package step running, the second worker takes the command steps instead of
+	// waiting for the package slot.
+	kinds := make([]string, 0, len(started))
+	for _, id := range started {
+		kinds = append(kinds, config.StepMap(steps)[id].Type)
+	}
+	require.ElementsMatch(t, []string{"package", "command"}, kinds[:2])
+	require.ElementsMatch(t, []string{"package", "command"}, kinds[2:4])
+	require.Equal(t, "package", kinds[4])
+}
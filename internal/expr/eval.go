@@ -0,0 +1,590 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Function is a helper callable from an expression, such as has_command("git").
+type Function func(args ...any) (any, error)
+
+// Functions maps helper names to their implementations.
+type Functions map[string]Function
+
+// SyntaxError reports a malformed expression.
+type SyntaxError struct {
+	Expr   string
+	Pos    int
+	Reason string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid expression %q at offset %d: %s", e.Expr, e.Pos, e.Reason)
+}
+
+// Expression is a compiled condition that can be evaluated repeatedly.
+//
+// The language supports string, number, boolean, null and list literals,
+// dotted references (facts.os, env.CI, vars.profile), the operators
+// == != && || ! and in, parentheses, and function calls.
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile parses src into an Expression.
+func Compile(src string) (*Expression, error) {
+	p := &parser{src: src, tokens: nil}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Expression{source: src, root: root}, nil
+}
+
+// String returns the original expression source.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// References returns every dotted reference used by the expression.
+func (e *Expression) References() []string {
+	var refs []string
+	walk(e.root, func(n node) {
+		if r, ok := n.(refNode); ok {
+			refs = append(refs, r.path)
+		}
+	})
+	return refs
+}
+
+// Calls returns the name of every function invoked by the expression.
+func (e *Expression) Calls() []string {
+	var calls []string
+	walk(e.root, func(n node) {
+		if c, ok := n.(callNode); ok {
+			calls = append(calls, c.name)
+		}
+	})
+	return calls
+}
+
+// Eval evaluates the expression. References whose root is not in scope are an error;
+// missing keys beneath a known root evaluate to null so optional values (env.CI) can be tested.
+func (e *Expression) Eval(scope Scope, funcs Functions) (any, error) {
+	return e.root.eval(scope, funcs)
+}
+
+// EvalBool evaluates the expression and reports its truthiness.
+func (e *Expression) EvalBool(scope Scope, funcs Functions) (bool, error) {
+	v, err := e.Eval(scope, funcs)
+	if err != nil {
+		return false, err
+	}
+	return Truthy(v), nil
+}
+
+// Truthy reports whether v counts as true in a condition: false, null, "", 0 and empty collections are false.
+func Truthy(v any) bool {
+	switch typed := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return typed
+	case string:
+		return typed != ""
+	case int:
+		return typed != 0
+	case float64:
+		return typed != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() != 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() != 0
+	case reflect.Float32:
+		return rv.Float() != 0
+	}
+	return true
+}
+
+// ---- AST ----
+
+type node interface {
+	eval(scope Scope, funcs Functions) (any, error)
+}
+
+type literalNode struct{ value any }
+
+type refNode struct{ path string }
+
+type listNode struct{ items []node }
+
+type notNode struct{ operand node }
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n literalNode) eval(Scope, Functions) (any, error) { return n.value, nil }
+
+func (n refNode) eval(scope Scope, _ Functions) (any, error) {
+	root := Root(n.path)
+	if !scope.Has(root) {
+		return nil, &UndefinedError{Ref: n.path}
+	}
+	v, _ := scope.Lookup(n.path)
+	return v, nil
+}
+
+func (n listNode) eval(scope Scope, funcs Functions) (any, error) {
+	out := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(scope, funcs)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (n notNode) eval(scope Scope, funcs Functions) (any, error) {
+	v, err := n.operand.eval(scope, funcs)
+	if err != nil {
+		return nil, err
+	}
+	return !Truthy(v), nil
+}
+
+func (n binaryNode) eval(scope Scope, funcs Functions) (any, error) {
+	left, err := n.left.eval(scope, funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit the logical operators.
+	switch n.op {
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(scope, funcs)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(scope, funcs)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	}
+
+	right, err := n.right.eval(scope, funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left), nil
+	}
+	return nil, fmt.Errorf("unsupported operator %q", n.op)
+}
+
+func (n callNode) eval(scope Scope, funcs Functions) (any, error) {
+	fn, ok := funcs[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", n.name)
+	}
+	args := make([]any, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := arg.eval(scope, funcs)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	return fn(args...)
+}
+
+func walk(n node, visit func(node)) {
+	visit(n)
+	switch typed := n.(type) {
+	case listNode:
+		for _, item := range typed.items {
+			walk(item, visit)
+		}
+	case notNode:
+		walk(typed.operand, visit)
+	case binaryNode:
+		walk(typed.left, visit)
+		walk(typed.right, visit)
+	case callNode:
+		for _, arg := range typed.args {
+			walk(arg, visit)
+		}
+	}
+}
+
+// equal compares values loosely: numbers by value, everything else by its string form,
+// so facts gathered as strings compare naturally against YAML scalars.
+func equal(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+	}
+	if ab, ok := a.(bool); ok {
+		bb, ok := b.(bool)
+		return ok && ab == bb
+	}
+	return Stringify(a) == Stringify(b)
+}
+
+func contains(haystack, needle any) bool {
+	switch typed := haystack.(type) {
+	case nil:
+		return false
+	case string:
+		return strings.Contains(typed, Stringify(needle))
+	}
+
+	rv := reflect.ValueOf(haystack)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if equal(rv.Index(i).Interface(), needle) {
+				return true
+			}
+		}
+	case reflect.Map:
+		_, ok := child(haystack, Stringify(needle))
+		return ok
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// ---- lexer and parser ----
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return &SyntaxError{Expr: p.src, Pos: tok.pos, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) lex() error {
+	src := p.src
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			var sb strings.Builder
+			for i < len(src) && rune(src[i]) != c {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				sb.WriteByte(src[i])
+				i++
+			}
+			if i >= len(src) {
+				return &SyntaxError{Expr: src, Pos: start, Reason: "unterminated string"}
+			}
+			i++
+			p.tokens = append(p.tokens, token{kind: tokString, text: sb.String(), pos: start})
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			i++
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokNumber, text: src[start:i], pos: start})
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || src[i] == '-' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			start := i
+			two := ""
+			if i+1 < len(src) {
+				two = src[i : i+2]
+			}
+			switch two {
+			case "==", "!=", "&&", "||":
+				p.tokens = append(p.tokens, token{kind: tokOp, text: two, pos: start})
+				i += 2
+				continue
+			}
+			switch c {
+			case '!', '(', ')', '[', ']', ',':
+				p.tokens = append(p.tokens, token{kind: tokOp, text: string(c), pos: start})
+				i++
+			default:
+				return &SyntaxError{Expr: src, Pos: start, Reason: fmt.Sprintf("unexpected character %q", c)}
+			}
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokEOF, pos: len(src)})
+	return nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) accept(kind tokenKind, text string) bool {
+	tok := p.peek()
+	if tok.kind == kind && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(tokOp, text) {
+		tok := p.peek()
+		if tok.kind == tokEOF {
+			return p.errorf(tok, "expected %q before end of expression", text)
+		}
+		return p.errorf(tok, "expected %q, found %q", text, tok.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokOp, "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokOp, "&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept(tokOp, "!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	var op string
+	switch {
+	case tok.kind == tokOp && (tok.text == "==" || tok.text == "!="):
+		op = tok.text
+	case tok.kind == tokIdent && tok.text == "in":
+		op = "in"
+	case tok.kind == tokIdent && tok.text == "not" && p.tokens[p.pos+1].kind == tokIdent && p.tokens[p.pos+1].text == "in":
+		p.pos++
+		op = "not in"
+	default:
+		return left, nil
+	}
+	p.pos++
+
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if op == "not in" {
+		return notNode{operand: binaryNode{op: "in", left: left, right: right}}, nil
+	}
+	return binaryNode{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokEOF:
+		return nil, p.errorf(tok, "unexpected end of expression")
+	case tokString:
+		return literalNode{value: tok.text}, nil
+	case tokNumber:
+		if n, err := strconv.Atoi(tok.text); err == nil {
+			return literalNode{value: n}, nil
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %q", tok.text)
+		}
+		return literalNode{value: f}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null", "nil":
+			return literalNode{value: nil}, nil
+		case "in", "not":
+			return nil, p.errorf(tok, "unexpected %q", tok.text)
+		}
+		if p.accept(tokOp, "(") {
+			if strings.Contains(tok.text, ".") {
+				return nil, p.errorf(tok, "invalid function name %q", tok.text)
+			}
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return callNode{name: tok.text, args: args}, nil
+		}
+		if strings.HasSuffix(tok.text, ".") || strings.Contains(tok.text, "..") {
+			return nil, p.errorf(tok, "invalid reference %q", tok.text)
+		}
+		return refNode{path: tok.text}, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return listNode{items: items}, nil
+		case "!":
+			p.pos--
+			return p.parseUnary()
+		}
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if p.accept(tokOp, closing) {
+		return items, nil
+	}
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.accept(tokOp, closing) {
+			return items, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpressionEval(t *testing.T) {
+	t.Parallel()
+
+	scope := Scope{
+		"facts": map[string]any{"os": "linux", "distro": "fedora", "arch": "amd64"},
+		"env":   map[string]string{"CI": "true"},
+		"vars":  map[string]any{"profile": "laptop", "cores": 8, "groups": []any{"docker", "wheel"}},
+	}
+	funcs := Functions{
+		"has_command": func(args ...any) (any, error) { return args[0] == "git", nil },
+	}
+
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{`facts.os == "linux"`, true},
+		{`facts.os != 'linux'`, false},
+		{`facts.distro in ["ubuntu", "debian"]`, false},
+		{`facts.distro not in ["ubuntu", "debian"]`, true},
+		{`facts.os == "linux" && (facts.distro == "fedora" || facts.distro == "rhel")`, true},
+		{`!env.CI`, false},
+		{`env.MISSING == null`, true},
+		{`!env.MISSING`, true},
+		{`vars.cores == 8`, true},
+		{`"docker" in vars.groups`, true},
+		{`"arm" in facts.arch`, false},
+		{`has_command("git") && !has_command("svn")`, true},
+		{`true || undefined_root.x`, true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+
+			compiled, err := Compile(tc.expr)
+			require.NoError(t, err)
+			got, err := compiled.EvalBool(scope, funcs)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, src := range []string{`facts.os ==`, `(facts.os == "linux"`, `facts.os = "linux"`, `"unterminated`, `has_command("git"`, `facts.os "linux"`} {
+		_, err := Compile(src)
+		var syntaxErr *SyntaxError
+		require.ErrorAs(t, err, &syntaxErr, src)
+	}
+
+	compiled, err := Compile(`hosts.name == "x"`)
+	require.NoError(t, err)
+	_, err = compiled.EvalBool(Scope{}, nil)
+	var undefined *UndefinedError
+	require.ErrorAs(t, err, &undefined)
+
+	compiled, err = Compile(`nope("x")`)
+	require.NoError(t, err)
+	_, err = compiled.EvalBool(Scope{}, nil)
+	require.ErrorContains(t, err, "unknown function")
+}
+
+func TestExpressionIntrospection(t *testing.T) {
+	t.Parallel()
+
+	compiled, err := Compile(`facts.os == "linux" && has_command(vars.tool) || env.CI`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"facts.os", "vars.tool", "env.CI"}, compiled.References())
+	require.Equal(t, []string{"has_command"}, compiled.Calls())
+}
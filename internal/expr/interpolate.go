@@ -0,0 +1,163 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// referencePattern matches ${root.path} references; whitespace inside the braces is ignored.
+var referencePattern = regexp.MustCompile(`\$\{\s*([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z0-9_-]+)*)\s*\}`)
+
+// FieldError records which field of a nested value failed to interpolate.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+// Unwrap exposes the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// References returns every ${...} reference found in s, in order of appearance.
+func References(s string) []string {
+	matches := referencePattern.FindAllStringSubmatch(s, -1)
+	refs := make([]string, 0, len(matches))
+	for _, m := range matches {
+		refs = append(refs, m[1])
+	}
+	return refs
+}
+
+// Interpolate substitutes ${root.path} references whose root is defined in scope.
+// References to roots the scope does not know about are left untouched, so shell
+// syntax such as ${HOME} passes through. When s consists of a single reference the
+// referenced value is returned as-is, allowing lists and maps to be substituted.
+func Interpolate(s string, scope Scope) (any, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	if m := referencePattern.FindStringSubmatchIndex(s); m != nil && m[0] == 0 && m[1] == len(s) {
+		ref := s[m[2]:m[3]]
+		if !scope.Has(Root(ref)) {
+			return s, nil
+		}
+		value, ok := scope.Lookup(ref)
+		if !ok {
+			return nil, &UndefinedError{Ref: ref}
+		}
+		return value, nil
+	}
+
+	var firstErr error
+	out := referencePattern.ReplaceAllStringFunc(s, func(match string) string {
+		ref := referencePattern.FindStringSubmatch(match)[1]
+		if !scope.Has(Root(ref)) {
+			return match
+		}
+		value, ok := scope.Lookup(ref)
+		if !ok {
+			if firstErr == nil {
+				firstErr = &UndefinedError{Ref: ref}
+			}
+			return match
+		}
+		return Stringify(value)
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return out, nil
+}
+
+// InterpolateValue walks maps and slices decoded from YAML and interpolates every string.
+// Errors are returned as *FieldError naming the offending field (e.g. "packages[1]").
+func InterpolateValue(value any, scope Scope) (any, error) {
+	return interpolateValue(value, scope, "")
+}
+
+func interpolateValue(value any, scope Scope, field string) (any, error) {
+	switch typed := value.(type) {
+	case string:
+		out, err := Interpolate(typed, scope)
+		if err != nil {
+			return nil, &FieldError{Field: field, Err: err}
+		}
+		return out, nil
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		out := make(map[string]any, len(typed))
+		for _, k := range keys {
+			v, err := interpolateValue(typed[k], scope, joinField(field, k))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = v
+		}
+		return out, nil
+	case []any:
+		out := make([]any, 0, len(typed))
+		for i, item := range typed {
+			v, err := interpolateValue(item, scope, fmt.Sprintf("%s[%d]", field, i))
+			if err != nil {
+				return nil, err
+			}
+			// A list element that expands to a list is spliced into the parent list.
+			if s, ok := item.(string); ok && isWholeReference(s) {
+				if spliced, ok := v.([]any); ok {
+					out = append(out, spliced...)
+					continue
+				}
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// Stringify renders a resolved value for embedding inside a larger string.
+func Stringify(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case []any:
+		parts := make([]string, 0, len(typed))
+		for _, item := range typed {
+			parts = append(parts, Stringify(item))
+		}
+		return strings.Join(parts, " ")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func isWholeReference(s string) bool {
+	m := referencePattern.FindStringIndex(s)
+	return m != nil && m[0] == 0 && m[1] == len(s)
+}
+
+func joinField(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,105 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Parallel()
+
+	scope := Scope{
+		"vars": map[string]any{
+			"user":     "alice",
+			"port":     8080,
+			"packages": []any{"git", "curl"},
+			"nested":   map[string]any{"dir": "/opt/app"},
+		},
+	}
+
+	cases := []struct {
+		name    string
+		input   string
+		want    any
+		wantRef string
+	}{
+		{name: "plain string is unchanged", input: "echo hello", want: "echo hello"},
+		{name: "embedded reference", input: "/home/${vars.user}/.config", want: "/home/alice/.config"},
+		{name: "whitespace inside braces", input: "${ vars.user }", want: "alice"},
+		{name: "whole reference keeps type", input: "${vars.port}", want: 8080},
+		{name: "whole reference to list", input: "${vars.packages}", want: []any{"git", "curl"}},
+		{name: "nested lookup", input: "${vars.nested.dir}/bin", want: "/opt/app/bin"},
+		{name: "list index", input: "${vars.packages.1}", want: "curl"},
+		{name: "unknown roots pass through", input: "echo ${HOME} ${vars.user}", want: "echo ${HOME} alice"},
+		{name: "undefined variable", input: "echo ${vars.missing}", wantRef: "vars.missing"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Interpolate(tc.input, scope)
+			if tc.wantRef != "" {
+				var undefined *UndefinedError
+				require.ErrorAs(t, err, &undefined)
+				require.Equal(t, tc.wantRef, undefined.Ref)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestInterpolateValue(t *testing.T) {
+	t.Parallel()
+
+	scope := Scope{"vars": map[string]any{
+		"packages": []any{"git", "curl"},
+		"home":     "/home/alice",
+	}}
+
+	t.Run("walks nested values and splices lists", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := InterpolateValue(map[string]any{
+			"destination": "${vars.home}/.gitconfig",
+			"packages":    []any{"${vars.packages}", "jq"},
+			"env":         map[string]any{"HOME": "${vars.home}"},
+			"mode":        420,
+		}, scope)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"destination": "/home/alice/.gitconfig",
+			"packages":    []any{"git", "curl", "jq"},
+			"env":         map[string]any{"HOME": "/home/alice"},
+			"mode":        420,
+		}, got)
+	})
+
+	t.Run("errors name the offending field", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := InterpolateValue(map[string]any{
+			"env":      map[string]any{"PATH": "${vars.path}"},
+			"packages": []any{"git", "${vars.extra}"},
+		}, scope)
+
+		var fieldErr *FieldError
+		require.ErrorAs(t, err, &fieldErr)
+		require.Equal(t, "env.PATH", fieldErr.Field)
+
+		_, err = InterpolateValue(map[string]any{"packages": []any{"git", "${vars.extra}"}}, scope)
+		require.ErrorAs(t, err, &fieldErr)
+		require.Equal(t, "packages[1]", fieldErr.Field)
+	})
+}
+
+func TestReferences(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"vars.a", "outputs.step.stdout"}, References("${vars.a}-${ outputs.step.stdout }"))
+	require.Empty(t, References("no references"))
+}
@@ -0,0 +1,86 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Scope maps root names (for example "vars") to the values reachable beneath them.
+type Scope map[string]any
+
+// UndefinedError reports a reference that could not be resolved against a scope.
+type UndefinedError struct {
+	Ref string
+}
+
+func (e *UndefinedError) Error() string {
+	return fmt.Sprintf("undefined variable %q", e.Ref)
+}
+
+// Has reports whether the scope defines the given root.
+func (s Scope) Has(root string) bool {
+	_, ok := s[root]
+	return ok
+}
+
+// Lookup resolves a dotted path such as "vars.user.name" against the scope.
+// Numeric segments index into lists ("vars.packages.0").
+func (s Scope) Lookup(path string) (any, bool) {
+	segments := strings.Split(path, ".")
+	current, ok := s[segments[0]]
+	if !ok {
+		return nil, false
+	}
+
+	for _, segment := range segments[1:] {
+		current, ok = child(current, segment)
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// Root returns the first segment of a dotted reference.
+func Root(path string) string {
+	root, _, _ := strings.Cut(path, ".")
+	return root
+}
+
+func child(value any, key string) (any, bool) {
+	switch typed := value.(type) {
+	case nil:
+		return nil, false
+	case map[string]any:
+		v, ok := typed[key]
+		return v, ok
+	case map[string]string:
+		v, ok := typed[key]
+		return v, ok
+	case Scope:
+		v, ok := typed[key]
+		return v, ok
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		v := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+		if !v.IsValid() {
+			return nil, false
+		}
+		return v.Interface(), true
+	case reflect.Slice, reflect.Array:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= rv.Len() {
+			return nil, false
+		}
+		return rv.Index(idx).Interface(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,44 @@
+package facts
+
+import (
+	"context"
+	"sync"
+)
+
+// Cache gathers facts at most once and shares them for the rest of a run.
+type Cache struct {
+	once  sync.Once
+	facts *Facts
+}
+
+// NewCache returns a cache seeded with f. When f is nil the facts are gathered on first use.
+func NewCache(f *Facts) *Cache {
+	return &Cache{facts: f}
+}
+
+// Get returns the cached facts, gathering them if necessary.
+func (c *Cache) Get(ctx context.Context) *Facts {
+	c.once.Do(func() {
+		if c.facts == nil {
+			c.facts = Gather(ctx)
+		}
+	})
+	return c.facts
+}
+
+type cacheKey struct{}
+
+// NewContext returns a context carrying the run's fact cache.
+func NewContext(ctx context.Context, cache *Cache) context.Context {
+	return context.WithValue(ctx, cacheKey{}, cache)
+}
+
+// FromContext returns the facts for the run carried by ctx, gathering fresh facts when ctx has no cache.
+func FromContext(ctx context.Context) *Facts {
+	if ctx != nil {
+		if cache, ok := ctx.Value(cacheKey{}).(*Cache); ok && cache != nil {
+			return cache.Get(ctx)
+		}
+	}
+	return Gather(ctx)
+}
@@ -0,0 +1,167 @@
+// Package facts gathers information about the host Streamy is running on so
+// steps can be conditioned on it and templates can adapt to it.
+package facts
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/user"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// osReleasePath is the file distribution details are read from; overridden in tests.
+var osReleasePath = "/etc/os-release"
+
+// Facts describes the host.
+type Facts struct {
+	OS              string            `json:"os"`
+	Distro          string            `json:"distro"`
+	DistroVersion   string            `json:"distro_version"`
+	DistroLike      []string          `json:"distro_like,omitempty"`
+	Kernel          string            `json:"kernel"`
+	Arch            string            `json:"arch"`
+	Hostname        string            `json:"hostname"`
+	CPUs            int               `json:"cpus"`
+	MemoryBytes     uint64            `json:"memory_bytes"`
+	User            string            `json:"user"`
+	Home            string            `json:"home"`
+	Shell           string            `json:"shell"`
+	PackageManagers []string          `json:"package_managers"`
+	Tools           map[string]string `json:"tools"`
+}
+
+// Gather collects facts about the current host. Information that cannot be determined is left empty.
+func Gather(ctx context.Context) *Facts {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	f := &Facts{
+		OS:     runtime.GOOS,
+		Arch:   runtime.GOARCH,
+		CPUs:   runtime.NumCPU(),
+		Kernel: kernelRelease(ctx),
+		Shell:  os.Getenv("SHELL"),
+	}
+
+	if host, err := os.Hostname(); err == nil {
+		f.Hostname = host
+	}
+
+	if file, err := os.Open(osReleasePath); err == nil {
+		release := parseOSRelease(file)
+		_ = file.Close()
+		f.Distro = release["ID"]
+		f.DistroVersion = release["VERSION_ID"]
+		f.DistroLike = strings.Fields(release["ID_LIKE"])
+	}
+	if f.Distro == "" && f.OS == "darwin" {
+		f.Distro = "macos"
+	}
+
+	f.MemoryBytes = totalMemory(ctx)
+
+	if current, err := user.Current(); err == nil {
+		f.User = current.Username
+		f.Home = current.HomeDir
+	}
+	if f.Home == "" {
+		f.Home, _ = os.UserHomeDir()
+	}
+
+	f.PackageManagers = detectPackageManagers()
+	f.Tools = detectToolVersions(ctx)
+
+	return f
+}
+
+// Map exposes the facts for expressions and templates, keyed by snake_case names.
+func (f *Facts) Map() map[string]any {
+	if f == nil {
+		return map[string]any{}
+	}
+
+	managers := make([]any, 0, len(f.PackageManagers))
+	for _, m := range f.PackageManagers {
+		managers = append(managers, m)
+	}
+	like := make([]any, 0, len(f.DistroLike))
+	for _, d := range f.DistroLike {
+		like = append(like, d)
+	}
+	tools := make(map[string]any, len(f.Tools))
+	for name, version := range f.Tools {
+		tools[name] = version
+	}
+
+	return map[string]any{
+		"os":               f.OS,
+		"distro":           f.Distro,
+		"distro_version":   f.DistroVersion,
+		"distro_like":      like,
+		"kernel":           f.Kernel,
+		"arch":             f.Arch,
+		"hostname":         f.Hostname,
+		"cpus":             f.CPUs,
+		"memory_mb":        int(f.MemoryBytes / (1024 * 1024)),
+		"user":             f.User,
+		"home":             f.Home,
+		"shell":            f.Shell,
+		"package_managers": managers,
+		"package_manager":  f.PrimaryPackageManager(),
+		"tools":            tools,
+	}
+}
+
+// PrimaryPackageManager returns the preferred system package manager, or an empty string when none was found.
+func (f *Facts) PrimaryPackageManager() string {
+	if f == nil || len(f.PackageManagers) == 0 {
+		return ""
+	}
+	return f.PackageManagers[0]
+}
+
+// ToolNames returns the names of detected tools in sorted order.
+func (f *Facts) ToolNames() []string {
+	if f == nil {
+		return nil
+	}
+	names := make([]string, 0, len(f.Tools))
+	for name := range f.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Environment returns the process environment as a map.
+func Environment() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+func parseOSRelease(r io.Reader) map[string]string {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[key] = strings.Trim(value, `"'`)
+	}
+	return values
+}
@@ -0,0 +1,126 @@
+package facts
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	t.Parallel()
+
+	values := parseOSRelease(strings.NewReader(`# comment
+NAME="Ubuntu"
+ID=ubuntu
+VERSION_ID="22.04"
+
+ID_LIKE=debian
+`))
+
+	require.Equal(t, "ubuntu", values["ID"])
+	require.Equal(t, "22.04", values["VERSION_ID"])
+	require.Equal(t, "Ubuntu", values["NAME"])
+	require.Equal(t, "debian", values["ID_LIKE"])
+}
+
+func TestParseMeminfo(t *testing.T) {
+	t.Parallel()
+
+	total := parseMeminfo(strings.NewReader("MemTotal:       16303412 kB\nMemFree:         1234 kB\n"))
+	require.Equal(t, uint64(16303412*1024), total)
+	require.Zero(t, parseMeminfo(strings.NewReader("garbage\n")))
+}
+
+func TestParseVersion(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"git version 2.43.0\n":            "2.43.0",
+		"go version go1.22.3 linux/amd64": "1.22.3",
+		"Python 3.12.1":                   "3.12.1",
+		"v20.11.0":                        "20.11.0",
+		"GNU bash, version 5.2.21(1)-release\nmore 1.0": "5.2.21",
+		"no version here": "",
+	}
+	for input, want := range cases {
+		require.Equal(t, want, parseVersion(input), input)
+	}
+}
+
+func TestGather(t *testing.T) {
+	t.Parallel()
+
+	f := Gather(context.Background())
+	require.Equal(t, runtime.GOOS, f.OS)
+	require.Equal(t, runtime.GOARCH, f.Arch)
+	require.Equal(t, runtime.NumCPU(), f.CPUs)
+	require.NotNil(t, f.Tools)
+
+	m := f.Map()
+	require.Equal(t, f.OS, m["os"])
+	require.Equal(t, f.CPUs, m["cpus"])
+	require.Equal(t, f.PrimaryPackageManager(), m["package_manager"])
+}
+
+// Tests below replace package-level indirections and therefore do not run in parallel.
+
+func TestDetectPackageManagersAndTools(t *testing.T) {
+	origLookPath, origRun := lookPath, runCommand
+	t.Cleanup(func() { lookPath, runCommand = origLookPath, origRun })
+
+	available := map[string]bool{"dnf": true, "brew": true, "git": true, "go": true}
+	lookPath = func(name string) (string, error) {
+		if available[name] {
+			return "/usr/bin/" + name, nil
+		}
+		return "", errors.New("not found")
+	}
+	runCommand = func(_ context.Context, name string, _ ...string) (string, error) {
+		switch name {
+		case "git":
+			return "git version 2.45.1", nil
+		case "go":
+			return "", errors.New("broken toolchain")
+		}
+		return "", errors.New("unexpected")
+	}
+
+	require.Equal(t, []string{"dnf", "brew"}, detectPackageManagers())
+	require.Equal(t, map[string]string{"git": "2.45.1"}, detectToolVersions(context.Background()))
+
+	found, err := hasCommand("git")
+	require.NoError(t, err)
+	require.Equal(t, true, found)
+}
+
+func TestHasCommand(t *testing.T) {
+	t.Parallel()
+
+	fn := Functions()["has_command"]
+
+	found, err := fn("sh")
+	require.NoError(t, err)
+	require.Equal(t, true, found)
+
+	found, err = fn("definitely-not-a-streamy-command")
+	require.NoError(t, err)
+	require.Equal(t, false, found)
+
+	_, err = fn()
+	require.Error(t, err)
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	preset := &Facts{OS: "plan9", Distro: "bell"}
+	cache := NewCache(preset)
+	ctx := NewContext(context.Background(), cache)
+
+	require.Same(t, preset, FromContext(ctx))
+	require.Same(t, cache.Get(ctx), cache.Get(context.Background()))
+}
@@ -0,0 +1,64 @@
+package facts
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Paths read on Linux; overridden in tests.
+var (
+	kernelReleasePath = "/proc/sys/kernel/osrelease"
+	meminfoPath       = "/proc/meminfo"
+)
+
+func kernelRelease(ctx context.Context) string {
+	if data, err := os.ReadFile(kernelReleasePath); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	out, err := runCommand(ctx, "uname", "-r")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+func totalMemory(ctx context.Context) uint64 {
+	if file, err := os.Open(meminfoPath); err == nil {
+		defer file.Close()
+		if total := parseMeminfo(file); total > 0 {
+			return total
+		}
+	}
+
+	if runtime.GOOS == "darwin" {
+		out, err := runCommand(ctx, "sysctl", "-n", "hw.memsize")
+		if err == nil {
+			if total, err := strconv.ParseUint(strings.TrimSpace(out), 10, 64); err == nil {
+				return total
+			}
+		}
+	}
+	return 0
+}
+
+// parseMeminfo returns MemTotal in bytes from /proc/meminfo content.
+func parseMeminfo(r io.Reader) uint64 {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb * 1024
+	}
+	return 0
+}
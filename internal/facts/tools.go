@@ -0,0 +1,125 @@
+package facts
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexisbeaulieu97/streamy/internal/expr"
+)
+
+// toolTimeout bounds each version probe so a misbehaving binary cannot stall a run.
+const toolTimeout = 2 * time.Second
+
+// packageManagers lists supported package managers in order of preference, keyed by the binary that identifies them.
+var packageManagers = []struct {
+	Name   string
+	Binary string
+}{
+	{Name: "apt", Binary: "apt-get"},
+	{Name: "dnf", Binary: "dnf"},
+	{Name: "yum", Binary: "yum"},
+	{Name: "pacman", Binary: "pacman"},
+	{Name: "apk", Binary: "apk"},
+	{Name: "zypper", Binary: "zypper"},
+	{Name: "brew", Binary: "brew"},
+}
+
+// toolProbes lists the tools whose versions are reported, with the arguments that print the version.
+var toolProbes = map[string][]string{
+	"git":     {"--version"},
+	"go":      {"version"},
+	"python3": {"--version"},
+	"node":    {"--version"},
+	"npm":     {"--version"},
+	"cargo":   {"--version"},
+	"ruby":    {"--version"},
+	"docker":  {"--version"},
+	"make":    {"--version"},
+	"curl":    {"--version"},
+	"zsh":     {"--version"},
+	"bash":    {"--version"},
+}
+
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// Indirections over the host, replaced in tests.
+var (
+	lookPath   = exec.LookPath
+	runCommand = func(ctx context.Context, name string, args ...string) (string, error) {
+		out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+		return string(out), err
+	}
+)
+
+// Functions returns the helpers available to conditions.
+func Functions() expr.Functions {
+	return expr.Functions{
+		"has_command": hasCommand,
+	}
+}
+
+func hasCommand(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("has_command expects 1 argument, got %d", len(args))
+	}
+	name, ok := args[0].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("has_command expects a command name")
+	}
+	_, err := lookPath(name)
+	return err == nil, nil
+}
+
+func detectPackageManagers() []string {
+	var found []string
+	for _, pm := range packageManagers {
+		if _, err := lookPath(pm.Binary); err == nil {
+			found = append(found, pm.Name)
+		}
+	}
+	return found
+}
+
+func detectToolVersions(ctx context.Context) map[string]string {
+	versions := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, args := range toolProbes {
+		if _, err := lookPath(name); err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, args []string) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, toolTimeout)
+			defer cancel()
+
+			out, err := runCommand(probeCtx, name, args...)
+			version := parseVersion(out)
+			if err != nil && version == "" {
+				return
+			}
+			mu.Lock()
+			versions[name] = version
+			mu.Unlock()
+		}(name, args)
+	}
+
+	wg.Wait()
+	return versions
+}
+
+// parseVersion extracts the first dotted version number from a tool's version banner.
+func parseVersion(output string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	if v := versionPattern.FindString(line); v != "" {
+		return v
+	}
+	return versionPattern.FindString(output)
+}
@@ -0,0 +1,201 @@
+// Package hooks runs the lifecycle hooks declared under `hooks:` around apply and verify.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/logger"
+)
+
+// Summary describes the run a hook is attached to. Command hooks receive it as JSON on stdin.
+type Summary struct {
+	Event      string    `json:"event"`
+	Operation  string    `json:"operation"`
+	Pipeline   string    `json:"pipeline"`
+	ConfigPath string    `json:"config_path"`
+	DryRun     bool      `json:"dry_run"`
+	StartedAt  time.Time `json:"started_at"`
+	// Status is "success" or "failed" once the run has finished, and empty for before_* hooks.
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	RunID  string `json:"run_id,omitempty"`
+	// DurationMS is the run's wall-clock time in milliseconds, once it has finished.
+	DurationMS int64         `json:"duration_ms,omitempty"`
+	Steps      []StepSummary `json:"steps,omitempty"`
+	Handlers   []StepSummary `json:"handlers,omitempty"`
+}
+
+// StepSummary is the outcome of a single step (or handler) in a Summary.
+type StepSummary struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Func is a hook implemented in Go, referenced from the config with `func: <name>`.
+type Func func(ctx context.Context, summary Summary) error
+
+// Registry holds the Go hook functions available to configurations.
+type Registry struct {
+	mu    sync.RWMutex
+	funcs map[string]Func
+}
+
+// NewRegistry creates an empty hook registry.
+func NewRegistry() *Registry {
+	return &Registry{funcs: make(map[string]Func)}
+}
+
+// Register makes fn available to configurations as `func: name`.
+func (r *Registry) Register(name string, fn Func) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("hook name is required")
+	}
+	if fn == nil {
+		return fmt.Errorf("hook %q: function is nil", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.funcs[name]; exists {
+		return fmt.Errorf("hook %q is already registered", name)
+	}
+	r.funcs[name] = fn
+	return nil
+}
+
+// Get returns the function registered under name.
+func (r *Registry) Get(name string) (Func, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.funcs[name]
+	return fn, ok
+}
+
+// Names lists the registered functions in sorted order.
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.funcs))
+	for name := range r.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run executes hooks in order for summary.Event and stops at the first failure.
+func Run(ctx context.Context, reg *Registry, log *logger.Logger, hooks []config.Hook, summary Summary) error {
+	for _, hook := range hooks {
+		start := time.Now()
+		err := runHook(ctx, reg, hook, summary)
+		if log != nil {
+			entry := log.WithFields(map[string]any{
+				"hook":        hook.Label(),
+				"event":       summary.Event,
+				"duration_ms": time.Since(start).Milliseconds(),
+			})
+			if err != nil {
+				entry.Error(err, "hook failed")
+			} else {
+				entry.Debug("hook completed")
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook %q: %w", summary.Event, hook.Label(), err)
+		}
+	}
+	return nil
+}
+
+func runHook(ctx context.Context, reg *Registry, hook config.Hook, summary Summary) error {
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(hook.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	if hook.Func != "" {
+		fn, ok := reg.Get(hook.Func)
+		if !ok {
+			return fmt.Errorf("no hook function registered as %q", hook.Func)
+		}
+		return fn(ctx, summary)
+	}
+
+	payload, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("encode summary: %w", err)
+	}
+
+	shell, args := shellFor(hook.Shell)
+	cmd := exec.CommandContext(ctx, shell, append(args, hook.Command)...)
+	cmd.Dir = hook.WorkDir
+	cmd.Env = append(os.Environ(), Environment(summary)...)
+	for key, value := range hook.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Don't wait on background processes that inherited the output pipe once the hook is killed.
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if out := strings.TrimSpace(output.String()); out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// Environment returns the STREAMY_* variables describing the run to command hooks.
+func Environment(summary Summary) []string {
+	env := []string{
+		"STREAMY_HOOK=" + summary.Event,
+		"STREAMY_OPERATION=" + summary.Operation,
+		"STREAMY_PIPELINE=" + summary.Pipeline,
+		"STREAMY_CONFIG=" + summary.ConfigPath,
+		"STREAMY_DRY_RUN=" + strconv.FormatBool(summary.DryRun),
+	}
+	if summary.Status != "" {
+		env = append(env, "STREAMY_STATUS="+summary.Status)
+	}
+	if summary.RunID != "" {
+		env = append(env, "STREAMY_RUN_ID="+summary.RunID)
+	}
+	return env
+}
+
+func shellFor(explicit string) (string, []string) {
+	if explicit != "" {
+		return explicit, []string{"-c"}
+	}
+	if runtime.GOOS == "windows" {
+		return "cmd", []string{"/C"}
+	}
+	return "sh", []string{"-c"}
+}
@@ -0,0 +1,99 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+)
+
+func TestRun_CommandReceivesSummaryAndEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("command hooks use POSIX shell syntax in this test")
+	}
+
+	dir := t.TempDir()
+	summary := Summary{
+		Event:      config.HookAfterApply,
+		Operation:  "apply",
+		Pipeline:   "dotfiles",
+		ConfigPath: "/tmp/streamy.yaml",
+		StartedAt:  time.Now().UTC().Truncate(time.Second),
+		Status:     "success",
+		Steps:      []StepSummary{{ID: "step1", Status: "success"}},
+	}
+	hook := config.Hook{
+		Command: `cat > summary.json && printf '%s|%s|%s|%s' "$STREAMY_HOOK" "$STREAMY_PIPELINE" "$STREAMY_STATUS" "$EXTRA" > env.txt`,
+		WorkDir: dir,
+		Env:     map[string]string{"EXTRA": "yes"},
+	}
+
+	require.NoError(t, Run(context.Background(), NewRegistry(), nil, []config.Hook{hook}, summary))
+
+	data, err := os.ReadFile(filepath.Join(dir, "summary.json"))
+	require.NoError(t, err)
+	var got Summary
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, summary.Pipeline, got.Pipeline)
+	require.Equal(t, summary.Steps, got.Steps)
+	require.True(t, summary.StartedAt.Equal(got.StartedAt))
+
+	env, err := os.ReadFile(filepath.Join(dir, "env.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "after_apply|dotfiles|success|yes", string(env))
+}
+
+func TestRun_StopsAtFirstFailure(t *testing.T) {
+	reg := NewRegistry()
+	var calls []string
+	require.NoError(t, reg.Register("ok", func(_ context.Context, s Summary) error {
+		calls = append(calls, "ok:"+s.Event)
+		return nil
+	}))
+	require.NoError(t, reg.Register("fail", func(context.Context, Summary) error {
+		calls = append(calls, "fail")
+		return errors.New("nope")
+	}))
+
+	hooks := []config.Hook{{Func: "ok"}, {Name: "gate", Func: "fail"}, {Func: "ok"}}
+	err := Run(context.Background(), reg, nil, hooks, Summary{Event: config.HookBeforeApply})
+	require.EqualError(t, err, `before_apply hook "gate": nope`)
+	require.Equal(t, []string{"ok:before_apply", "fail"}, calls)
+}
+
+func TestRun_Errors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("command hooks use POSIX shell syntax in this test")
+	}
+
+	summary := Summary{Event: config.HookBeforeVerify}
+
+	err := Run(context.Background(), NewRegistry(), nil, []config.Hook{{Func: "missing"}}, summary)
+	require.ErrorContains(t, err, `no hook function registered as "missing"`)
+
+	err = Run(context.Background(), NewRegistry(), nil, []config.Hook{{Command: "echo broken >&2; exit 3"}}, summary)
+	require.ErrorContains(t, err, "exit status 3: broken")
+
+	err = Run(context.Background(), NewRegistry(), nil, []config.Hook{{Command: "sleep 5", Timeout: 1}}, summary)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	reg := NewRegistry()
+	noop := func(context.Context, Summary) error { return nil }
+
+	require.NoError(t, reg.Register("b", noop))
+	require.NoError(t, reg.Register("a", noop))
+	require.Error(t, reg.Register("a", noop))
+	require.Error(t, reg.Register(" ", noop))
+	require.Error(t, reg.Register("c", nil))
+	require.Equal(t, []string{"a", "b"}, reg.Names())
+}
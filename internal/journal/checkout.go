@@ -0,0 +1,54 @@
+package journal
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+func describeCheckout(op Operation) string {
+	target := fmt.Sprintf("detached at %s", short(op.Commit))
+	if op.Branch != "" {
+		target = fmt.Sprintf("%s at %s", op.Branch, short(op.Commit))
+	}
+	description := fmt.Sprintf("restore checkout %s to %s", op.Path, target)
+	if op.Stash != "" {
+		description += fmt.Sprintf(" and reapply local changes (%s)", short(op.Stash))
+	}
+	return description
+}
+
+// restoreCheckout checks out the recorded branch, moved back to the recorded commit, or the
+// commit alone when HEAD was detached, then reapplies the uncommitted changes. Git refuses
+// the checkout rather than overwrite changes made since the run.
+func restoreCheckout(op Operation) error {
+	args := []string{"checkout", "--quiet", "--detach", op.Commit}
+	if op.Branch != "" {
+		args = []string{"checkout", "--quiet", "-B", op.Branch, op.Commit}
+	}
+	if err := git(op.Path, args...); err != nil {
+		return err
+	}
+	if op.Stash != "" {
+		return git(op.Path, "stash", "apply", "--quiet", op.Stash)
+	}
+	return nil
+}
+
+func git(dir string, args ...string) error {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		if output := strings.TrimSpace(string(out)); output != "" {
+			return fmt.Errorf("git %s: %w: %s", args[0], err, output)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+func short(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,218 @@
+// Package journal records the changes an apply run makes so they can be rolled back.
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const journalFile = "journal.json"
+
+// Run is the persisted record of one apply run: every step that changed the machine, in the
+// order the steps started applying, with the operations needed to undo them.
+type Run struct {
+	ID           string       `json:"id"`
+	ConfigPath   string       `json:"config_path"`
+	StartedAt    time.Time    `json:"started_at"`
+	FinishedAt   time.Time    `json:"finished_at,omitzero"`
+	RolledBackAt time.Time    `json:"rolled_back_at,omitzero"`
+	Steps        []StepRecord `json:"steps"`
+}
+
+// StepRecord lists the undo operations captured while a step applied.
+type StepRecord struct {
+	StepID string `json:"step_id"`
+	Type   string `json:"type"`
+	// Irreversible marks steps whose plugin cannot describe how to undo its changes.
+	Irreversible bool        `json:"irreversible,omitempty"`
+	Operations   []Operation `json:"operations,omitempty"`
+}
+
+// Journal records a run as it happens. It is safe for concurrent use by step workers and
+// rewrites the journal file after every change so an interrupted run can still be rolled back.
+type Journal struct {
+	mu      sync.Mutex
+	dir     string
+	run     Run
+	steps   map[string]int
+	backups int
+}
+
+// Create starts a journal for a new run in a fresh directory under root.
+func Create(root, configPath string) (*Journal, error) {
+	now := time.Now()
+	id := fmt.Sprintf("%s-%04x", now.Format("20060102-150405"), rand.IntN(0x10000))
+	dir := filepath.Join(root, id)
+	if err := os.MkdirAll(filepath.Join(dir, "backups"), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	j := &Journal{
+		dir:   dir,
+		run:   Run{ID: id, ConfigPath: configPath, StartedAt: now, Steps: []StepRecord{}},
+		steps: make(map[string]int),
+	}
+	if err := j.flush(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// ID returns the run identifier accepted by `streamy rollback`.
+func (j *Journal) ID() string {
+	return j.run.ID
+}
+
+// Step returns the recorder for a step that is about to apply. Calling it again for the same
+// step (for example on retry) appends to the existing record.
+func (j *Journal) Step(stepID, stepType string, irreversible bool) *StepJournal {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	idx, ok := j.steps[stepID]
+	if !ok {
+		idx = len(j.run.Steps)
+		j.steps[stepID] = idx
+		j.run.Steps = append(j.run.Steps, StepRecord{StepID: stepID, Type: stepType})
+	}
+	if irreversible {
+		j.run.Steps[idx].Irreversible = true
+	}
+	// A failed write here resurfaces on the next SavePath or on Close.
+	_ = j.flush()
+	return &StepJournal{journal: j, index: idx}
+}
+
+// Close marks the run finished. A run that changed nothing leaves no journal behind, in which
+// case kept is false.
+func (j *Journal) Close() (kept bool, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.run.Steps) == 0 {
+		return false, os.RemoveAll(j.dir)
+	}
+	j.run.FinishedAt = time.Now()
+	return true, j.flush()
+}
+
+func (j *Journal) reserveBackup() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.backups++
+	return filepath.Join("backups", strconv.Itoa(j.backups))
+}
+
+func (j *Journal) record(index int, op Operation) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.run.Steps[index].Operations = append(j.run.Steps[index].Operations, op)
+	return j.flush()
+}
+
+// flush must be called with j.mu held.
+func (j *Journal) flush() error {
+	return writeRun(j.dir, &j.run)
+}
+
+// StepJournal records undo operations for a single step. It implements plugin.Journal.
+type StepJournal struct {
+	journal *Journal
+	index   int
+}
+
+// SavePath captures the current state of path — file contents and mode, symlink target,
+// directory tree, or absence — so a rollback can put it back.
+func (s *StepJournal) SavePath(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	op, err := snapshot(s.journal.dir, abs, s.journal.reserveBackup)
+	if err != nil {
+		return fmt.Errorf("failed to journal %s: %w", abs, err)
+	}
+	return s.journal.record(s.index, op)
+}
+
+// SaveCheckout records the HEAD of the git checkout at dir, and the stash commit holding its
+// uncommitted changes, so a rollback can check them out again without a copy of the tree.
+func (s *StepJournal) SaveCheckout(dir, branch, commit, stash string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if commit == "" {
+		return fmt.Errorf("failed to journal %s: no commit", abs)
+	}
+	return s.journal.record(s.index, Operation{Kind: OpRestoreCheckout, Path: abs, Branch: branch, Commit: commit, Stash: stash})
+}
+
+// Load reads the run with the given ID from root.
+func Load(root, id string) (*Run, error) {
+	data, err := os.ReadFile(filepath.Join(root, id, journalFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("run %q not found in %s", id, root)
+		}
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse journal for run %q: %w", id, err)
+	}
+	return &run, nil
+}
+
+// Latest returns the most recent run recorded under root.
+func Latest(root string) (*Run, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, entry.Name(), journalFile)); err == nil {
+			ids = append(ids, entry.Name())
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no recorded runs in %s", root)
+	}
+
+	// Run IDs start with their timestamp, so lexical order is chronological.
+	sort.Strings(ids)
+	return Load(root, ids[len(ids)-1])
+}
+
+func writeRun(dir string, run *Run) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+
+	path := filepath.Join(dir, journalFile)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
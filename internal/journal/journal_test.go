@@ -0,0 +1,140 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback_RestoresPreviousState(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	work := t.TempDir()
+
+	file := filepath.Join(work, "config.txt")
+	require.NoError(t, os.WriteFile(file, []byte("original"), 0o640))
+	link := filepath.Join(work, "current")
+	require.NoError(t, os.Symlink("v1", link))
+	dir := filepath.Join(work, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "a.txt"), []byte("a"), 0o600))
+	created := filepath.Join(work, "new", "nested", "file.txt")
+
+	j, err := Create(root, "streamy.yaml")
+	require.NoError(t, err)
+
+	files := j.Step("files", "copy", false)
+	require.NoError(t, files.SavePath(file))
+	require.NoError(t, files.SavePath(created))
+	links := j.Step("links", "symlink", false)
+	require.NoError(t, links.SavePath(link))
+	require.NoError(t, j.Step("clone", "repo", false).SavePath(dir))
+	j.Step("script", "command", true)
+
+	// Simulate what the steps changed.
+	require.NoError(t, os.WriteFile(file, []byte("changed"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Dir(created), 0o755))
+	require.NoError(t, os.WriteFile(created, []byte("new"), 0o600))
+	require.NoError(t, os.Remove(link))
+	require.NoError(t, os.Symlink("v2", link))
+	require.NoError(t, os.RemoveAll(dir))
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+
+	kept, err := j.Close()
+	require.NoError(t, err)
+	require.True(t, kept)
+
+	run, err := Load(root, j.ID())
+	require.NoError(t, err)
+	require.Len(t, run.Steps, 4)
+	require.False(t, run.FinishedAt.IsZero())
+
+	preview, err := Rollback(root, run, true)
+	require.NoError(t, err)
+	require.Equal(t, []Action{
+		{StepID: "script", Description: "cannot undo command step; its changes are left in place", Irreversible: true},
+		{StepID: "clone", Description: "restore directory " + dir},
+		{StepID: "links", Description: "restore symlink " + link + " -> v1"},
+		{StepID: "files", Description: "remove " + filepath.Join(work, "new")},
+		{StepID: "files", Description: "restore file " + file},
+	}, preview)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "changed", string(data), "dry run must not touch the filesystem")
+
+	_, err = Rollback(root, run, false)
+	require.NoError(t, err)
+
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "original", string(data))
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	target, err := os.Readlink(link)
+	require.NoError(t, err)
+	require.Equal(t, "v1", target)
+
+	data, err = os.ReadFile(filepath.Join(dir, "sub", "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "a", string(data))
+
+	_, err = os.Lstat(filepath.Join(work, "new"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	reloaded, err := Load(root, run.ID)
+	require.NoError(t, err)
+	_, err = Rollback(root, reloaded, false)
+	require.ErrorContains(t, err, "already rolled back")
+}
+
+func TestJournal_CloseDiscardsEmptyRuns(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	j, err := Create(root, "streamy.yaml")
+	require.NoError(t, err)
+
+	kept, err := j.Close()
+	require.NoError(t, err)
+	require.False(t, kept)
+	_, err = os.Stat(filepath.Join(root, j.ID()))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestOperation_DescribeCheckout(t *testing.T) {
+	t.Parallel()
+
+	commit := "0123456789abcdef0123456789abcdef01234567"
+	stash := "fedcba9876543210fedcba9876543210fedcba98"
+	require.Equal(t, "restore checkout /src/tool to main at 0123456",
+		Operation{Kind: OpRestoreCheckout, Path: "/src/tool", Branch: "main", Commit: commit}.Describe())
+	require.Equal(t, "restore checkout /src/tool to detached at 0123456 and reapply local changes (fedcba9)",
+		Operation{Kind: OpRestoreCheckout, Path: "/src/tool", Commit: commit, Stash: stash}.Describe())
+}
+
+func TestLatest(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	_, err := Latest(root)
+	require.ErrorContains(t, err, "no recorded runs")
+
+	for _, id := range []string{"20240101-120000-0001", "20240301-090000-00ff", "20240201-000000-0abc"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, id), 0o700))
+		require.NoError(t, writeRun(filepath.Join(root, id), &Run{ID: id}))
+	}
+	// Directories without a journal are ignored.
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "20991231-000000-0000"), 0o700))
+
+	run, err := Latest(root)
+	require.NoError(t, err)
+	require.Equal(t, "20240301-090000-00ff", run.ID)
+
+	_, err = Load(root, "missing")
+	require.ErrorContains(t, err, `run "missing" not found`)
+}
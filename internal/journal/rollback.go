@@ -0,0 +1,69 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Action is one entry of a rollback, in the order it is (or would be) performed.
+type Action struct {
+	StepID      string
+	Description string
+	// Irreversible is set for steps whose changes cannot be undone; nothing is done for them.
+	Irreversible bool
+	Err          error
+}
+
+// Rollback undoes a run, newest step first and each step's operations in reverse, which
+// restores dependencies only after everything that relied on them. With dryRun the actions
+// are reported without touching the filesystem. Failed actions do not stop the rollback;
+// they are reported on the returned actions and summarised in the error.
+func Rollback(root string, run *Run, dryRun bool) ([]Action, error) {
+	if run == nil {
+		return nil, fmt.Errorf("run is nil")
+	}
+	if !run.RolledBackAt.IsZero() {
+		return nil, fmt.Errorf("run %s was already rolled back at %s", run.ID, run.RolledBackAt.Format(time.RFC3339))
+	}
+
+	runDir := filepath.Join(root, run.ID)
+	var actions []Action
+	var errs []error
+
+	for i := len(run.Steps) - 1; i >= 0; i-- {
+		step := run.Steps[i]
+		if step.Irreversible {
+			actions = append(actions, Action{
+				StepID:       step.StepID,
+				Description:  fmt.Sprintf("cannot undo %s step; its changes are left in place", step.Type),
+				Irreversible: true,
+			})
+		}
+		for j := len(step.Operations) - 1; j >= 0; j-- {
+			op := step.Operations[j]
+			action := Action{StepID: step.StepID, Description: op.Describe()}
+			if !dryRun {
+				if err := op.undo(runDir); err != nil {
+					action.Err = err
+					errs = append(errs, fmt.Errorf("step %s: %s: %w", step.StepID, action.Description, err))
+				}
+			}
+			actions = append(actions, action)
+		}
+	}
+
+	if dryRun {
+		return actions, nil
+	}
+	if len(errs) > 0 {
+		return actions, errors.Join(errs...)
+	}
+
+	run.RolledBackAt = time.Now()
+	if err := writeRun(runDir, run); err != nil {
+		return actions, err
+	}
+	return actions, nil
+}
@@ -0,0 +1,206 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// OperationKind identifies how an operation puts a path back.
+type OperationKind string
+
+const (
+	// OpRemove deletes a path that did not exist before the step ran.
+	OpRemove OperationKind = "remove"
+	// OpRestoreFile rewrites a regular file from its backup.
+	OpRestoreFile OperationKind = "restore_file"
+	// OpRestoreLink recreates a symlink with its previous target.
+	OpRestoreLink OperationKind = "restore_link"
+	// OpRestoreDir replaces a directory with its backed-up tree.
+	OpRestoreDir OperationKind = "restore_dir"
+	// OpRestoreCheckout moves a git checkout back to its previous branch and commit.
+	OpRestoreCheckout OperationKind = "restore_checkout"
+)
+
+// Operation is a single reversible change: the state Path must be returned to.
+type Operation struct {
+	Kind OperationKind `json:"kind"`
+	Path string        `json:"path"`
+	// Backup is the copy of the previous file or tree, relative to the run directory.
+	Backup     string      `json:"backup,omitempty"`
+	Mode       fs.FileMode `json:"mode,omitempty"`
+	LinkTarget string      `json:"link_target,omitempty"`
+	// Branch, Commit and Stash describe a git checkout's previous HEAD and uncommitted changes.
+	Branch string `json:"branch,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	Stash  string `json:"stash,omitempty"`
+}
+
+// Describe renders the operation for rollback previews.
+func (op Operation) Describe() string {
+	switch op.Kind {
+	case OpRemove:
+		return fmt.Sprintf("remove %s", op.Path)
+	case OpRestoreFile:
+		return fmt.Sprintf("restore file %s", op.Path)
+	case OpRestoreLink:
+		return fmt.Sprintf("restore symlink %s -> %s", op.Path, op.LinkTarget)
+	case OpRestoreDir:
+		return fmt.Sprintf("restore directory %s", op.Path)
+	case OpRestoreCheckout:
+		return describeCheckout(op)
+	default:
+		return fmt.Sprintf("%s %s", op.Kind, op.Path)
+	}
+}
+
+// snapshot records how to restore path to its current state. Backups are written to the
+// location returned by reserve, relative to runDir.
+func snapshot(runDir, path string, reserve func() string) (Operation, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// The step will create path and any missing parents; undo removes the outermost one.
+		created := path
+		for {
+			parent := filepath.Dir(created)
+			if parent == created {
+				break
+			}
+			if _, err := os.Lstat(parent); !errors.Is(err, os.ErrNotExist) {
+				break
+			}
+			created = parent
+		}
+		return Operation{Kind: OpRemove, Path: created}, nil
+	}
+	if err != nil {
+		return Operation{}, err
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return Operation{}, err
+		}
+		return Operation{Kind: OpRestoreLink, Path: path, LinkTarget: target}, nil
+	case info.Mode().IsRegular():
+		backup := reserve()
+		if err := copyFile(path, filepath.Join(runDir, backup), info.Mode().Perm()); err != nil {
+			return Operation{}, err
+		}
+		return Operation{Kind: OpRestoreFile, Path: path, Backup: backup, Mode: info.Mode().Perm()}, nil
+	case info.IsDir():
+		backup := reserve()
+		if err := copyTree(path, filepath.Join(runDir, backup)); err != nil {
+			return Operation{}, err
+		}
+		return Operation{Kind: OpRestoreDir, Path: path, Backup: backup, Mode: info.Mode().Perm()}, nil
+	default:
+		return Operation{}, fmt.Errorf("unsupported file type %s", info.Mode().Type())
+	}
+}
+
+// undo returns op.Path to its recorded state.
+func (op Operation) undo(runDir string) error {
+	if op.Kind == OpRestoreCheckout {
+		return restoreCheckout(op)
+	}
+	if err := os.RemoveAll(op.Path); err != nil {
+		return err
+	}
+
+	switch op.Kind {
+	case OpRemove:
+		return nil
+	case OpRestoreFile:
+		return copyFile(filepath.Join(runDir, op.Backup), op.Path, op.Mode)
+	case OpRestoreLink:
+		if err := os.MkdirAll(filepath.Dir(op.Path), 0o755); err != nil {
+			return err
+		}
+		return os.Symlink(op.LinkTarget, op.Path)
+	case OpRestoreDir:
+		return copyTree(filepath.Join(runDir, op.Backup), op.Path)
+	default:
+		return fmt.Errorf("unknown operation %q", op.Kind)
+	}
+}
+
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode)
+}
+
+// copyTree copies a directory tree, preserving modes and symlinks.
+func copyTree(src, dst string) error {
+	type dirMode struct {
+		path string
+		mode fs.FileMode
+	}
+	// Directory modes are applied last so read-only directories can still be filled.
+	var dirs []dirMode
+
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			dirs = append(dirs, dirMode{path: target, mode: info.Mode().Perm()})
+			return os.MkdirAll(target, 0o755)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return fmt.Errorf("unsupported file type %s at %s", info.Mode().Type(), path)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
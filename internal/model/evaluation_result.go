@@ -26,4 +26,8 @@ type EvaluationResult struct {
 	// InternalData is opaque data passed from Evaluate() to Apply()
 	// Used to avoid recomputation and pass domain-specific data
 	InternalData any
+
+	// Outputs are values already known from evaluation (for example the current
+	// commit of a repository) that are registered when Apply is not needed
+	Outputs map[string]any
 }
@@ -75,6 +75,7 @@ func TestVerificationStatus_IsValid(t *testing.T) {
 		{"drifted is valid", StatusDrifted, true},
 		{"blocked is valid", StatusBlocked, true},
 		{"unknown is valid", StatusUnknown, true},
+		{"skipped is valid", StatusVerificationSkipped, true},
 		{"invalid status", VerificationStatus("invalid"), false},
 		{"empty status", VerificationStatus(""), false},
 	}
@@ -124,6 +125,17 @@ func TestVerificationSummary_AllSatisfied(t *testing.T) {
 		require.False(t, summary.AllSatisfied())
 	})
 
+	t.Run("counts steps skipped by condition as satisfied", func(t *testing.T) {
+		t.Parallel()
+		summary := &VerificationSummary{
+			TotalSteps: 5,
+			Satisfied:  3,
+			Skipped:    2,
+		}
+		require.True(t, summary.AllSatisfied())
+		require.Equal(t, 0, summary.ExitCode())
+	})
+
 	t.Run("returns true for zero steps", func(t *testing.T) {
 		t.Parallel()
 		summary := &VerificationSummary{
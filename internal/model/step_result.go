@@ -15,6 +15,8 @@ const (
 	StatusSkipped = "skipped"
 	// StatusFailed marks a failure during step execution.
 	StatusFailed = "failed"
+	// StatusFailedIgnored marks a failure of a step declared continue_on_error; the run carries on.
+	StatusFailedIgnored = "failed_ignored"
 	// StatusWouldCreate indicates dry-run would create a resource.
 	StatusWouldCreate = "would_create"
 	// StatusWouldUpdate indicates dry-run would update a resource.
@@ -23,7 +25,21 @@ const (
 
 // StepResult captures the outcome of executing a single step.
 type StepResult struct {
-	StepID    string
+	StepID     string
+	Status     string
+	Message    string
+	SkipReason string         // Populated when a step's condition (or a dependency's) prevented it from running
+	Outputs    map[string]any // Plugin-specific values later steps can read when the step declares register
+	Attempts   []Attempt      // Every try at the step, in order; more than one when retries were needed
+	Carried    bool           // Reported from an earlier run (ApplyRequest.Carry) without running again; notifies no handlers
+	Error      error
+	Duration   time.Duration
+	Timestamp  time.Time
+}
+
+// Attempt records a single try at evaluating and applying a step.
+type Attempt struct {
+	Number    int
 	Status    string
 	Message   string
 	Error     error
@@ -31,6 +47,14 @@ type StepResult struct {
 	Timestamp time.Time
 }
 
+// AttemptCount returns how many times the step was tried, treating results without history as one attempt.
+func (r StepResult) AttemptCount() int {
+	if len(r.Attempts) == 0 {
+		return 1
+	}
+	return len(r.Attempts)
+}
+
 // VerificationStatus represents the state match level for a single step verification.
 type VerificationStatus string
 
@@ -45,12 +69,14 @@ const (
 	StatusBlocked VerificationStatus = "blocked"
 	// StatusUnknown indicates verification status cannot be determined
 	StatusUnknown VerificationStatus = "unknown"
+	// StatusVerificationSkipped indicates the step was not verified because its condition was false
+	StatusVerificationSkipped VerificationStatus = "skipped"
 )
 
 // IsValid checks if the verification status is one of the defined values.
 func (s VerificationStatus) IsValid() bool {
 	switch s {
-	case StatusSatisfied, StatusMissing, StatusDrifted, StatusBlocked, StatusUnknown:
+	case StatusSatisfied, StatusMissing, StatusDrifted, StatusBlocked, StatusUnknown, StatusVerificationSkipped:
 		return true
 	default:
 		return false
@@ -59,13 +85,14 @@ func (s VerificationStatus) IsValid() bool {
 
 // VerificationResult contains the outcome of verifying a single step.
 type VerificationResult struct {
-	StepID    string
-	Status    VerificationStatus
-	Message   string
-	Details   string // Unified diff for drifted status
-	Error     error  // Populated for blocked status
-	Duration  time.Duration
-	Timestamp time.Time
+	StepID     string
+	Status     VerificationStatus
+	Message    string
+	Details    string // Unified diff for drifted status
+	SkipReason string // Populated for skipped status
+	Error      error  // Populated for blocked status
+	Duration   time.Duration
+	Timestamp  time.Time
 }
 
 // VerificationSummary aggregates verification results across all steps.
@@ -76,13 +103,14 @@ type VerificationSummary struct {
 	Drifted    int
 	Blocked    int
 	Unknown    int
+	Skipped    int
 	Results    []*VerificationResult
 	Duration   time.Duration
 }
 
-// AllSatisfied returns true if all steps are satisfied.
+// AllSatisfied returns true if all steps are satisfied. Steps skipped by their condition count as satisfied.
 func (s *VerificationSummary) AllSatisfied() bool {
-	return s.Satisfied == s.TotalSteps
+	return s.Satisfied+s.Skipped == s.TotalSteps
 }
 
 // NeedsApply returns true if any steps need to be applied (not satisfied).
@@ -0,0 +1,131 @@
+// Package planfile reads and writes the saved plans produced by `streamy plan` and checks that
+// a saved plan still describes the machine before `streamy apply --plan` executes it.
+package planfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+)
+
+// FormatVersion is the plan file layout written by this build.
+const FormatVersion = 1
+
+// Plan is the evaluated state of every step of a pipeline at the time it was planned.
+type Plan struct {
+	FormatVersion int       `json:"format_version"`
+	ConfigPath    string    `json:"config_path"`
+	ConfigHash    string    `json:"config_hash"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Vars are the --var overrides the plan was made with; apply reuses them.
+	Vars  map[string]any `json:"vars,omitempty"`
+	Steps []Step         `json:"steps"`
+}
+
+// Step is one step of a plan, in execution order.
+type Step struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Status is the dry-run outcome: would_update, would_create, or skipped.
+	Status         string                   `json:"status"`
+	CurrentState   model.VerificationStatus `json:"current_state,omitempty"`
+	RequiresAction bool                     `json:"requires_action"`
+	Message        string                   `json:"message,omitempty"`
+	Diff           string                   `json:"diff,omitempty"`
+	SkipReason     string                   `json:"skip_reason,omitempty"`
+}
+
+// Changes counts the steps the plan would act on.
+func (p *Plan) Changes() int {
+	count := 0
+	for _, step := range p.Steps {
+		if step.RequiresAction {
+			count++
+		}
+	}
+	return count
+}
+
+// Write saves a plan as indented JSON.
+func Write(path string, plan *Plan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// Read loads a plan written by Write.
+func Read(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	if plan.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("plan %s has format version %d; this build reads version %d", path, plan.FormatVersion, FormatVersion)
+	}
+	return &plan, nil
+}
+
+// ErrStale is returned by Compare when a saved plan no longer matches the config or the machine.
+var ErrStale = errors.New("plan is stale")
+
+// Compare checks that current, freshly evaluated, matches the saved plan: the same config and,
+// for every step, the same evaluated state and diff. The error lists every difference.
+func Compare(saved, current *Plan) error {
+	if saved.ConfigHash != current.ConfigHash {
+		return fmt.Errorf("%w: the configuration changed since the plan was created; run 'streamy plan' again", ErrStale)
+	}
+
+	planned := make(map[string]Step, len(saved.Steps))
+	for _, step := range saved.Steps {
+		planned[step.ID] = step
+	}
+
+	var drift []string
+	for _, now := range current.Steps {
+		was, ok := planned[now.ID]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("%s: not in the plan", now.ID))
+			continue
+		}
+		delete(planned, now.ID)
+		if was.Status != now.Status || was.CurrentState != now.CurrentState || was.RequiresAction != now.RequiresAction || was.Diff != now.Diff {
+			if describe(was) == describe(now) {
+				drift = append(drift, fmt.Sprintf("%s: diff changed", now.ID))
+			} else {
+				drift = append(drift, fmt.Sprintf("%s: planned %s, now %s", now.ID, describe(was), describe(now)))
+			}
+		}
+	}
+	for _, step := range saved.Steps {
+		if _, missing := planned[step.ID]; missing {
+			drift = append(drift, fmt.Sprintf("%s: planned but no longer evaluated", step.ID))
+		}
+	}
+
+	if len(drift) > 0 {
+		return fmt.Errorf("%w: the evaluated state changed since the plan was created:\n  %s", ErrStale, strings.Join(drift, "\n  "))
+	}
+	return nil
+}
+
+func describe(step Step) string {
+	if step.CurrentState == "" {
+		return step.Status
+	}
+	return fmt.Sprintf("%s (%s)", step.Status, step.CurrentState)
+}
@@ -0,0 +1,96 @@
+package planfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+)
+
+func samplePlan() *Plan {
+	return &Plan{
+		FormatVersion: FormatVersion,
+		ConfigPath:    "/configs/streamy.yaml",
+		ConfigHash:    "abc123",
+		CreatedAt:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Vars:          map[string]any{"env": "prod"},
+		Steps: []Step{
+			{ID: "dotfiles", Type: "copy", Status: model.StatusWouldUpdate, CurrentState: model.StatusDrifted, RequiresAction: true, Diff: "-old\n+new"},
+			{ID: "git", Type: "package", Status: model.StatusSkipped, CurrentState: model.StatusSatisfied},
+		},
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	plan := samplePlan()
+	require.NoError(t, Write(path, plan))
+
+	loaded, err := Read(path)
+	require.NoError(t, err)
+	require.Equal(t, plan, loaded)
+	require.Equal(t, 1, loaded.Changes())
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"format_version": 99}`), 0o600))
+	_, err = Read(path)
+	require.ErrorContains(t, err, "format version 99")
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mutate  func(*Plan)
+		wantErr string
+	}{
+		{name: "identical", mutate: func(*Plan) {}},
+		{name: "timestamps are ignored", mutate: func(p *Plan) { p.CreatedAt = time.Now() }},
+		{
+			name:    "config changed",
+			mutate:  func(p *Plan) { p.ConfigHash = "def456" },
+			wantErr: "configuration changed",
+		},
+		{
+			name: "step state changed",
+			mutate: func(p *Plan) {
+				p.Steps[1].Status = model.StatusWouldUpdate
+				p.Steps[1].CurrentState = model.StatusMissing
+				p.Steps[1].RequiresAction = true
+			},
+			wantErr: "git: planned skipped (satisfied), now would_update (missing)",
+		},
+		{
+			name:    "diff changed",
+			mutate:  func(p *Plan) { p.Steps[0].Diff = "-old\n+newer" },
+			wantErr: "dotfiles: diff changed",
+		},
+		{
+			name:    "step no longer evaluated",
+			mutate:  func(p *Plan) { p.Steps = p.Steps[:1] },
+			wantErr: "git: planned but no longer evaluated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			current := samplePlan()
+			tt.mutate(current)
+			err := Compare(samplePlan(), current)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrStale)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package plugin
+
+import "context"
+
+// Journal records the state of paths a step is about to change so `streamy rollback` can
+// restore them. Plugins call SavePath before modifying, replacing or creating a path, and
+// SaveCheckout before moving the HEAD of an existing git checkout.
+type Journal interface {
+	SavePath(path string) error
+	// SaveCheckout records the branch (empty when HEAD is detached) and commit dir is at, and
+	// stash, a `git stash create` commit holding its uncommitted changes, if there are any.
+	SaveCheckout(dir, branch, commit, stash string) error
+}
+
+type journalKey struct{}
+
+type noopJournal struct{}
+
+func (noopJournal) SavePath(string) error { return nil }
+
+func (noopJournal) SaveCheckout(string, string, string, string) error { return nil }
+
+// ContextWithJournal attaches the journal for the step being applied.
+func ContextWithJournal(ctx context.Context, journal Journal) context.Context {
+	return context.WithValue(ctx, journalKey{}, journal)
+}
+
+// JournalFromContext returns the step's journal, or one that records nothing when the run is
+// not journaled (dry runs, tests).
+func JournalFromContext(ctx context.Context) Journal {
+	if ctx != nil {
+		if journal, ok := ctx.Value(journalKey{}).(Journal); ok && journal != nil {
+			return journal
+		}
+	}
+	return noopJournal{}
+}
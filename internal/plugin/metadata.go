@@ -20,6 +20,14 @@ type PluginMetadata struct {
 	Dependencies []Dependency
 	Stateful     bool
 	Description  string
+	// MaxConcurrency caps how many steps of this plugin the engine evaluates at once; 0 means no limit.
+	MaxConcurrency int
+	// Resources names resources every step of this plugin holds while it is applied, such as the
+	// package database; the engine never applies two steps holding the same resource at once.
+	Resources []string
+	// Irreversible marks plugins that cannot journal their changes, so `streamy rollback` reports
+	// their steps instead of undoing them.
+	Irreversible bool
 }
 
 // Dependency captures a dependency on another plugin.
@@ -46,6 +54,16 @@ func (m PluginMetadata) Validate() error {
 		return fmt.Errorf("plugin '%s' has invalid APIVersion '%s' (expected format: N.x)", m.Name, m.APIVersion)
 	}
 
+	if m.MaxConcurrency < 0 {
+		return fmt.Errorf("plugin '%s' has negative MaxConcurrency %d", m.Name, m.MaxConcurrency)
+	}
+
+	for _, resource := range m.Resources {
+		if strings.TrimSpace(resource) == "" {
+			return fmt.Errorf("plugin '%s' declares a resource with an empty name", m.Name)
+		}
+	}
+
 	seenDeps := map[string]struct{}{}
 	for _, dep := range m.Dependencies {
 		if err := dep.Validate(m.Name); err != nil {
@@ -0,0 +1,20 @@
+package plugin
+
+import "context"
+
+// OutputSink receives lines a plugin's subprocesses write while a step runs.
+// stream is "stdout" or "stderr".
+type OutputSink func(stream, line string)
+
+type outputSinkKey struct{}
+
+// ContextWithOutput attaches sink to ctx so plugins can stream step output to it.
+func ContextWithOutput(ctx context.Context, sink OutputSink) context.Context {
+	return context.WithValue(ctx, outputSinkKey{}, sink)
+}
+
+// OutputFromContext returns the sink attached to ctx, or nil when output is not being streamed.
+func OutputFromContext(ctx context.Context) OutputSink {
+	sink, _ := ctx.Value(outputSinkKey{}).(OutputSink)
+	return sink
+}
@@ -38,6 +38,8 @@ func (p *commandPlugin) PluginMetadata() plugin.PluginMetadata {
 		Dependencies: []plugin.Dependency{},
 		Stateful:     false,
 		Description:  "Executes shell commands with environment and working directory control.",
+		// Arbitrary commands have no recorded inverse.
+		Irreversible: true,
 	}
 }
 
@@ -213,7 +215,8 @@ func (p *commandPlugin) Apply(ctx context.Context, evalResult *model.EvaluationR
 		cmd.Dir = cfg.WorkDir
 	}
 
-	streamResult, err := internalexec.RunStreaming(cmd)
+	streamResult, err := internalexec.RunStreamingContext(ctx, cmd)
+	outputs := internalexec.Outputs(streamResult, err)
 	if err != nil {
 		combinedOutput := internalexec.PrimaryOutput(streamResult)
 		if combinedOutput != "" {
@@ -224,6 +227,7 @@ func (p *commandPlugin) Apply(ctx context.Context, evalResult *model.EvaluationR
 			StepID:  step.ID,
 			Status:  model.StatusFailed,
 			Message: fmt.Sprintf("command failed: %v", err),
+			Outputs: outputs,
 			Error:   err,
 		}, plugin.NewExecutionError(step.ID, fmt.Errorf("command failed: %w", err))
 	}
@@ -232,6 +236,7 @@ func (p *commandPlugin) Apply(ctx context.Context, evalResult *model.EvaluationR
 		StepID:  step.ID,
 		Status:  model.StatusSuccess,
 		Message: fmt.Sprintf("executed: %s", cfg.Command),
+		Outputs: outputs,
 	}, nil
 }
 
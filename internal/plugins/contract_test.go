@@ -16,6 +16,7 @@ import (
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
 	commandplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/command"
 	copyplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/copy"
+	langpackageplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/langpackage"
 	lineinfileplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/lineinfile"
 	packageplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/package"
 	repoplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/repo"
@@ -39,6 +40,7 @@ func getAllPlugins() []plugin.Plugin {
 		commandplugin.New(),
 		repoplugin.New(),
 		lineinfileplugin.New(),
+		langpackageplugin.New(),
 		copyplugin.New(),
 	}
 }
@@ -278,6 +280,12 @@ func createTestStep(t *testing.T, pluginType, tmpDir, testFile string) *config.S
 			Packages: []string{"bash"},
 			Manager:  "apt",
 		})
+	case "lang_package":
+		// npm lists itself among the global packages, so Apply is not invoked.
+		return newStepWithConfig(t, "test-lang-package", pluginType, config.LangPackageStep{
+			Manager:  "npm",
+			Packages: []string{"npm"},
+		})
 	case "repo":
 		source := initContractRepo(t)
 		return newStepWithConfig(t, "test-repo", pluginType, config.RepoStep{
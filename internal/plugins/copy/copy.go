@@ -256,6 +256,16 @@ func (p *copyPlugin) Apply(ctx context.Context, evalResult *model.EvaluationResu
 		}
 	}
 
+	// Record the destination's prior state so the copy can be rolled back
+	if err := plugin.JournalFromContext(ctx).SavePath(cfg.Destination); err != nil {
+		return &model.StepResult{
+			StepID:  step.ID,
+			Status:  model.StatusFailed,
+			Message: fmt.Sprintf("failed to journal %s: %v", cfg.Destination, err),
+			Error:   err,
+		}, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to journal destination: %w", err))
+	}
+
 	// Perform the copy operation
 	if data.IsDirectory {
 		if !data.NeedsRecursive {
@@ -2,10 +2,15 @@ package internalexec
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
 )
 
 // Result captures stdout/stderr emitted by a streaming command run.
@@ -17,20 +22,40 @@ type Result struct {
 // RunStreaming wires the command's stdout/stderr through to the parent process
 // while collecting the output for later inspection.
 func RunStreaming(cmd *exec.Cmd) (Result, error) {
+	return RunStreamingContext(context.Background(), cmd)
+}
+
+// RunStreamingContext behaves like RunStreaming, except that when ctx carries a
+// plugin.OutputSink the command's output is delivered to it line by line instead
+// of being copied to the parent process.
+func RunStreamingContext(ctx context.Context, cmd *exec.Cmd) (Result, error) {
 	var stdoutBuf, stderrBuf bytes.Buffer
 
+	stdout, stderr := io.Writer(os.Stdout), io.Writer(os.Stderr)
+	var lines []*lineWriter
+	if sink := plugin.OutputFromContext(ctx); sink != nil {
+		var mu sync.Mutex
+		out := &lineWriter{stream: "stdout", sink: sink, mu: &mu}
+		errOut := &lineWriter{stream: "stderr", sink: sink, mu: &mu}
+		stdout, stderr = out, errOut
+		lines = append(lines, out, errOut)
+	}
+
 	if cmd.Stdout != nil {
 		cmd.Stdout = io.MultiWriter(cmd.Stdout, &stdoutBuf)
 	} else {
-		cmd.Stdout = io.MultiWriter(os.Stdout, &stdoutBuf)
+		cmd.Stdout = io.MultiWriter(stdout, &stdoutBuf)
 	}
 	if cmd.Stderr != nil {
 		cmd.Stderr = io.MultiWriter(cmd.Stderr, &stderrBuf)
 	} else {
-		cmd.Stderr = io.MultiWriter(os.Stderr, &stderrBuf)
+		cmd.Stderr = io.MultiWriter(stderr, &stderrBuf)
 	}
 
 	err := cmd.Run()
+	for _, w := range lines {
+		w.Flush()
+	}
 
 	return Result{
 		Stdout: strings.TrimSpace(stdoutBuf.String()),
@@ -38,6 +63,42 @@ func RunStreaming(cmd *exec.Cmd) (Result, error) {
 	}, err
 }
 
+// lineWriter splits a byte stream into lines for an OutputSink. Writers for the
+// same command share mu so the sink never sees two lines at once.
+type lineWriter struct {
+	stream  string
+	sink    plugin.OutputSink
+	mu      *sync.Mutex
+	pending []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, p...)
+	for {
+		idx := bytes.IndexByte(w.pending, '\n')
+		if idx < 0 {
+			break
+		}
+		w.sink(w.stream, strings.TrimRight(string(w.pending[:idx]), "\r"))
+		w.pending = w.pending[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Flush delivers a trailing line that was not newline-terminated.
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) > 0 {
+		w.sink(w.stream, strings.TrimRight(string(w.pending), "\r"))
+		w.pending = nil
+	}
+}
+
 // PrimaryOutput returns stderr if present, otherwise stdout.
 func PrimaryOutput(res Result) string {
 	if res.Stderr != "" {
@@ -45,3 +106,25 @@ func PrimaryOutput(res Result) string {
 	}
 	return res.Stdout
 }
+
+// ExitCode reports the exit status carried by err: 0 on success, the process
+// exit code when the command ran, and -1 when it could not be started.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// Outputs exposes a command's collected output and exit code for `register`.
+func Outputs(res Result, err error) map[string]any {
+	return map[string]any{
+		"stdout":    res.Stdout,
+		"stderr":    res.Stderr,
+		"exit_code": ExitCode(err),
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
 )
 
 func TestRunStreaming_Success(t *testing.T) {
@@ -214,3 +216,38 @@ func TestRunStreaming_NoOutput(t *testing.T) {
 	assert.Equal(t, "", result.Stdout)
 	assert.Equal(t, "", result.Stderr)
 }
+
+func TestOutputs_ExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell assumptions do not hold on Windows")
+	}
+
+	result, err := RunStreaming(exec.Command("sh", "-c", "echo partial; exit 3"))
+	require.Error(t, err)
+	assert.Equal(t, map[string]any{"stdout": "partial", "stderr": "", "exit_code": 3}, Outputs(result, err))
+
+	result, err = RunStreaming(exec.Command("echo", "ok"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, Outputs(result, err)["exit_code"])
+
+	_, err = RunStreaming(exec.Command("definitely-not-a-real-command-12345"))
+	assert.Equal(t, -1, ExitCode(err))
+}
+
+func TestRunStreamingContext_OutputSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell assumptions do not hold on Windows")
+	}
+
+	var lines []string
+	ctx := plugin.ContextWithOutput(context.Background(), func(stream, line string) {
+		lines = append(lines, stream+": "+line)
+	})
+
+	cmd := exec.Command("sh", "-c", "echo first; echo oops >&2; printf last")
+	result, err := RunStreamingContext(ctx, cmd)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nlast", result.Stdout)
+	assert.Equal(t, "oops", result.Stderr)
+	assert.ElementsMatch(t, []string{"stdout: first", "stderr: oops", "stdout: last"}, lines)
+}
@@ -0,0 +1,650 @@
+package langpackageplugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/plugins/internalexec"
+)
+
+// ecosystem drives one language package manager. Queries run silently through runCommand;
+// installs and upgrades stream their output like any other step command.
+type ecosystem struct {
+	name string
+	// binary must be on PATH for the ecosystem to be used.
+	binary string
+	// installed returns the installed version of each of names that is installed.
+	installed func(ctx context.Context, cfg *config.LangPackageStep, names []string) (map[string]string, error)
+	// newer returns the newest published version of each installed package that is outdated,
+	// given the installed versions.
+	newer func(ctx context.Context, cfg *config.LangPackageStep, installed map[string]string) (map[string]string, error)
+	// install installs name at version, or the newest version when version is empty. reinstall
+	// is set when another version is installed already.
+	install func(ctx context.Context, cfg *config.LangPackageStep, name, version string, reinstall bool) error
+	// upgrade upgrades name to its newest version.
+	upgrade func(ctx context.Context, cfg *config.LangPackageStep, name string) error
+}
+
+var ecosystems = map[string]*ecosystem{
+	"pipx":  {name: "pipx", binary: "pipx", installed: pipxInstalled, newer: pipxNewer, install: pipxInstall, upgrade: pipxUpgrade},
+	"pip":   {name: "pip", binary: "python3", installed: pipInstalled, newer: pipNewer, install: pipInstall, upgrade: pipUpgrade},
+	"npm":   {name: "npm", binary: "npm", installed: npmInstalled, newer: npmNewer, install: npmInstall, upgrade: npmUpgrade},
+	"cargo": {name: "cargo", binary: "cargo", installed: cargoInstalled, newer: cargoNewer, install: cargoInstall, upgrade: cargoUpgrade},
+	"go":    {name: "go", binary: "go", installed: goInstalled, newer: goNewer, install: goInstall, upgrade: goUpgrade},
+	"gem":   {name: "gem", binary: "gem", installed: gemInstalled, newer: gemNewer, install: gemInstall, upgrade: gemUpgrade},
+}
+
+// pipEnv keeps pip from checking for a newer pip on every query.
+var pipEnv = []string{"PIP_DISABLE_PIP_VERSION_CHECK=1"}
+
+// runCommand runs a read-only query and returns its stdout; tests replace it to fake the
+// packages a host has installed.
+var runCommand = func(ctx context.Context, env []string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return string(out), err
+}
+
+// queryOutput runs a query whose exit status also signals findings, as `npm outdated` does, and
+// only fails when the command could not run or printed nothing.
+func queryOutput(ctx context.Context, env []string, name string, args ...string) (string, error) {
+	out, err := runCommand(ctx, env, name, args...)
+	var exitErr *exec.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || strings.TrimSpace(out) == "") {
+		return "", fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return out, nil
+}
+
+// run executes a command changing the system.
+func run(ctx context.Context, env []string, argv ...string) error {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+
+	streamResult, err := internalexec.RunStreamingContext(ctx, cmd)
+	if err != nil {
+		if output := internalexec.PrimaryOutput(streamResult); output != "" {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return err
+	}
+	return nil
+}
+
+// versionMatches reports whether one of the installed versions, separated by ", ", satisfies a
+// pinned one: exactly, or as a prefix when the pin ends in "*". A leading "v" is ignored.
+func versionMatches(installed, want string) bool {
+	want = strings.TrimPrefix(want, "v")
+	for _, version := range strings.Split(installed, ", ") {
+		version = strings.TrimPrefix(version, "v")
+		if prefix, ok := strings.CutSuffix(want, "*"); ok {
+			if strings.HasPrefix(version, prefix) {
+				return true
+			}
+		} else if version == want {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcard returns the prefix of a pin such as "8.5*" without the "*" and any trailing dot.
+func wildcard(version string) (string, bool) {
+	prefix, ok := strings.CutSuffix(version, "*")
+	return strings.TrimSuffix(prefix, "."), ok
+}
+
+// lines returns the non-blank lines of out, trimmed.
+func lines(out string) []string {
+	var all []string
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			all = append(all, line)
+		}
+	}
+	return all
+}
+
+// --- pip and pipx ---
+
+var pipSeparators = regexp.MustCompile(`[-_.]+`)
+
+// pipName normalises a Python package name so "PyYAML" and "pyyaml" compare equal.
+func pipName(name string) string {
+	return pipSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// pipSpec formats a requirement: "black==24.1.0", or "black==24.1.*" for a wildcard pin.
+func pipSpec(name, version string) string {
+	if version == "" {
+		return name
+	}
+	if prefix, ok := wildcard(version); ok {
+		return name + "==" + prefix + ".*"
+	}
+	return name + "==" + version
+}
+
+type pipPackage struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	LatestVersion string `json:"latest_version"`
+}
+
+// parsePipList reads `pip list --format=json`, keyed by the requested names.
+func parsePipList(out string, names []string, latest bool) (map[string]string, error) {
+	var listed []pipPackage
+	if err := json.Unmarshal([]byte(out), &listed); err != nil {
+		return nil, fmt.Errorf("parse pip list output: %w", err)
+	}
+	byName := make(map[string]pipPackage, len(listed))
+	for _, pkg := range listed {
+		byName[pipName(pkg.Name)] = pkg
+	}
+	found := make(map[string]string)
+	for _, name := range names {
+		if pkg, ok := byName[pipName(name)]; ok {
+			found[name] = pkg.Version
+			if latest {
+				found[name] = pkg.LatestVersion
+			}
+		}
+	}
+	return found, nil
+}
+
+// pipPython is the interpreter of the step's virtual environment, or python3 for user installs.
+func pipPython(cfg *config.LangPackageStep) string {
+	if cfg.Venv != "" {
+		return filepath.Join(cfg.Venv, "bin", "python")
+	}
+	return "python3"
+}
+
+// pipArgs builds a pip command line, limited to the user's site-packages outside a venv.
+func pipArgs(cfg *config.LangPackageStep, args ...string) []string {
+	argv := append([]string{pipPython(cfg), "-m", "pip"}, args...)
+	if cfg.Venv == "" {
+		argv = append(argv, "--user")
+	}
+	return argv
+}
+
+func pipList(ctx context.Context, cfg *config.LangPackageStep, names []string, outdated bool) (map[string]string, error) {
+	if cfg.Venv != "" {
+		if _, err := os.Stat(pipPython(cfg)); errors.Is(err, os.ErrNotExist) {
+			// The venv is created on the first install; nothing is installed in it yet.
+			return map[string]string{}, nil
+		}
+	}
+	args := []string{"list", "--format=json"}
+	if outdated {
+		args = append(args, "--outdated")
+	}
+	argv := pipArgs(cfg, args...)
+	out, err := runCommand(ctx, pipEnv, argv[0], argv[1:]...)
+	if err != nil {
+		return nil, fmt.Errorf("pip list: %w", err)
+	}
+	return parsePipList(out, names, outdated)
+}
+
+func pipInstalled(ctx context.Context, cfg *config.LangPackageStep, names []string) (map[string]string, error) {
+	return pipList(ctx, cfg, names, false)
+}
+
+func pipNewer(ctx context.Context, cfg *config.LangPackageStep, installed map[string]string) (map[string]string, error) {
+	return pipList(ctx, cfg, sortedKeys(installed), true)
+}
+
+func pipInstall(ctx context.Context, cfg *config.LangPackageStep, name, version string, _ bool) error {
+	if cfg.Venv != "" {
+		if _, err := os.Stat(pipPython(cfg)); errors.Is(err, os.ErrNotExist) {
+			if err := run(ctx, nil, "python3", "-m", "venv", cfg.Venv); err != nil {
+				return fmt.Errorf("create venv %s: %w", cfg.Venv, err)
+			}
+		}
+	}
+	return run(ctx, pipEnv, pipArgs(cfg, "install", pipSpec(name, version))...)
+}
+
+func pipUpgrade(ctx context.Context, cfg *config.LangPackageStep, name string) error {
+	return run(ctx, pipEnv, pipArgs(cfg, "install", "--upgrade", name)...)
+}
+
+// parsePipxList reads `pipx list --json`, keyed by the requested names.
+func parsePipxList(out string, names []string) (map[string]string, error) {
+	var listed struct {
+		Venvs map[string]struct {
+			Metadata struct {
+				MainPackage struct {
+					Package        string `json:"package"`
+					PackageVersion string `json:"package_version"`
+				} `json:"main_package"`
+			} `json:"metadata"`
+		} `json:"venvs"`
+	}
+	if err := json.Unmarshal([]byte(out), &listed); err != nil {
+		return nil, fmt.Errorf("parse pipx list output: %w", err)
+	}
+	byName := make(map[string]string, len(listed.Venvs))
+	for venv, info := range listed.Venvs {
+		pkg := info.Metadata.MainPackage
+		if pkg.Package == "" {
+			pkg.Package = venv
+		}
+		byName[pipName(pkg.Package)] = pkg.PackageVersion
+	}
+	found := make(map[string]string)
+	for _, name := range names {
+		if version, ok := byName[pipName(name)]; ok {
+			found[name] = version
+		}
+	}
+	return found, nil
+}
+
+func pipxInstalled(ctx context.Context, _ *config.LangPackageStep, names []string) (map[string]string, error) {
+	out, err := runCommand(ctx, nil, "pipx", "list", "--json")
+	if err != nil {
+		return nil, fmt.Errorf("pipx list: %w", err)
+	}
+	return parsePipxList(out, names)
+}
+
+// pipxNewer asks pip inside each package's venv, since pipx has no outdated command.
+func pipxNewer(ctx context.Context, _ *config.LangPackageStep, installed map[string]string) (map[string]string, error) {
+	newer := make(map[string]string)
+	for _, name := range sortedKeys(installed) {
+		out, err := runCommand(ctx, pipEnv, "pipx", "runpip", name, "list", "--outdated", "--format=json")
+		if err != nil {
+			return nil, fmt.Errorf("pipx runpip %s: %w", name, err)
+		}
+		found, err := parsePipList(out, []string{name}, true)
+		if err != nil {
+			return nil, err
+		}
+		if version, ok := found[name]; ok {
+			newer[name] = version
+		}
+	}
+	return newer, nil
+}
+
+func pipxInstall(ctx context.Context, _ *config.LangPackageStep, name, version string, reinstall bool) error {
+	argv := []string{"pipx", "install"}
+	if reinstall {
+		argv = append(argv, "--force")
+	}
+	return run(ctx, nil, append(argv, pipSpec(name, version))...)
+}
+
+func pipxUpgrade(ctx context.Context, _ *config.LangPackageStep, name string) error {
+	return run(ctx, nil, "pipx", "upgrade", name)
+}
+
+// --- npm ---
+
+// parseNpmList reads `npm ls -g --depth=0 --json`.
+func parseNpmList(out string, names []string) (map[string]string, error) {
+	var listed struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(out), &listed); err != nil {
+		return nil, fmt.Errorf("parse npm ls output: %w", err)
+	}
+	found := make(map[string]string)
+	for _, name := range names {
+		if dep, ok := listed.Dependencies[name]; ok && dep.Version != "" {
+			found[name] = dep.Version
+		}
+	}
+	return found, nil
+}
+
+// parseNpmOutdated reads `npm outdated -g --json`.
+func parseNpmOutdated(out string, installed map[string]string) (map[string]string, error) {
+	var listed map[string]struct {
+		Current string `json:"current"`
+		Latest  string `json:"latest"`
+	}
+	if err := json.Unmarshal([]byte(out), &listed); err != nil {
+		return nil, fmt.Errorf("parse npm outdated output: %w", err)
+	}
+	newer := make(map[string]string)
+	for name := range installed {
+		if pkg, ok := listed[name]; ok && pkg.Latest != "" && pkg.Latest != pkg.Current {
+			newer[name] = pkg.Latest
+		}
+	}
+	return newer, nil
+}
+
+func npmInstalled(ctx context.Context, _ *config.LangPackageStep, names []string) (map[string]string, error) {
+	// npm ls exits non-zero on problems such as extraneous packages but still lists them.
+	out, err := queryOutput(ctx, nil, "npm", "ls", "-g", "--depth=0", "--json")
+	if err != nil {
+		return nil, err
+	}
+	return parseNpmList(out, names)
+}
+
+func npmNewer(ctx context.Context, _ *config.LangPackageStep, installed map[string]string) (map[string]string, error) {
+	// npm outdated exits 1 when something is outdated.
+	out, err := queryOutput(ctx, nil, "npm", "outdated", "-g", "--json")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(out) == "" {
+		return map[string]string{}, nil
+	}
+	return parseNpmOutdated(out, installed)
+}
+
+func npmInstall(ctx context.Context, _ *config.LangPackageStep, name, version string, _ bool) error {
+	spec := name
+	if prefix, ok := wildcard(version); ok {
+		// npm reads a partial version as a range: "5.4" is any 5.4.x.
+		spec += "@" + prefix
+	} else if version != "" {
+		spec += "@" + version
+	}
+	return run(ctx, nil, "npm", "install", "-g", spec)
+}
+
+func npmUpgrade(ctx context.Context, _ *config.LangPackageStep, name string) error {
+	return run(ctx, nil, "npm", "install", "-g", name+"@latest")
+}
+
+// --- cargo ---
+
+// parseCargoList reads `cargo install --list`: "ripgrep v14.1.0:" followed by indented binaries.
+func parseCargoList(out string, names []string) map[string]string {
+	listed := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		fields := strings.Fields(strings.TrimSuffix(line, ":"))
+		if len(fields) >= 2 {
+			listed[fields[0]] = strings.TrimSuffix(strings.TrimPrefix(fields[1], "v"), ":")
+		}
+	}
+	found := make(map[string]string)
+	for _, name := range names {
+		if version, ok := listed[name]; ok {
+			found[name] = version
+		}
+	}
+	return found
+}
+
+// parseCargoSearch reads the `ripgrep = "14.1.0"    # description` line of `cargo search`.
+func parseCargoSearch(out, name string) string {
+	for _, line := range lines(out) {
+		if rest, ok := strings.CutPrefix(line, name+" = \""); ok {
+			if version, _, ok := strings.Cut(rest, "\""); ok {
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+func cargoInstalled(ctx context.Context, _ *config.LangPackageStep, names []string) (map[string]string, error) {
+	out, err := runCommand(ctx, nil, "cargo", "install", "--list")
+	if err != nil {
+		return nil, fmt.Errorf("cargo install --list: %w", err)
+	}
+	return parseCargoList(out, names), nil
+}
+
+func cargoNewer(ctx context.Context, _ *config.LangPackageStep, installed map[string]string) (map[string]string, error) {
+	newer := make(map[string]string)
+	for _, name := range sortedKeys(installed) {
+		out, err := runCommand(ctx, nil, "cargo", "search", name, "--limit", "1")
+		if err != nil {
+			return nil, fmt.Errorf("cargo search %s: %w", name, err)
+		}
+		if latest := parseCargoSearch(out, name); latest != "" && latest != installed[name] {
+			newer[name] = latest
+		}
+	}
+	return newer, nil
+}
+
+func cargoInstall(ctx context.Context, _ *config.LangPackageStep, name, version string, reinstall bool) error {
+	argv := []string{"cargo", "install", name}
+	if prefix, ok := wildcard(version); ok {
+		// A bare version means exactly that version to cargo; "~" accepts its patch releases.
+		argv = append(argv, "--version", "~"+prefix)
+	} else if version != "" {
+		argv = append(argv, "--version", version)
+	}
+	if reinstall {
+		argv = append(argv, "--force")
+	}
+	return run(ctx, nil, argv...)
+}
+
+func cargoUpgrade(ctx context.Context, _ *config.LangPackageStep, name string) error {
+	// cargo install replaces an installed crate when a newer version is published.
+	return run(ctx, nil, "cargo", "install", name)
+}
+
+// --- go ---
+
+// goBinDir is where go install puts binaries: GOBIN, or the bin directory of the first GOPATH entry.
+func goBinDir(ctx context.Context) (string, error) {
+	out, err := runCommand(ctx, nil, "go", "env", "GOBIN", "GOPATH")
+	if err != nil {
+		return "", fmt.Errorf("go env: %w", err)
+	}
+	values := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+		return strings.TrimSpace(values[0]), nil
+	}
+	if len(values) > 1 {
+		if gopath := filepath.SplitList(strings.TrimSpace(values[1])); len(gopath) > 0 && gopath[0] != "" {
+			return filepath.Join(gopath[0], "bin"), nil
+		}
+	}
+	return "", fmt.Errorf("go env reported neither GOBIN nor GOPATH")
+}
+
+var majorVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// goBinaryName is the name go install gives the binary of a package path, skipping a major
+// version suffix: "github.com/foo/tool/v2" builds "tool".
+func goBinaryName(pkg string) string {
+	base := path.Base(pkg)
+	if majorVersionSuffix.MatchString(base) && path.Dir(pkg) != "." {
+		base = path.Base(path.Dir(pkg))
+	}
+	return base
+}
+
+// goBuildInfo holds what `go version -m` reports about an installed binary.
+type goBuildInfo struct {
+	Path    string
+	Module  string
+	Version string
+}
+
+// parseGoVersion reads the "path" and "mod" lines of `go version -m`.
+func parseGoVersion(out string) goBuildInfo {
+	var info goBuildInfo
+	for _, line := range lines(out) {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 2 && fields[0] == "path":
+			info.Path = fields[1]
+		case len(fields) >= 3 && fields[0] == "mod":
+			info.Module, info.Version = fields[1], fields[2]
+		}
+	}
+	return info
+}
+
+// goBinaries reads the build info of the installed binary of each of names, skipping binaries
+// missing or built from another package.
+func goBinaries(ctx context.Context, names []string) (map[string]goBuildInfo, error) {
+	dir, err := goBinDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]goBuildInfo)
+	for _, name := range names {
+		binary := filepath.Join(dir, goBinaryName(name))
+		if _, err := os.Stat(binary); err != nil {
+			continue
+		}
+		out, err := runCommand(ctx, nil, "go", "version", "-m", binary)
+		if err != nil {
+			return nil, fmt.Errorf("go version -m %s: %w", binary, err)
+		}
+		if info := parseGoVersion(out); info.Path == name {
+			found[name] = info
+		}
+	}
+	return found, nil
+}
+
+func goInstalled(ctx context.Context, _ *config.LangPackageStep, names []string) (map[string]string, error) {
+	binaries, err := goBinaries(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]string, len(binaries))
+	for name, info := range binaries {
+		found[name] = info.Version
+	}
+	return found, nil
+}
+
+func goNewer(ctx context.Context, _ *config.LangPackageStep, installed map[string]string) (map[string]string, error) {
+	binaries, err := goBinaries(ctx, sortedKeys(installed))
+	if err != nil {
+		return nil, err
+	}
+	newer := make(map[string]string)
+	for _, name := range sortedKeys(installed) {
+		info, ok := binaries[name]
+		if !ok || info.Module == "" {
+			continue
+		}
+		out, err := runCommand(ctx, nil, "go", "list", "-m", "-f", "{{.Version}}", info.Module+"@latest")
+		if err != nil {
+			return nil, fmt.Errorf("go list %s: %w", info.Module, err)
+		}
+		if latest := strings.TrimSpace(out); latest != "" && latest != info.Version {
+			newer[name] = latest
+		}
+	}
+	return newer, nil
+}
+
+func goInstall(ctx context.Context, _ *config.LangPackageStep, name, version string, _ bool) error {
+	query := "latest"
+	if prefix, ok := wildcard(version); ok {
+		// go resolves a partial version to its newest release: "v0.15" is the latest v0.15.x.
+		query = "v" + strings.TrimPrefix(prefix, "v")
+	} else if version != "" {
+		query = "v" + strings.TrimPrefix(version, "v")
+	}
+	return run(ctx, nil, "go", "install", name+"@"+query)
+}
+
+func goUpgrade(ctx context.Context, _ *config.LangPackageStep, name string) error {
+	return run(ctx, nil, "go", "install", name+"@latest")
+}
+
+// --- gem ---
+
+// parseGemList reads `gem list --local` lines such as "rake (13.1.0, default: 13.0.6)"; every
+// installed version is kept, newest first and separated by ", ".
+func parseGemList(out string, names []string) map[string]string {
+	listed := make(map[string]string)
+	for _, line := range lines(out) {
+		name, rest, ok := strings.Cut(line, " (")
+		if !ok {
+			continue
+		}
+		versions := strings.Split(strings.TrimSuffix(rest, ")"), ", ")
+		for i, version := range versions {
+			versions[i] = strings.TrimPrefix(version, "default: ")
+		}
+		listed[name] = strings.Join(versions, ", ")
+	}
+	found := make(map[string]string)
+	for _, name := range names {
+		if version, ok := listed[name]; ok {
+			found[name] = version
+		}
+	}
+	return found
+}
+
+// parseGemOutdated reads `gem outdated` lines such as "rake (13.0.6 < 13.1.0)".
+func parseGemOutdated(out string, installed map[string]string) map[string]string {
+	newer := make(map[string]string)
+	for _, line := range lines(out) {
+		name, rest, ok := strings.Cut(line, " (")
+		if _, wanted := installed[name]; !ok || !wanted {
+			continue
+		}
+		if _, latest, ok := strings.Cut(strings.TrimSuffix(rest, ")"), " < "); ok {
+			newer[name] = latest
+		}
+	}
+	return newer
+}
+
+func gemInstalled(ctx context.Context, _ *config.LangPackageStep, names []string) (map[string]string, error) {
+	out, err := runCommand(ctx, nil, "gem", "list", "--local")
+	if err != nil {
+		return nil, fmt.Errorf("gem list: %w", err)
+	}
+	return parseGemList(out, names), nil
+}
+
+func gemNewer(ctx context.Context, _ *config.LangPackageStep, installed map[string]string) (map[string]string, error) {
+	out, err := runCommand(ctx, nil, "gem", "outdated")
+	if err != nil {
+		return nil, fmt.Errorf("gem outdated: %w", err)
+	}
+	return parseGemOutdated(out, installed), nil
+}
+
+func gemInstall(ctx context.Context, _ *config.LangPackageStep, name, version string, _ bool) error {
+	argv := []string{"gem", "install", name, "--no-document"}
+	if prefix, ok := wildcard(version); ok {
+		// "~> 13.0.0" accepts any 13.0.x.
+		argv = append(argv, "--version", "~> "+prefix+".0")
+	} else if version != "" {
+		argv = append(argv, "--version", version)
+	}
+	return run(ctx, nil, argv...)
+}
+
+func gemUpgrade(ctx context.Context, _ *config.LangPackageStep, name string) error {
+	return run(ctx, nil, "gem", "update", name, "--no-document")
+}
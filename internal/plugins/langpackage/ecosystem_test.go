@@ -0,0 +1,63 @@
+package langpackageplugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInstalled(t *testing.T) {
+	t.Parallel()
+
+	pip, err := parsePipList(`[{"name": "PyYAML", "version": "6.0.1"}, {"name": "black", "version": "24.1.0", "latest_version": "24.2.0"}]`, []string{"pyyaml", "black", "ruff"}, false)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"pyyaml": "6.0.1", "black": "24.1.0"}, pip)
+
+	pipx, err := parsePipxList(`{"venvs": {"black": {"metadata": {"main_package": {"package": "black", "package_version": "24.1.0"}}}}}`, []string{"black", "ruff"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"black": "24.1.0"}, pipx)
+
+	npm, err := parseNpmList(`{"dependencies": {"@angular/cli": {"version": "17.1.0"}}}`, []string{"@angular/cli", "typescript"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"@angular/cli": "17.1.0"}, npm)
+
+	require.Equal(t, map[string]string{"ripgrep": "14.1.0", "tool": "0.1.0"},
+		parseCargoList("ripgrep v14.1.0:\n    rg\ntool v0.1.0 (/src/tool):\n    tool\n", []string{"ripgrep", "tool", "bat"}))
+	require.Equal(t, map[string]string{"rake": "13.1.0, 12.3.3", "bundler": "2.5.4, 2.4.10"},
+		parseGemList("*** LOCAL GEMS ***\nbundler (2.5.4, default: 2.4.10)\nrake (13.1.0, 12.3.3)\n", []string{"rake", "bundler"}))
+
+	info := parseGoVersion("/root/go/bin/gopls: go1.22.1\n\tpath\tgolang.org/x/tools/gopls\n\tmod\tgolang.org/x/tools/gopls\tv0.15.1\th1:abc=\n\tdep\tgolang.org/x/mod\tv0.15.0\th1:def=\n")
+	require.Equal(t, goBuildInfo{Path: "golang.org/x/tools/gopls", Module: "golang.org/x/tools/gopls", Version: "v0.15.1"}, info)
+	require.Equal(t, "gopls", goBinaryName("golang.org/x/tools/gopls"))
+	require.Equal(t, "migrate", goBinaryName("github.com/golang-migrate/migrate/v4"))
+}
+
+func TestParseNewer(t *testing.T) {
+	t.Parallel()
+
+	installed := map[string]string{"typescript": "5.3.3", "prettier": "3.2.5", "rake": "13.0.6"}
+
+	npm, err := parseNpmOutdated(`{"typescript": {"current": "5.3.3", "wanted": "5.3.3", "latest": "5.4.2"}, "eslint": {"current": "8.0.0", "latest": "9.0.0"}}`, installed)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"typescript": "5.4.2"}, npm)
+
+	require.Equal(t, "14.1.0", parseCargoSearch("ripgrep = \"14.1.0\"    # fast grep\n... and 3 crates more\n", "ripgrep"))
+	require.Empty(t, parseCargoSearch("ripgrep-all = \"0.10.6\"\n", "ripgrep"))
+	require.Equal(t, map[string]string{"rake": "13.1.0"}, parseGemOutdated("rake (13.0.6 < 13.1.0)\nrack (2.0 < 3.0)\n", installed))
+}
+
+func TestVersionMatches(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, versionMatches("24.1.0", "24.1.0"))
+	require.True(t, versionMatches("v0.15.1", "0.15.1"))
+	require.True(t, versionMatches("v0.15.1", "v0.15*"))
+	require.True(t, versionMatches("13.1.0, 12.3.3", "12.3.3"))
+	require.False(t, versionMatches("24.10.0", "24.1.0"))
+	require.False(t, versionMatches("23.12.1", "24.1*"))
+
+	require.Equal(t, "black==24.1.*", pipSpec("black", "24.1*"))
+	require.Equal(t, "black==24.1.*", pipSpec("black", "24.1.*"))
+	require.Equal(t, "black==24.1.0", pipSpec("black", "24.1.0"))
+	require.Equal(t, "black", pipSpec("black", ""))
+}
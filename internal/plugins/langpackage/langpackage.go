@@ -0,0 +1,279 @@
+package langpackageplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
+)
+
+type langPackagePlugin struct{}
+
+// New creates a new lang_package plugin instance.
+func New() plugin.Plugin {
+	return &langPackagePlugin{}
+}
+
+var _ plugin.Plugin = (*langPackagePlugin)(nil)
+
+// PluginMetadata describes the plugin for the dependency registry.
+//
+// The empty Dependencies slice documents that lang_package does not require other plugins.
+// APIVersion pins compatibility with other plugins using the registry-provided interface.
+func (p *langPackagePlugin) PluginMetadata() plugin.PluginMetadata {
+	return plugin.PluginMetadata{
+		Name:         "lang_package",
+		Type:         "lang_package",
+		Version:      "1.0.0",
+		APIVersion:   "1.x",
+		Dependencies: []plugin.Dependency{},
+		Stateful:     false,
+		Description:  "Manages language packages with pipx, pip, npm, cargo, go install or gem.",
+		// Installing over a package does not keep the version it replaced.
+		Irreversible: true,
+	}
+}
+
+func (p *langPackagePlugin) Schema() any {
+	return config.LangPackageStep{}
+}
+
+// Evaluation data for lang_package operations
+type langPackageEvaluationData struct {
+	// Versions holds the installed version of each installed package.
+	Versions map[string]string
+	// Install lists packages to install: missing ones, and pinned ones at another version.
+	Install []string
+	Upgrade []string
+	// Changes describes each planned change, one per line, for the diff.
+	Changes []string
+}
+
+func (p *langPackagePlugin) Evaluate(ctx context.Context, step *config.Step) (*model.EvaluationResult, error) {
+	cfg, err := loadLangPackageConfig(step)
+	if err != nil {
+		return nil, plugin.NewValidationError(step.ID, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, plugin.NewStateError(step.ID, fmt.Errorf("context cancelled: %w", err))
+	}
+
+	eco, err := resolveEcosystem(cfg)
+	if err != nil {
+		return nil, plugin.NewStateError(step.ID, err)
+	}
+
+	versions, err := eco.installed(ctx, cfg, cfg.Packages)
+	if err != nil {
+		return nil, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to query packages with %s: %w", eco.name, err))
+	}
+
+	data := &langPackageEvaluationData{Versions: versions}
+	var missing, drifted []string
+	for _, name := range cfg.Packages {
+		version, ok := versions[name]
+		want := cfg.Versions[name]
+		switch {
+		case !ok:
+			data.Install = append(data.Install, name)
+			data.Changes = append(data.Changes, strings.TrimSpace("install "+name+" "+want))
+			missing = append(missing, name)
+		case want != "" && !versionMatches(version, want):
+			data.Install = append(data.Install, name)
+			data.Changes = append(data.Changes, fmt.Sprintf("%s %s -> %s", name, version, want))
+			drifted = append(drifted, name)
+		}
+	}
+
+	if cfg.State == config.LangPackageStateLatest && len(versions) > 0 {
+		newer, err := eco.newer(ctx, cfg, versions)
+		if err != nil {
+			return nil, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to check %s for newer versions: %w", eco.name, err))
+		}
+		for _, name := range cfg.Packages {
+			if version, ok := newer[name]; ok {
+				data.Upgrade = append(data.Upgrade, name)
+				data.Changes = append(data.Changes, fmt.Sprintf("%s %s -> %s", name, versions[name], version))
+				drifted = append(drifted, name)
+			}
+		}
+	}
+
+	if len(data.Changes) == 0 {
+		return &model.EvaluationResult{
+			StepID:         step.ID,
+			CurrentState:   model.StatusSatisfied,
+			RequiresAction: false,
+			Message:        fmt.Sprintf("all packages installed: %s", strings.Join(cfg.Packages, ", ")),
+			InternalData:   data,
+		}, nil
+	}
+
+	// Installed packages at the wrong or an outdated version count as drift; missing ones do not.
+	state := model.StatusMissing
+	message := fmt.Sprintf("packages not installed: %s", strings.Join(missing, ", "))
+	if len(drifted) > 0 {
+		state = model.StatusDrifted
+		message = fmt.Sprintf("packages drifted: %s", strings.Join(drifted, ", "))
+		if len(missing) > 0 {
+			message += fmt.Sprintf("; not installed: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	return &model.EvaluationResult{
+		StepID:         step.ID,
+		CurrentState:   state,
+		RequiresAction: true,
+		Message:        message,
+		Diff:           fmt.Sprintf("Would change with %s:\n%s", eco.name, strings.Join(data.Changes, "\n")),
+		InternalData:   data,
+	}, nil
+}
+
+func (p *langPackagePlugin) Apply(ctx context.Context, evalResult *model.EvaluationResult, step *config.Step) (*model.StepResult, error) {
+	cfg, err := loadLangPackageConfig(step)
+	if err != nil {
+		return nil, plugin.NewValidationError(step.ID, err)
+	}
+
+	// Use evaluation data to avoid recomputation
+	var data *langPackageEvaluationData
+	if evalResult != nil {
+		if typed, ok := evalResult.InternalData.(*langPackageEvaluationData); ok {
+			data = typed
+		}
+	}
+	if data == nil {
+		// Fallback to re-evaluating
+		evalResult, err = p.Evaluate(ctx, step)
+		if err != nil {
+			return nil, convertError(step.ID, err)
+		}
+		typed, ok := evalResult.InternalData.(*langPackageEvaluationData)
+		if !ok || typed == nil {
+			return &model.StepResult{
+				StepID:  step.ID,
+				Status:  model.StatusFailed,
+				Message: "evaluation failed during apply",
+				Error:   fmt.Errorf("evaluation result missing lang_package evaluation data"),
+			}, plugin.NewExecutionError(step.ID, fmt.Errorf("evaluation failed during apply"))
+		}
+		data = typed
+	}
+
+	// Only apply if changes are needed
+	if !evalResult.RequiresAction {
+		return &model.StepResult{
+			StepID:  step.ID,
+			Status:  model.StatusSkipped,
+			Message: "no changes needed",
+		}, nil
+	}
+
+	eco, err := resolveEcosystem(cfg)
+	if err != nil {
+		return nil, plugin.NewStateError(step.ID, err)
+	}
+
+	var done []string
+	for _, name := range data.Install {
+		_, reinstall := data.Versions[name]
+		if err := eco.install(ctx, cfg, name, cfg.Versions[name], reinstall); err != nil {
+			return failedApply(step.ID, "install "+name, err)
+		}
+	}
+	if len(data.Install) > 0 {
+		done = append(done, "installed "+strings.Join(data.Install, ", "))
+	}
+	for _, name := range data.Upgrade {
+		if err := eco.upgrade(ctx, cfg, name); err != nil {
+			return failedApply(step.ID, "upgrade "+name, err)
+		}
+	}
+	if len(data.Upgrade) > 0 {
+		done = append(done, "upgraded "+strings.Join(data.Upgrade, ", "))
+	}
+
+	return &model.StepResult{
+		StepID:  step.ID,
+		Status:  model.StatusSuccess,
+		Message: fmt.Sprintf("%s: %s", eco.name, strings.Join(done, "; ")),
+	}, nil
+}
+
+// Helper functions
+
+// resolveEcosystem returns the step's ecosystem once its tool is found. A pip venv that already
+// exists brings its own interpreter.
+func resolveEcosystem(cfg *config.LangPackageStep) (*ecosystem, error) {
+	eco, ok := ecosystems[cfg.Manager]
+	if !ok {
+		return nil, fmt.Errorf("unsupported manager %q", cfg.Manager)
+	}
+	if cfg.Venv != "" {
+		if _, err := os.Stat(pipPython(cfg)); err == nil {
+			return eco, nil
+		}
+	}
+	if _, err := exec.LookPath(eco.binary); err != nil {
+		return nil, fmt.Errorf("%s not found on PATH", eco.binary)
+	}
+	return eco, nil
+}
+
+func failedApply(stepID, action string, err error) (*model.StepResult, error) {
+	return &model.StepResult{
+		StepID:  stepID,
+		Status:  model.StatusFailed,
+		Message: fmt.Sprintf("failed to %s: %v", action, err),
+		Error:   err,
+	}, plugin.NewExecutionError(stepID, fmt.Errorf("failed to %s: %w", action, err))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func convertError(stepID string, err error) error {
+	var valErr *streamyerrors.ValidationError
+	if errors.As(err, &valErr) {
+		return plugin.NewValidationError(stepID, valErr.Err)
+	}
+
+	var execErr *streamyerrors.ExecutionError
+	if errors.As(err, &execErr) {
+		return plugin.NewExecutionError(stepID, execErr.Err)
+	}
+
+	return plugin.NewExecutionError(stepID, err)
+}
+
+func loadLangPackageConfig(step *config.Step) (*config.LangPackageStep, error) {
+	if step == nil {
+		return nil, fmt.Errorf("step is nil")
+	}
+
+	if len(step.RawConfig()) == 0 {
+		return nil, fmt.Errorf("lang_package configuration missing")
+	}
+
+	cfg := &config.LangPackageStep{}
+	if err := step.DecodeConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
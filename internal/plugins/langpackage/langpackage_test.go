@@ -0,0 +1,252 @@
+package langpackageplugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+func newLangPackageStep(t *testing.T, id string, cfg config.LangPackageStep) *config.Step {
+	t.Helper()
+	step := &config.Step{ID: id, Type: "lang_package", Enabled: true}
+	require.NoError(t, step.SetConfig(cfg))
+	return step
+}
+
+// stubQueries answers queries with canned output keyed by the full command line and returns
+// the command lines queried.
+func stubQueries(t *testing.T, outputs map[string]string) *[]string {
+	t.Helper()
+
+	previous := runCommand
+	t.Cleanup(func() { runCommand = previous })
+	var calls []string
+	runCommand = func(_ context.Context, _ []string, name string, args ...string) (string, error) {
+		call := strings.Join(append([]string{name}, args...), " ")
+		calls = append(calls, call)
+		out, ok := outputs[call]
+		if !ok {
+			return "", fmt.Errorf("unexpected query %q", call)
+		}
+		return out, nil
+	}
+	return &calls
+}
+
+// stubPath puts executables that only log their command line first and alone on PATH, and
+// returns the log.
+func stubPath(t *testing.T, names ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "calls.log")
+	for _, name := range names {
+		script := "#!/bin/sh\necho \"${0##*/} $*\" >> " + logPath + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+	t.Setenv("PATH", dir)
+	return logPath
+}
+
+func readCalls(t *testing.T, logPath string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestLangPackagePlugin_Metadata(t *testing.T) {
+	t.Parallel()
+
+	meta := New().PluginMetadata()
+	require.Equal(t, "lang_package", meta.Name)
+	require.Equal(t, "lang_package", meta.Type)
+	require.NoError(t, meta.Validate())
+
+	_, ok := New().Schema().(config.LangPackageStep)
+	require.True(t, ok)
+}
+
+func TestLangPackagePlugin_MissingAndPinned(t *testing.T) {
+	stubQueries(t, map[string]string{
+		"npm ls -g --depth=0 --json": `{"dependencies": {"npm": {"version": "10.2.4"}, "typescript": {"version": "5.3.3"}}}`,
+	})
+	logPath := stubPath(t, "npm")
+	step := newLangPackageStep(t, "node", config.LangPackageStep{
+		Manager:  "npm",
+		Packages: []string{"typescript", "prettier"},
+		Versions: map[string]string{"typescript": "5.4*", "prettier": "3.2.5"},
+	})
+	p := New()
+
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Equal(t, "packages drifted: typescript; not installed: prettier", eval.Message)
+	require.Equal(t, "Would change with npm:\ntypescript 5.3.3 -> 5.4*\ninstall prettier 3.2.5", eval.Diff)
+
+	result, err := p.Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSuccess, result.Status)
+	require.Equal(t, "npm: installed typescript, prettier", result.Message)
+	require.Equal(t, []string{"npm install -g typescript@5.4", "npm install -g prettier@3.2.5"}, readCalls(t, logPath))
+}
+
+func TestLangPackagePlugin_LatestReportsOutdatedAsDrift(t *testing.T) {
+	stubQueries(t, map[string]string{
+		"cargo install --list":           "bat v0.24.0:\n    bat\nripgrep v14.0.3:\n    rg\n",
+		"cargo search bat --limit 1":     "bat = \"0.24.0\"    # A cat(1) clone with wings.\n",
+		"cargo search ripgrep --limit 1": "ripgrep = \"14.1.0\"    # ripgrep is a line-oriented search tool\n",
+	})
+	logPath := stubPath(t, "cargo")
+	step := newLangPackageStep(t, "rust", config.LangPackageStep{
+		Manager:  "cargo",
+		Packages: []string{"ripgrep", "bat"},
+		State:    config.LangPackageStateLatest,
+	})
+	p := New()
+
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Equal(t, "Would change with cargo:\nripgrep 14.0.3 -> 14.1.0", eval.Diff)
+
+	result, err := p.Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, "cargo: upgraded ripgrep", result.Message)
+	require.Equal(t, []string{"cargo install ripgrep"}, readCalls(t, logPath))
+}
+
+func TestLangPackagePlugin_Present(t *testing.T) {
+	calls := stubQueries(t, map[string]string{
+		"gem list --local": "*** LOCAL GEMS ***\n\nbundler (2.5.4, default: 2.4.10)\nrake (13.1.0, 12.3.3)\n",
+	})
+	stubPath(t, "gem")
+	step := newLangPackageStep(t, "ruby", config.LangPackageStep{
+		Manager:  "gem",
+		Packages: []string{"bundler", "rake"},
+		Versions: map[string]string{"rake": "12.3.3"},
+	})
+
+	eval, err := New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+	require.Equal(t, "all packages installed: bundler, rake", eval.Message)
+	// Without state latest nothing asks for newer versions.
+	require.Equal(t, []string{"gem list --local"}, *calls)
+}
+
+func TestLangPackagePlugin_GoInstall(t *testing.T) {
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "gopls"), nil, 0o755))
+	stubQueries(t, map[string]string{
+		"go env GOBIN GOPATH": "\n/home/me/go\n",
+	})
+	stubPath(t, "go")
+	step := newLangPackageStep(t, "go-tools", config.LangPackageStep{
+		Manager:  "go",
+		Packages: []string{"golang.org/x/tools/gopls"},
+		Versions: map[string]string{"golang.org/x/tools/gopls": "0.15*"},
+	})
+
+	eval, err := New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusMissing, eval.CurrentState)
+
+	logPath := stubPath(t, "go")
+	stubQueries(t, map[string]string{
+		"go env GOBIN GOPATH":                          bin + "\n/home/me/go\n",
+		"go version -m " + filepath.Join(bin, "gopls"): bin + "/gopls: go1.22.1\n\tpath\tgolang.org/x/tools/gopls\n\tmod\tgolang.org/x/tools/gopls\tv0.14.2\th1:abc=\n",
+	})
+	eval, err = New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Equal(t, "Would change with go:\ngolang.org/x/tools/gopls v0.14.2 -> 0.15*", eval.Diff)
+
+	_, err = New().Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, []string{"go install golang.org/x/tools/gopls@v0.15"}, readCalls(t, logPath))
+}
+
+func TestLangPackagePlugin_PipUserAndVenv(t *testing.T) {
+	stubQueries(t, map[string]string{
+		"python3 -m pip list --format=json --user": `[{"name": "Black", "version": "23.12.1"}]`,
+	})
+	logPath := stubPath(t, "python3")
+	step := newLangPackageStep(t, "python", config.LangPackageStep{
+		Manager:  "pip",
+		Packages: []string{"black", "ruff"},
+		Versions: map[string]string{"black": "24.1*"},
+	})
+	p := New()
+
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, "Would change with pip:\nblack 23.12.1 -> 24.1*\ninstall ruff", eval.Diff)
+
+	_, err = p.Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"python3 -m pip install black==24.1.* --user",
+		"python3 -m pip install ruff --user",
+	}, readCalls(t, logPath))
+
+	// A venv that does not exist yet holds nothing, and is not queried.
+	calls := stubQueries(t, map[string]string{})
+	step = newLangPackageStep(t, "python", config.LangPackageStep{
+		Manager:  "pip",
+		Packages: []string{"black"},
+		Venv:     filepath.Join(t.TempDir(), "venv"),
+	})
+	eval, err = p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusMissing, eval.CurrentState)
+	require.Empty(t, *calls)
+}
+
+func TestLangPackagePlugin_Errors(t *testing.T) {
+	stubPath(t)
+	step := newLangPackageStep(t, "node", config.LangPackageStep{Manager: "npm", Packages: []string{"typescript"}})
+
+	_, err := New().Evaluate(context.Background(), step)
+	var stateErr *plugin.StateError
+	require.ErrorAs(t, err, &stateErr)
+	require.ErrorContains(t, err, "npm not found on PATH")
+
+	stubPath(t, "npm")
+	stubQueries(t, map[string]string{})
+	_, err = New().Evaluate(context.Background(), step)
+	var execErr *plugin.ExecutionError
+	require.ErrorAs(t, err, &execErr)
+	require.ErrorContains(t, err, "failed to query packages with npm")
+
+	_, err = New().Evaluate(context.Background(), &config.Step{ID: "empty", Type: "lang_package"})
+	require.ErrorContains(t, err, "lang_package configuration missing")
+}
+
+func TestLangPackagePlugin_ApplyFailure(t *testing.T) {
+	stubQueries(t, map[string]string{"pipx list --json": `{"venvs": {}}`})
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pipx"), []byte("#!/bin/sh\necho 'No matching distribution found for nope' >&2\nexit 1\n"), 0o755))
+	t.Setenv("PATH", dir)
+	step := newLangPackageStep(t, "python", config.LangPackageStep{Manager: "pipx", Packages: []string{"nope"}})
+
+	eval, err := New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+
+	result, err := New().Apply(context.Background(), eval, step)
+	require.Error(t, err)
+	require.Equal(t, model.StatusFailed, result.Status)
+	require.Contains(t, result.Message, "failed to install nope")
+	require.Contains(t, result.Message, "No matching distribution found")
+}
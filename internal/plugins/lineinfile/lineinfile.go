@@ -173,6 +173,11 @@ func (p *lineInFilePlugin) Apply(ctx context.Context, evalResult *model.Evaluati
 	// Write the updated content
 	newContent := joinLines(data.UpdatedLines, data.TrailingNewline)
 
+	// Record the file's prior content so the edit can be rolled back
+	if err := plugin.JournalFromContext(ctx).SavePath(data.State.Path); err != nil {
+		return nil, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to journal %s: %w", data.State.Path, err))
+	}
+
 	// Handle backup if needed
 	if cfg.Backup && data.State.Exists {
 		originalBytes, err := encodeContent(data.CurrentContent, cfg.Encoding)
@@ -0,0 +1,582 @@
+package packageplugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/alexisbeaulieu97/streamy/internal/plugins/internalexec"
+)
+
+// backend drives one package manager. Queries run silently through runCommand and check every
+// package in a single invocation; changes stream their output like any other step command.
+type backend struct {
+	name string
+	// binary identifies the manager on the host during auto-detection.
+	binary string
+	// query prints the installed version of each package whose name is appended.
+	query []string
+	// listsAll is set for queries that take no names and list every installed package.
+	listsAll bool
+	// queryFailsOnMissing is set for queries that exit non-zero when some names are not
+	// installed while still listing the ones that are.
+	queryFailsOnMissing bool
+	// parse maps the installed packages among names to their versions, from the query's stdout.
+	parse func(out string, names []string) map[string]string
+	// outdated lists every installed package with a newer version available; parseOutdated maps
+	// those among names to the newer version.
+	outdated      []string
+	parseOutdated func(out string, names []string) map[string]string
+	// held lists the packages held at their version; parseHeld picks those among names. Both are
+	// nil for managers that cannot hold packages from the command line.
+	held      []string
+	parseHeld func(out string, names []string) map[string]bool
+	// pin formats a package and version constraint for install; nil when versions cannot be chosen.
+	// rpm-based managers take a version without its release and pick any release.
+	pin func(name, version string) string
+	// candidates lists the versions available for each package whose name is appended, newest
+	// first, for managers that install only exact versions; parseCandidates maps those among
+	// names to them. Pins are resolved to the newest candidate they match before installing.
+	candidates      []string
+	parseCandidates func(out string, names []string) map[string][]string
+	// pinFlags are added to install when a version is pinned, e.g. to allow downgrades.
+	pinFlags []string
+
+	update  []string
+	install []string
+	upgrade []string
+	remove  []string
+	hold    []string
+	unhold  []string
+	env     []string
+}
+
+// backends lists supported package managers in order of preference for auto-detection, matching
+// the order facts reports them in.
+var backends = []*backend{
+	{
+		name:                "apt",
+		binary:              "apt-get",
+		query:               []string{"dpkg-query", "-W", "-f", "${Package}\t${Status}\t${Version}\n"},
+		queryFailsOnMissing: true,
+		parse:               parseDpkgStatus,
+		outdated:            []string{"apt", "list", "--upgradable"},
+		parseOutdated:       parseAptUpgradable,
+		held:                []string{"apt-mark", "showhold"},
+		parseHeld:           parseNames,
+		pin:                 func(name, version string) string { return name + "=" + version },
+		pinFlags:            []string{"--allow-downgrades"},
+		candidates:          []string{"apt-cache", "madison"},
+		parseCandidates:     parseMadison,
+		update:              []string{"apt-get", "update"},
+		install:             []string{"apt-get", "install", "-y"},
+		upgrade:             []string{"apt-get", "install", "-y", "--only-upgrade"},
+		remove:              []string{"apt-get", "remove", "-y"},
+		hold:                []string{"apt-mark", "hold"},
+		unhold:              []string{"apt-mark", "unhold"},
+		env:                 []string{"DEBIAN_FRONTEND=noninteractive"},
+	},
+	{
+		name:                "dnf",
+		binary:              "dnf",
+		query:               []string{"rpm", "-q", "--qf", "%{NAME}\t%{VERSION}-%{RELEASE}\n"},
+		queryFailsOnMissing: true,
+		parse:               parseTabbed,
+		outdated:            []string{"dnf", "-q", "check-update"},
+		parseOutdated:       parseCheckUpdate,
+		held:                []string{"dnf", "-q", "versionlock", "list"},
+		parseHeld:           parseVersionedNames,
+		pin:                 func(name, version string) string { return name + "-" + version },
+		update:              []string{"dnf", "makecache", "-y"},
+		install:             []string{"dnf", "install", "-y"},
+		upgrade:             []string{"dnf", "upgrade", "-y"},
+		remove:              []string{"dnf", "remove", "-y"},
+		hold:                []string{"dnf", "versionlock", "add"},
+		unhold:              []string{"dnf", "versionlock", "delete"},
+	},
+	{
+		name:                "yum",
+		binary:              "yum",
+		query:               []string{"rpm", "-q", "--qf", "%{NAME}\t%{VERSION}-%{RELEASE}\n"},
+		queryFailsOnMissing: true,
+		parse:               parseTabbed,
+		outdated:            []string{"yum", "-q", "check-update"},
+		parseOutdated:       parseCheckUpdate,
+		held:                []string{"yum", "-q", "versionlock", "list"},
+		parseHeld:           parseVersionedNames,
+		pin:                 func(name, version string) string { return name + "-" + version },
+		update:              []string{"yum", "makecache", "-y"},
+		install:             []string{"yum", "install", "-y"},
+		upgrade:             []string{"yum", "update", "-y"},
+		remove:              []string{"yum", "remove", "-y"},
+		hold:                []string{"yum", "versionlock", "add"},
+		unhold:              []string{"yum", "versionlock", "delete"},
+	},
+	{
+		// pacman can neither install an older version from its repositories nor hold packages
+		// without editing pacman.conf.
+		name:                "pacman",
+		binary:              "pacman",
+		query:               []string{"pacman", "-Q"},
+		queryFailsOnMissing: true,
+		parse:               parseNameVersion,
+		outdated:            []string{"pacman", "-Qu"},
+		parseOutdated:       parseArrow,
+		update:              []string{"pacman", "-Sy", "--noconfirm"},
+		install:             []string{"pacman", "-S", "--noconfirm", "--needed"},
+		upgrade:             []string{"pacman", "-S", "--noconfirm"},
+		remove:              []string{"pacman", "-R", "--noconfirm"},
+	},
+	{
+		name:                "apk",
+		binary:              "apk",
+		query:               []string{"apk", "list", "--installed"},
+		queryFailsOnMissing: true,
+		parse:               parseApkList,
+		outdated:            []string{"apk", "version", "-l", "<"},
+		parseOutdated:       parseApkVersion,
+		pin:                 func(name, version string) string { return name + "=" + version },
+		update:              []string{"apk", "update"},
+		install:             []string{"apk", "add"},
+		upgrade:             []string{"apk", "add", "--upgrade"},
+		remove:              []string{"apk", "del"},
+	},
+	{
+		name:                "zypper",
+		binary:              "zypper",
+		query:               []string{"rpm", "-q", "--qf", "%{NAME}\t%{VERSION}-%{RELEASE}\n"},
+		queryFailsOnMissing: true,
+		parse:               parseTabbed,
+		outdated:            []string{"zypper", "-q", "list-updates"},
+		parseOutdated:       parseZypperUpdates,
+		held:                []string{"zypper", "-q", "locks"},
+		parseHeld:           parseZypperLocks,
+		pin:                 func(name, version string) string { return name + "=" + version },
+		pinFlags:            []string{"--oldpackage"},
+		update:              []string{"zypper", "--non-interactive", "refresh"},
+		install:             []string{"zypper", "--non-interactive", "install"},
+		upgrade:             []string{"zypper", "--non-interactive", "update"},
+		remove:              []string{"zypper", "--non-interactive", "remove"},
+		hold:                []string{"zypper", "--non-interactive", "addlock"},
+		unhold:              []string{"zypper", "--non-interactive", "removelock"},
+	},
+	{
+		// brew cannot be asked about specific names without failing on the first missing one,
+		// so it lists everything installed, formulae and casks alike. Versions are separate
+		// formulae (python@3.12) rather than something to pin.
+		name:          "brew",
+		binary:        "brew",
+		query:         []string{"brew", "list", "--versions"},
+		listsAll:      true,
+		parse:         parseNameVersion,
+		outdated:      []string{"brew", "outdated", "--verbose"},
+		parseOutdated: parseBrewOutdated,
+		held:          []string{"brew", "list", "--pinned"},
+		parseHeld:     parseNames,
+		update:        []string{"brew", "update"},
+		install:       []string{"brew", "install"},
+		upgrade:       []string{"brew", "upgrade"},
+		remove:        []string{"brew", "uninstall"},
+		hold:          []string{"brew", "pin"},
+		unhold:        []string{"brew", "unpin"},
+		env:           []string{"HOMEBREW_NO_AUTO_UPDATE=1"},
+	},
+}
+
+// runCommand runs a read-only package manager query and returns its stdout; tests replace it to
+// fake the host's packages.
+var runCommand = func(ctx context.Context, env []string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return string(out), err
+}
+
+// resolveBackend returns the backend named by the step, or the first one found on the host.
+func resolveBackend(name string) (*backend, error) {
+	if name != "" {
+		for _, b := range backends {
+			if b.name == name {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported package manager %q", name)
+	}
+
+	for _, b := range backends {
+		if _, err := exec.LookPath(b.binary); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("no supported package manager found on PATH")
+}
+
+// installed returns the installed version of each of names that is installed, in one query.
+func (b *backend) installed(ctx context.Context, names []string) (map[string]string, error) {
+	if len(names) == 0 {
+		return map[string]string{}, nil
+	}
+
+	args := append([]string(nil), b.query[1:]...)
+	if !b.listsAll {
+		args = append(args, names...)
+	}
+	out, err := runCommand(ctx, b.env, b.query[0], args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !b.queryFailsOnMissing || !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%s: %w", b.query[0], err)
+		}
+	}
+	return b.parse(out, names), nil
+}
+
+// newer returns the newer version available for each of names that can be upgraded.
+func (b *backend) newer(ctx context.Context, names []string) (map[string]string, error) {
+	out, err := runCommand(ctx, b.env, b.outdated[0], b.outdated[1:]...)
+	if err != nil {
+		// check-update and pacman -Qu use their exit status to say whether anything is listed.
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%s: %w", b.outdated[0], err)
+		}
+	}
+	return b.parseOutdated(out, names), nil
+}
+
+// holds reports which of names are held.
+func (b *backend) holds(ctx context.Context, names []string) (map[string]bool, error) {
+	if b.held == nil {
+		return nil, fmt.Errorf("%s cannot hold packages", b.name)
+	}
+	out, err := runCommand(ctx, b.env, b.held[0], b.held[1:]...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.held[0], err)
+	}
+	return b.parseHeld(out, names), nil
+}
+
+// refresh updates the manager's package index.
+func (b *backend) refresh(ctx context.Context) error {
+	return b.run(ctx, b.update)
+}
+
+// available returns the candidate versions of the names pinned in versions, for managers that
+// install only exact versions.
+func (b *backend) available(ctx context.Context, names []string, versions map[string]string) (map[string][]string, error) {
+	if b.candidates == nil {
+		return nil, nil
+	}
+	var pinned []string
+	for _, name := range names {
+		if versions[name] != "" {
+			pinned = append(pinned, name)
+		}
+	}
+	if len(pinned) == 0 {
+		return nil, nil
+	}
+	args := append(append([]string(nil), b.candidates[1:]...), pinned...)
+	out, err := runCommand(ctx, b.env, b.candidates[0], args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.candidates[0], err)
+	}
+	return b.parseCandidates(out, pinned), nil
+}
+
+// add installs names, pinning those with a version in versions.
+func (b *backend) add(ctx context.Context, names []string, versions map[string]string) error {
+	argv := append([]string(nil), b.install...)
+	available, err := b.available(ctx, names, versions)
+	if err != nil {
+		return err
+	}
+	var specs []string
+	for _, name := range names {
+		version := versions[name]
+		if version == "" {
+			specs = append(specs, name)
+			continue
+		}
+		if b.pin == nil {
+			return fmt.Errorf("%s cannot install a specific version of %s", b.name, name)
+		}
+		if len(argv) == len(b.install) {
+			argv = append(argv, b.pinFlags...)
+		}
+		specs = append(specs, b.pin(name, resolvePin(version, available[name])))
+	}
+	return b.run(ctx, argv, specs...)
+}
+
+// resolvePin returns the newest of candidates the pinned version matches, so "8.5.0" installs
+// "8.5.0-1ubuntu1". Without a match the pin is kept and the manager reports it as unavailable.
+func resolvePin(want string, candidates []string) string {
+	for _, candidate := range candidates {
+		if versionMatches(candidate, want) {
+			return candidate
+		}
+	}
+	return want
+}
+
+// run executes a command changing the system, with names appended.
+func (b *backend) run(ctx context.Context, argv []string, names ...string) error {
+	argv = append(append([]string(nil), argv...), names...)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), b.env...)
+
+	streamResult, err := internalexec.RunStreamingContext(ctx, cmd)
+	if err != nil {
+		if output := internalexec.PrimaryOutput(streamResult); output != "" {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return err
+	}
+	return nil
+}
+
+// versionMatches reports whether an installed version satisfies a pinned one. The pin matches
+// the version exactly or without its packaging revision ("8.5.0" matches "8.5.0-1ubuntu1"), or
+// as a prefix when it ends in "*". Epochs ("1:") are ignored.
+func versionMatches(installed, want string) bool {
+	if i := strings.IndexByte(installed, ':'); i > 0 && strings.Trim(installed[:i], "0123456789") == "" {
+		installed = installed[i+1:]
+	}
+	if prefix, ok := strings.CutSuffix(want, "*"); ok {
+		return strings.HasPrefix(installed, prefix)
+	}
+	return installed == want || strings.HasPrefix(installed, want+"-")
+}
+
+// baseName drops an architecture qualifier such as ":amd64", which queries do not echo back.
+func baseName(name string) string {
+	if i := strings.IndexByte(name, ':'); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// pick keeps the entries of found requested in names, keyed as requested.
+func pick[V any](found map[string]V, names []string) map[string]V {
+	picked := make(map[string]V)
+	for _, name := range names {
+		if v, ok := found[name]; ok {
+			picked[name] = v
+		} else if v, ok := found[baseName(name)]; ok {
+			picked[name] = v
+		}
+	}
+	return picked
+}
+
+// lines returns the non-blank lines of out, trimmed.
+func lines(out string) []string {
+	var all []string
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			all = append(all, line)
+		}
+	}
+	return all
+}
+
+// versionAfter returns what follows "name-" in s when it starts with a digit, as in apk's
+// "curl-8.5.0-r0", where names can contain dashes too.
+func versionAfter(s, name string) (string, bool) {
+	rest, ok := strings.CutPrefix(s, name+"-")
+	if !ok || rest == "" || rest[0] < '0' || rest[0] > '9' {
+		return "", false
+	}
+	return rest, true
+}
+
+// parseDpkgStatus keeps the packages dpkg reports as fully installed; removed packages whose
+// configuration files remain are listed too, with another status.
+func parseDpkgStatus(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) == 3 && strings.HasSuffix(strings.TrimSpace(fields[1]), " installed") {
+			found[fields[0]] = strings.TrimSpace(fields[2])
+		}
+	}
+	return pick(found, names)
+}
+
+// parseTabbed reads "name<TAB>version" lines, skipping rpm's "package foo is not installed".
+func parseTabbed(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range lines(out) {
+		if name, version, ok := strings.Cut(line, "\t"); ok {
+			found[name] = strings.TrimSpace(version)
+		}
+	}
+	return pick(found, names)
+}
+
+// parseNameVersion reads "name version..." lines, keeping the last version listed.
+func parseNameVersion(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			found[fields[0]] = fields[len(fields)-1]
+		}
+	}
+	return pick(found, names)
+}
+
+// parseApkList reads "curl-8.5.0-r0 x86_64 {curl} (MIT) [installed]" lines.
+func parseApkList(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range lines(out) {
+		if !strings.Contains(line, "[installed]") {
+			continue
+		}
+		field := strings.Fields(line)[0]
+		for _, name := range names {
+			if version, ok := versionAfter(field, name); ok {
+				found[name] = version
+			}
+		}
+	}
+	return found
+}
+
+// parseAptUpgradable reads "curl/jammy-updates 8.5.0-1 amd64 [upgradable from: 7.81.0-1]".
+func parseAptUpgradable(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range lines(out) {
+		fields := strings.Fields(line)
+		if name, _, ok := strings.Cut(fields[0], "/"); ok && len(fields) >= 2 {
+			found[name] = fields[1]
+		}
+	}
+	return pick(found, names)
+}
+
+// parseMadison reads apt-cache madison's "curl | 8.5.0-2ubuntu10.6 | <archive> Packages" lines,
+// which list each package's versions newest first. Source package lines are skipped.
+func parseMadison(out string, names []string) map[string][]string {
+	found := make(map[string][]string)
+	for _, line := range lines(out) {
+		fields := strings.Split(line, "|")
+		if len(fields) != 3 || strings.HasSuffix(strings.TrimSpace(fields[2]), "Sources") {
+			continue
+		}
+		name := strings.TrimSpace(fields[0])
+		found[name] = append(found[name], strings.TrimSpace(fields[1]))
+	}
+	return pick(found, names)
+}
+
+// parseCheckUpdate reads dnf and yum's "curl.x86_64  8.5.0-1.fc39  updates" lines.
+func parseCheckUpdate(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndexByte(name, '.'); i > 0 {
+			name = name[:i]
+		}
+		found[name] = fields[1]
+	}
+	return pick(found, names)
+}
+
+// parseArrow reads pacman's "curl 7.81.0-1 -> 8.5.0-1" lines.
+func parseArrow(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) == 4 && fields[2] == "->" {
+			found[fields[0]] = fields[3]
+		}
+	}
+	return pick(found, names)
+}
+
+// parseApkVersion reads "curl-7.81.0-r0  < 8.5.0-r0" lines.
+func parseApkVersion(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[1] != "<" {
+			continue
+		}
+		for _, name := range names {
+			if _, ok := versionAfter(fields[0], name); ok {
+				found[name] = fields[2]
+			}
+		}
+	}
+	return found
+}
+
+// parseZypperUpdates reads the "v | repo | curl | 7.81 | 8.5 | x86_64" rows of list-updates.
+func parseZypperUpdates(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range lines(out) {
+		if fields := strings.Split(line, "|"); len(fields) >= 5 {
+			found[strings.TrimSpace(fields[2])] = strings.TrimSpace(fields[4])
+		}
+	}
+	return pick(found, names)
+}
+
+// parseBrewOutdated reads "curl (8.4.0) < 8.5.0" lines.
+func parseBrewOutdated(out string, names []string) map[string]string {
+	found := make(map[string]string)
+	for _, line := range lines(out) {
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			found[fields[0]] = fields[len(fields)-1]
+		}
+	}
+	return pick(found, names)
+}
+
+// parseNames reads one name per line.
+func parseNames(out string, names []string) map[string]bool {
+	found := make(map[string]bool)
+	for _, line := range lines(out) {
+		found[strings.Fields(line)[0]] = true
+	}
+	return pick(found, names)
+}
+
+// parseVersionedNames reads versionlock entries such as "curl-0:7.81.0-1.fc39.*".
+func parseVersionedNames(out string, names []string) map[string]bool {
+	found := make(map[string]bool)
+	for _, line := range lines(out) {
+		for _, name := range names {
+			if _, ok := versionAfter(line, name); ok {
+				found[name] = true
+			}
+		}
+	}
+	return found
+}
+
+// parseZypperLocks reads the "1 | curl | package | (any)" rows of zypper locks.
+func parseZypperLocks(out string, names []string) map[string]bool {
+	found := make(map[string]bool)
+	for _, line := range lines(out) {
+		if fields := strings.Split(line, "|"); len(fields) >= 2 {
+			found[strings.TrimSpace(fields[1])] = true
+		}
+	}
+	return pick(found, names)
+}
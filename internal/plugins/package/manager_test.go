@@ -0,0 +1,259 @@
+package packageplugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+// queryBodies answer installed-state queries like the real tools do, for the names in $INSTALLED.
+var queryBodies = map[string]string{
+	"dpkg-query": `rc=0
+for a in "$@"; do
+  case "$a" in -*|\$*) continue ;; esac
+  case " $INSTALLED " in
+    *" $a "*) printf '%s\tinstall ok installed\t1.0\n' "$a" ;;
+    *) echo "dpkg-query: no packages found matching $a" >&2; rc=1 ;;
+  esac
+done
+exit $rc`,
+	"rpm": `rc=0
+for a in "$@"; do
+  case "$a" in -*|%*) continue ;; esac
+  case " $INSTALLED " in
+    *" $a "*) printf '%s\t1.0-1\n' "$a" ;;
+    *) echo "package $a is not installed"; rc=$((rc+1)) ;;
+  esac
+done
+exit $rc`,
+	"pacman-query": `rc=0
+for a in "$@"; do
+  case "$a" in -*) continue ;; esac
+  case " $INSTALLED " in
+    *" $a "*) echo "$a 1.0-1" ;;
+    *) echo "error: package '$a' was not found" >&2; rc=1 ;;
+  esac
+done
+exit $rc`,
+	"apk-query": `for a in "$@"; do
+  case "$a" in -*|list) continue ;; esac
+  case " $INSTALLED " in
+    *" $a "*) echo "$a-1.0-r0 x86_64 {$a} (MIT) [installed]" ;;
+  esac
+done`,
+	"brew-query": `for a in $INSTALLED; do echo "$a 1.0"; done`,
+}
+
+// stubPath puts executables named after scripts' keys first and alone on PATH. Each logs its
+// name and arguments to the returned file before running its body; calls are separated by a
+// record separator since query formats contain newlines.
+func stubPath(t *testing.T, installed string, scripts map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "calls.log")
+	for name, body := range scripts {
+		script := "#!/bin/sh\nprintf '%s %s\\036' \"${0##*/}\" \"$*\" >> " + logPath + "\n" + body + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("INSTALLED", installed)
+	return logPath
+}
+
+func readCalls(t *testing.T, logPath string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\x1e"), "\x1e")
+}
+
+func TestPackagePlugin_Backends(t *testing.T) {
+	tests := []struct {
+		manager string
+		scripts map[string]string
+		query   string
+		update  string
+		install string
+	}{
+		{
+			manager: "apt",
+			scripts: map[string]string{"apt-get": "", "dpkg-query": queryBodies["dpkg-query"]},
+			query:   "dpkg-query -W -f ${Package}\t${Status}\t${Version}\n curl jq ripgrep",
+			update:  "apt-get update",
+			install: "apt-get install -y jq ripgrep",
+		},
+		{
+			manager: "dnf",
+			scripts: map[string]string{"dnf": "", "rpm": queryBodies["rpm"]},
+			query:   "rpm -q --qf %{NAME}\t%{VERSION}-%{RELEASE}\n curl jq ripgrep",
+			update:  "dnf makecache -y",
+			install: "dnf install -y jq ripgrep",
+		},
+		{
+			manager: "yum",
+			scripts: map[string]string{"yum": "", "rpm": queryBodies["rpm"]},
+			query:   "rpm -q --qf %{NAME}\t%{VERSION}-%{RELEASE}\n curl jq ripgrep",
+			update:  "yum makecache -y",
+			install: "yum install -y jq ripgrep",
+		},
+		{
+			manager: "pacman",
+			scripts: map[string]string{"pacman": `case "$1" in -Q) ` + queryBodies["pacman-query"] + ` ;; esac`},
+			query:   "pacman -Q curl jq ripgrep",
+			update:  "pacman -Sy --noconfirm",
+			install: "pacman -S --noconfirm --needed jq ripgrep",
+		},
+		{
+			manager: "apk",
+			scripts: map[string]string{"apk": `case "$1" in list) ` + queryBodies["apk-query"] + ` ;; esac`},
+			query:   "apk list --installed curl jq ripgrep",
+			update:  "apk update",
+			install: "apk add jq ripgrep",
+		},
+		{
+			manager: "zypper",
+			scripts: map[string]string{"zypper": "", "rpm": queryBodies["rpm"]},
+			query:   "rpm -q --qf %{NAME}\t%{VERSION}-%{RELEASE}\n curl jq ripgrep",
+			update:  "zypper --non-interactive refresh",
+			install: "zypper --non-interactive install jq ripgrep",
+		},
+		{
+			manager: "brew",
+			scripts: map[string]string{"brew": `case "$1" in list) ` + queryBodies["brew-query"] + ` ;; esac`},
+			query:   "brew list --versions",
+			update:  "brew update",
+			install: "brew install jq ripgrep",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.manager, func(t *testing.T) {
+			for _, explicit := range []bool{false, true} {
+				logPath := stubPath(t, "curl git", tt.scripts)
+				cfg := config.PackageStep{Packages: []string{"curl", "jq", "ripgrep"}, Update: true}
+				if explicit {
+					cfg.Manager = tt.manager
+				}
+				step := newPackageStep(t, "tools", cfg)
+				p := New()
+
+				eval, err := p.Evaluate(context.Background(), step)
+				require.NoError(t, err)
+				require.Equal(t, model.StatusMissing, eval.CurrentState)
+				require.Equal(t, "packages not installed: jq, ripgrep", eval.Message)
+				require.Equal(t, "Would change with "+tt.manager+":\ninstall jq\ninstall ripgrep", eval.Diff)
+				// All packages are checked in a single query.
+				require.Equal(t, []string{tt.query}, readCalls(t, logPath))
+
+				result, err := p.Apply(context.Background(), eval, step)
+				require.NoError(t, err)
+				require.Equal(t, model.StatusSuccess, result.Status)
+				require.Equal(t, []string{tt.query, tt.update, tt.install}, readCalls(t, logPath))
+			}
+		})
+	}
+}
+
+func TestPackagePlugin_SatisfiedSkipsUpdate(t *testing.T) {
+	logPath := stubPath(t, "curl git", map[string]string{"apt-get": "", "dpkg-query": queryBodies["dpkg-query"]})
+	step := newPackageStep(t, "tools", config.PackageStep{Packages: []string{"curl", "git"}, Update: true})
+	p := New()
+
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+
+	result, err := p.Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSkipped, result.Status)
+	require.Len(t, readCalls(t, logPath), 1)
+}
+
+func TestPackagePlugin_DetectsManagerInPreferenceOrder(t *testing.T) {
+	stubPath(t, "", map[string]string{"brew": "", "pacman": "", "zypper": ""})
+	b, err := resolveBackend("")
+	require.NoError(t, err)
+	require.Equal(t, "pacman", b.name)
+
+	stubPath(t, "", map[string]string{})
+	_, err = resolveBackend("")
+	require.ErrorContains(t, err, "no supported package manager")
+
+	step := newPackageStep(t, "tools", config.PackageStep{Packages: []string{"curl"}})
+	_, err = New().Evaluate(context.Background(), step)
+	var stateErr *plugin.StateError
+	require.ErrorAs(t, err, &stateErr)
+}
+
+func TestPackagePlugin_FailuresAreReported(t *testing.T) {
+	stubPath(t, "", map[string]string{
+		"apt-get":    `case "$1" in update) echo "could not resolve mirror" >&2; exit 100 ;; esac`,
+		"dpkg-query": queryBodies["dpkg-query"],
+	})
+	step := newPackageStep(t, "tools", config.PackageStep{Packages: []string{"jq"}, Update: true})
+	p := New()
+
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+
+	result, err := p.Apply(context.Background(), eval, step)
+	require.Error(t, err)
+	require.Equal(t, model.StatusFailed, result.Status)
+	require.Contains(t, result.Message, "failed to update apt package index")
+	require.Contains(t, result.Message, "could not resolve mirror")
+
+	stubPath(t, "", map[string]string{"brew": "echo 'brew: broken' >&2; exit 1"})
+	step = newPackageStep(t, "tools", config.PackageStep{Packages: []string{"jq"}, Manager: "brew"})
+	_, err = p.Evaluate(context.Background(), step)
+	require.ErrorContains(t, err, "brew: broken")
+}
+
+func TestParseInstalled(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"curl", "git", "libc6:amd64", "vim", "zsh"}
+	require.Equal(t,
+		map[string]string{"curl": "7.81.0-1ubuntu1", "libc6:amd64": "2.35-0ubuntu3"},
+		parseDpkgStatus("curl\tinstall ok installed\t7.81.0-1ubuntu1\nvim\tdeinstall ok config-files\t2:8.2\nlibc6\tinstall ok installed\t2.35-0ubuntu3\n", names))
+	require.Equal(t, map[string]string{"git": "2.43.0-1.fc39"}, parseTabbed("git\t2.43.0-1.fc39\npackage jq is not installed\n", names))
+	require.Equal(t, map[string]string{"git": "2.45.0-1", "zsh": "5.9-5"}, parseNameVersion("git 2.45.0-1\nzsh 5.9-5\nbat 0.24.0\n", names))
+	require.Equal(t, map[string]string{"curl": "8.5.0-r0"}, parseApkList("curl-8.5.0-r0 x86_64 {curl} (MIT) [installed]\ncurl-dev-8.5.0-r0 x86_64 {curl} (MIT) [installed]\n", names))
+	require.Equal(t, "libc6", baseName("libc6:amd64"))
+}
+
+func TestParseOutdatedAndHeld(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"curl", "git"}
+	require.Equal(t, map[string]string{"curl": "8.5.0-1"}, parseAptUpgradable("Listing...\ncurl/jammy-updates 8.5.0-1 amd64 [upgradable from: 7.81.0-1]\n", names))
+	require.Equal(t, map[string]string{"git": "2.45.0-1.fc39"}, parseCheckUpdate("git.x86_64  2.45.0-1.fc39  updates\n\nObsoleting Packages\n", names))
+	require.Equal(t, map[string]string{"curl": "8.5.0-1"}, parseArrow("curl 7.81.0-1 -> 8.5.0-1\n", names))
+	require.Equal(t, map[string]string{"curl": "8.5.0-r0"}, parseApkVersion("Installed:  Available:\ncurl-7.81.0-r0  < 8.5.0-r0\n", names))
+	require.Equal(t, map[string]string{"curl": "8.5.0"}, parseZypperUpdates("S | Repository | Name | Current Version | Available Version | Arch\n--+---+---\nv | Main | curl | 7.81.0 | 8.5.0 | x86_64\n", names))
+	require.Equal(t, map[string]string{"git": "2.45.0"}, parseBrewOutdated("git (2.44.0) < 2.45.0\n", names))
+
+	require.Equal(t, map[string]bool{"curl": true}, parseNames("curl\nvim\n", names))
+	require.Equal(t, map[string]bool{"git": true}, parseVersionedNames("git-0:2.43.0-1.fc39.*\ngit-lfs-0:3.4.0-1.*\n", names))
+	require.Equal(t, map[string]bool{"curl": true}, parseZypperLocks("# | Name | Type | Repository\n--+------+------+-----------\n1 | curl | package | (any)\n", names))
+}
+
+func TestVersionMatches(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, versionMatches("8.5.0", "8.5.0"))
+	require.True(t, versionMatches("8.5.0-1ubuntu1", "8.5.0"))
+	require.True(t, versionMatches("1:8.5.0-1", "8.5.0"))
+	require.True(t, versionMatches("8.5.2-1", "8.5*"))
+	require.False(t, versionMatches("8.50.0", "8.5"))
+	require.False(t, versionMatches("7.81.0-1", "8.5*"))
+}
@@ -4,14 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/alexisbeaulieu97/streamy/internal/config"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
-	"github.com/alexisbeaulieu97/streamy/internal/plugins/internalexec"
 	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
 )
 
@@ -36,7 +33,14 @@ func (p *packagePlugin) PluginMetadata() plugin.PluginMetadata {
 		APIVersion:   "1.x",
 		Dependencies: []plugin.Dependency{},
 		Stateful:     false,
-		Description:  "Manages system packages using apt package manager.",
+		Description:  "Manages system packages with apt, dnf, yum, pacman, apk, zypper or Homebrew.",
+		// Package queries serialise on the package database; evaluating in parallel only adds contention.
+		MaxConcurrency: 1,
+		// Package managers lock their database while installing, so two installs running at once
+		// make one of them fail.
+		Resources: []string{"package-manager"},
+		// Removing a package would not restore the dependencies or configuration it replaced.
+		Irreversible: true,
 	}
 }
 
@@ -46,9 +50,21 @@ func (p *packagePlugin) Schema() any {
 
 // Evaluation data for package operations
 type packageEvaluationData struct {
-	InstalledPackages []string
-	MissingPackages   []string
-	PackageStatus     map[string]bool // true = installed, false = missing
+	Manager string
+	// Versions holds the installed version of each installed package.
+	Versions map[string]string
+	// Install lists packages to install: missing ones, and pinned ones at another version.
+	Install []string
+	Upgrade []string
+	Remove  []string
+	Hold    []string
+	Unhold  []string
+	// Changes describes each planned change, one per line, for the diff.
+	Changes []string
+}
+
+func (d *packageEvaluationData) empty() bool {
+	return len(d.Install)+len(d.Upgrade)+len(d.Remove)+len(d.Hold)+len(d.Unhold) == 0
 }
 
 func (p *packagePlugin) Evaluate(ctx context.Context, step *config.Step) (*model.EvaluationResult, error) {
@@ -61,59 +77,121 @@ func (p *packagePlugin) Evaluate(ctx context.Context, step *config.Step) (*model
 		return nil, plugin.NewStateError(step.ID, fmt.Errorf("context cancelled: %w", err))
 	}
 
+	manager, err := resolveBackend(pkgCfg.Manager)
+	if err != nil {
+		return nil, plugin.NewStateError(step.ID, err)
+	}
+
 	// Check package status (read-only operation)
-	var installedPackages []string
-	var missingPackages []string
-	packageStatus := make(map[string]bool)
-
-	for _, name := range pkgCfg.Packages {
-		if err := runCommand(ctx, "dpkg-query", "-W", name); err != nil {
-			var exitErr *exec.ExitError
-			if errors.As(err, &exitErr) {
-				// Package is not installed
-				missingPackages = append(missingPackages, name)
-				packageStatus[name] = false
-			} else {
-				return nil, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to query package %s: %w", name, err))
+	versions, err := manager.installed(ctx, pkgCfg.Packages)
+	if err != nil {
+		return nil, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to query packages with %s: %w", manager.name, err))
+	}
+
+	data := &packageEvaluationData{Manager: manager.name, Versions: versions}
+	var missing, drifted []string
+
+	if pkgCfg.State == config.PackageStateAbsent {
+		for _, name := range pkgCfg.Packages {
+			if version, ok := versions[name]; ok {
+				data.Remove = append(data.Remove, name)
+				data.Changes = append(data.Changes, fmt.Sprintf("remove %s %s", name, version))
+				drifted = append(drifted, name)
+			}
+		}
+	} else {
+		for _, name := range pkgCfg.Packages {
+			version, ok := versions[name]
+			want := pkgCfg.Versions[name]
+			switch {
+			case !ok:
+				data.Install = append(data.Install, name)
+				data.Changes = append(data.Changes, strings.TrimSpace("install "+name+" "+want))
+				missing = append(missing, name)
+			case want != "" && !versionMatches(version, want):
+				data.Install = append(data.Install, name)
+				data.Changes = append(data.Changes, fmt.Sprintf("%s %s -> %s", name, version, want))
+				drifted = append(drifted, name)
+			}
+		}
+
+		if pkgCfg.State == config.PackageStateLatest && len(versions) > 0 {
+			newer, err := manager.newer(ctx, pkgCfg.Packages)
+			if err != nil {
+				return nil, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to check %s for upgrades: %w", manager.name, err))
+			}
+			for _, name := range pkgCfg.Packages {
+				if version, ok := newer[name]; ok && versions[name] != "" {
+					data.Upgrade = append(data.Upgrade, name)
+					data.Changes = append(data.Changes, fmt.Sprintf("%s %s -> %s", name, versions[name], version))
+					drifted = append(drifted, name)
+				}
 			}
-		} else {
-			// Package is installed
-			installedPackages = append(installedPackages, name)
-			packageStatus[name] = true
 		}
-	}
 
-	// Store evaluation data to avoid recomputation
-	internalData := &packageEvaluationData{
-		InstalledPackages: installedPackages,
-		MissingPackages:   missingPackages,
-		PackageStatus:     packageStatus,
+		if pkgCfg.Hold != nil && len(pkgCfg.Packages) > 0 {
+			held, err := manager.holds(ctx, pkgCfg.Packages)
+			if err != nil {
+				return nil, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to query held packages: %w", err))
+			}
+			for _, name := range pkgCfg.Packages {
+				switch {
+				case *pkgCfg.Hold && !held[name]:
+					data.Hold = append(data.Hold, name)
+					data.Changes = append(data.Changes, "hold "+name)
+				case !*pkgCfg.Hold && held[name]:
+					data.Unhold = append(data.Unhold, name)
+					data.Changes = append(data.Changes, "unhold "+name)
+				default:
+					continue
+				}
+				// A missing package is only held once installed; that is part of installing it.
+				if _, ok := versions[name]; ok {
+					drifted = append(drifted, name)
+				}
+			}
+		}
 	}
 
 	// Determine current state
-	if len(missingPackages) == 0 {
+	if data.empty() {
+		message := fmt.Sprintf("all packages installed: %s", strings.Join(pkgCfg.Packages, ", "))
+		if pkgCfg.State == config.PackageStateAbsent {
+			message = fmt.Sprintf("no packages installed: %s", strings.Join(pkgCfg.Packages, ", "))
+		}
 		return &model.EvaluationResult{
 			StepID:         step.ID,
 			CurrentState:   model.StatusSatisfied,
 			RequiresAction: false,
-			Message:        fmt.Sprintf("all packages installed: %s", strings.Join(pkgCfg.Packages, ", ")),
-			InternalData:   internalData,
+			Message:        message,
+			InternalData:   data,
 		}, nil
 	}
 
-	// Some packages are missing
+	// Installed packages in the wrong state count as drift; packages merely missing do not.
+	state := model.StatusMissing
+	message := fmt.Sprintf("packages not installed: %s", strings.Join(missing, ", "))
+	if len(drifted) > 0 {
+		state = model.StatusDrifted
+		message = fmt.Sprintf("packages drifted: %s", strings.Join(uniqueNames(drifted), ", "))
+		if len(missing) > 0 {
+			message += fmt.Sprintf("; not installed: %s", strings.Join(missing, ", "))
+		}
+	}
+
 	return &model.EvaluationResult{
 		StepID:         step.ID,
-		CurrentState:   model.StatusMissing,
+		CurrentState:   state,
 		RequiresAction: true,
-		Message:        fmt.Sprintf("packages not installed: %s", strings.Join(missingPackages, ", ")),
-		Diff:           fmt.Sprintf("Would install: %s", strings.Join(missingPackages, ", ")),
-		InternalData:   internalData,
+		Message:        message,
+		Diff:           fmt.Sprintf("Would change with %s:\n%s", manager.name, strings.Join(data.Changes, "\n")),
+		InternalData:   data,
 	}, nil
 }
 
 func (p *packagePlugin) Apply(ctx context.Context, evalResult *model.EvaluationResult, step *config.Step) (*model.StepResult, error) {
-	if _, err := loadPackageConfig(step); err != nil {
+	pkgCfg, err := loadPackageConfig(step)
+	if err != nil {
 		return nil, plugin.NewValidationError(step.ID, err)
 	}
 
@@ -126,7 +204,6 @@ func (p *packagePlugin) Apply(ctx context.Context, evalResult *model.EvaluationR
 	}
 	if data == nil {
 		// Fallback to re-evaluating
-		var err error
 		evalResult, err = p.Evaluate(ctx, step)
 		if err != nil {
 			return nil, convertError(step.ID, err)
@@ -152,26 +229,78 @@ func (p *packagePlugin) Apply(ctx context.Context, evalResult *model.EvaluationR
 		}, nil
 	}
 
-	// Install missing packages
-	if len(data.MissingPackages) > 0 {
-		args := append([]string{"install", "-y"}, data.MissingPackages...)
-		if err := runCommand(ctx, "apt-get", args...); err != nil {
-			return &model.StepResult{
-				StepID:  step.ID,
-				Status:  model.StatusFailed,
-				Message: fmt.Sprintf("failed to install packages: %v", err),
-				Error:   err,
-			}, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to install packages: %w", err))
+	manager, err := resolveBackend(data.Manager)
+	if err != nil {
+		return nil, plugin.NewStateError(step.ID, err)
+	}
+
+	if pkgCfg.Update && len(data.Install)+len(data.Upgrade) > 0 {
+		if err := manager.refresh(ctx); err != nil {
+			return failedApply(step.ID, fmt.Sprintf("update %s package index", manager.name), err)
 		}
 	}
 
+	// Holds are released first so the packages they cover can change, and placed last so they
+	// cover the versions just installed.
+	var done []string
+	actions := []struct {
+		verb  string
+		names []string
+		run   func([]string) error
+	}{
+		{"unhold", data.Unhold, func(names []string) error { return manager.run(ctx, manager.unhold, names...) }},
+		{"remove", data.Remove, func(names []string) error { return manager.run(ctx, manager.remove, names...) }},
+		{"install", data.Install, func(names []string) error { return manager.add(ctx, names, pkgCfg.Versions) }},
+		{"upgrade", data.Upgrade, func(names []string) error { return manager.run(ctx, manager.upgrade, names...) }},
+		{"hold", data.Hold, func(names []string) error { return manager.run(ctx, manager.hold, names...) }},
+	}
+	for _, action := range actions {
+		if len(action.names) == 0 {
+			continue
+		}
+		if err := action.run(action.names); err != nil {
+			return failedApply(step.ID, action.verb+" packages", err)
+		}
+		done = append(done, fmt.Sprintf("%s %s", pastTense[action.verb], strings.Join(action.names, ", ")))
+	}
+
 	return &model.StepResult{
 		StepID:  step.ID,
 		Status:  model.StatusSuccess,
-		Message: fmt.Sprintf("installed packages: %s", strings.Join(data.MissingPackages, ", ")),
+		Message: fmt.Sprintf("%s: %s", manager.name, strings.Join(done, "; ")),
 	}, nil
 }
 
+var pastTense = map[string]string{
+	"unhold":  "unheld",
+	"remove":  "removed",
+	"install": "installed",
+	"upgrade": "upgraded",
+	"hold":    "held",
+}
+
+func failedApply(stepID, action string, err error) (*model.StepResult, error) {
+	return &model.StepResult{
+		StepID:  stepID,
+		Status:  model.StatusFailed,
+		Message: fmt.Sprintf("failed to %s: %v", action, err),
+		Error:   err,
+	}, plugin.NewExecutionError(stepID, fmt.Errorf("failed to %s: %w", action, err))
+}
+
+// uniqueNames drops repeated names, keeping the first occurrence.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	out := names[:0:0]
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
 // Helper functions
 
 func convertError(stepID string, err error) error {
@@ -190,22 +319,6 @@ func convertError(stepID string, err error) error {
 	return plugin.NewExecutionError(stepID, err)
 }
 
-func runCommand(ctx context.Context, name string, args ...string) error {
-	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Env = os.Environ()
-
-	streamResult, err := internalexec.RunStreaming(cmd)
-	if err != nil {
-		combinedOutput := internalexec.PrimaryOutput(streamResult)
-		if combinedOutput != "" {
-			return fmt.Errorf("%w: %s", err, combinedOutput)
-		}
-		return err
-	}
-
-	return nil
-}
-
 func loadPackageConfig(step *config.Step) (*config.PackageStep, error) {
 	if step == nil {
 		return nil, fmt.Errorf("step is nil")
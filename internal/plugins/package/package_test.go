@@ -282,3 +282,143 @@ func TestPackageConvertError(t *testing.T) {
 		require.Equal(t, "pkg3", pluginErr.StepID())
 	})
 }
+
+// stubQueries answers package queries with canned output, keyed by command name.
+func stubQueries(t *testing.T, outputs map[string]string) {
+	t.Helper()
+
+	previous := runCommand
+	t.Cleanup(func() { runCommand = previous })
+	runCommand = func(_ context.Context, _ []string, name string, _ ...string) (string, error) {
+		return outputs[name], nil
+	}
+}
+
+func TestPackagePlugin_VersionDrift(t *testing.T) {
+	stubQueries(t, map[string]string{
+		"dpkg-query": "curl\tinstall ok installed\t7.81\njq\tinstall ok installed\t1.7.1-3\n",
+	})
+	logPath := stubPath(t, "", map[string]string{"apt-get": ""})
+	step := newPackageStep(t, "tools", config.PackageStep{
+		Packages: []string{"curl", "jq", "ripgrep"},
+		Manager:  "apt",
+		Versions: map[string]string{"curl": "8.5", "jq": "1.7.1", "ripgrep": "14.1*"},
+	})
+	p := New()
+
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Equal(t, "packages drifted: curl; not installed: ripgrep", eval.Message)
+	require.Equal(t, "Would change with apt:\ncurl 7.81 -> 8.5\ninstall ripgrep 14.1*", eval.Diff)
+
+	result, err := p.Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, "apt: installed curl, ripgrep", result.Message)
+	require.Equal(t, []string{"apt-get install -y --allow-downgrades curl=8.5 ripgrep=14.1*"}, readCalls(t, logPath))
+}
+
+func TestPackagePlugin_PinInstallsMatchingAptVersion(t *testing.T) {
+	stubQueries(t, map[string]string{
+		"dpkg-query": "curl\tinstall ok installed\t8.4.0-1\n",
+		"apt-cache": "      curl | 8.5.1-1 | http://archive.ubuntu.com/ubuntu noble-proposed/main amd64 Packages\n" +
+			"      curl | 8.5.0-2ubuntu10.6 | http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages\n" +
+			"      curl | 8.5.0-2ubuntu10 | http://archive.ubuntu.com/ubuntu noble/main amd64 Packages\n" +
+			"      curl | 8.5.0-2ubuntu10.6 | http://archive.ubuntu.com/ubuntu noble-updates/main Sources\n",
+	})
+	logPath := stubPath(t, "", map[string]string{"apt-get": ""})
+	step := newPackageStep(t, "tools", config.PackageStep{
+		Packages: []string{"curl"},
+		Manager:  "apt",
+		Versions: map[string]string{"curl": "8.5.0"},
+	})
+	p := New()
+
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, "Would change with apt:\ncurl 8.4.0-1 -> 8.5.0", eval.Diff)
+
+	// apt only installs exact versions, so the pin becomes the newest matching candidate.
+	_, err = p.Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, []string{"apt-get install -y --allow-downgrades curl=8.5.0-2ubuntu10.6"}, readCalls(t, logPath))
+
+	stubQueries(t, map[string]string{"dpkg-query": "curl\tinstall ok installed\t8.5.0-2ubuntu10.6\n"})
+	eval, err = p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+}
+
+func TestPackagePlugin_Absent(t *testing.T) {
+	stubQueries(t, map[string]string{"dpkg-query": "curl\tinstall ok installed\t8.5.0-1\n"})
+	logPath := stubPath(t, "", map[string]string{"apt-get": ""})
+	step := newPackageStep(t, "tools", config.PackageStep{
+		Packages: []string{"curl", "telnet"},
+		Manager:  "apt",
+		State:    config.PackageStateAbsent,
+		Update:   true,
+	})
+	p := New()
+
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Equal(t, "Would change with apt:\nremove curl 8.5.0-1", eval.Diff)
+
+	result, err := p.Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, "apt: removed curl", result.Message)
+	// Removing needs no fresh package index.
+	require.Equal(t, []string{"apt-get remove -y curl"}, readCalls(t, logPath))
+
+	stubQueries(t, map[string]string{})
+	eval, err = p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+	require.Equal(t, "no packages installed: curl, telnet", eval.Message)
+}
+
+func TestPackagePlugin_LatestAndHold(t *testing.T) {
+	hold := true
+	stubQueries(t, map[string]string{
+		"dpkg-query": "curl\tinstall ok installed\t7.81.0-1\ngit\tinstall ok installed\t1:2.43.0-1\n",
+		"apt":        "Listing...\ncurl/jammy-updates 8.5.0-1 amd64 [upgradable from: 7.81.0-1]\n",
+		"apt-mark":   "git\n",
+	})
+	logPath := stubPath(t, "", map[string]string{"apt-get": "", "apt-mark": ""})
+	step := newPackageStep(t, "tools", config.PackageStep{
+		Packages: []string{"curl", "git"},
+		Manager:  "apt",
+		State:    config.PackageStateLatest,
+		Hold:     &hold,
+	})
+	p := New()
+
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Equal(t, "Would change with apt:\ncurl 7.81.0-1 -> 8.5.0-1\nhold curl", eval.Diff)
+
+	result, err := p.Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, "apt: upgraded curl; held curl", result.Message)
+	require.Equal(t, []string{"apt-get install -y --only-upgrade curl", "apt-mark hold curl"}, readCalls(t, logPath))
+}
+
+func TestPackagePlugin_UnsupportedPinAndHold(t *testing.T) {
+	hold := false
+	stubQueries(t, map[string]string{})
+	step := newPackageStep(t, "tools", config.PackageStep{Packages: []string{"git"}, Manager: "pacman", Hold: &hold})
+
+	_, err := New().Evaluate(context.Background(), step)
+	require.ErrorContains(t, err, "pacman cannot hold packages")
+
+	stubPath(t, "", map[string]string{"pacman": ""})
+	step = newPackageStep(t, "tools", config.PackageStep{Packages: []string{"git"}, Manager: "pacman", Versions: map[string]string{"git": "2.45"}})
+	eval, err := New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+
+	result, err := New().Apply(context.Background(), eval, step)
+	require.Error(t, err)
+	require.Contains(t, result.Message, "pacman cannot install a specific version of git")
+}
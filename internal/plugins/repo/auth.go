@@ -0,0 +1,117 @@
+package repoplugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+)
+
+// authMethod builds the credentials for cloning and fetching from the step's auth block. It
+// returns nil without one, leaving go-git to its defaults: the ssh agent and known_hosts for
+// ssh URLs, no credentials for http ones.
+func authMethod(cfg *config.RepoStep) (transport.AuthMethod, error) {
+	auth := cfg.Auth
+	if auth == nil {
+		return nil, nil
+	}
+
+	user := auth.Username
+	if user == "" {
+		if endpoint, err := transport.NewEndpoint(cfg.URL); err == nil {
+			user = endpoint.User
+		}
+	}
+	if user == "" {
+		user = "git"
+	}
+
+	if auth.TokenEnv != "" {
+		token, err := secretEnv(auth.TokenEnv)
+		if err != nil {
+			return nil, err
+		}
+		return &githttp.BasicAuth{Username: user, Password: token}, nil
+	}
+
+	hostKeys, err := hostKeyCallback(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth.SSHKey != "" {
+		var passphrase string
+		if auth.PassphraseEnv != "" {
+			if passphrase, err = secretEnv(auth.PassphraseEnv); err != nil {
+				return nil, err
+			}
+		}
+		keyPath, err := expandHome(auth.SSHKey)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := gitssh.NewPublicKeysFromFile(user, keyPath, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("load ssh key %s: %w", auth.SSHKey, err)
+		}
+		keys.HostKeyCallback = hostKeys
+		return keys, nil
+	}
+
+	if hostKeys == nil {
+		return nil, nil
+	}
+	// Host key settings without a key still authenticate through the agent.
+	agent, err := gitssh.NewSSHAgentAuth(user)
+	if err != nil {
+		return nil, fmt.Errorf("connect to ssh agent: %w", err)
+	}
+	agent.HostKeyCallback = hostKeys
+	return agent, nil
+}
+
+// hostKeyCallback returns how ssh server keys are checked, or nil for go-git's default lookup.
+func hostKeyCallback(auth *config.RepoAuth) (ssh.HostKeyCallback, error) {
+	switch {
+	case auth.InsecureIgnoreHostKey:
+		return ssh.InsecureIgnoreHostKey(), nil
+	case auth.KnownHosts != "":
+		path, err := expandHome(auth.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+		callback, err := gitssh.NewKnownHostsCallback(path)
+		if err != nil {
+			return nil, fmt.Errorf("load known_hosts %s: %w", auth.KnownHosts, err)
+		}
+		return callback, nil
+	default:
+		return nil, nil
+	}
+}
+
+func secretEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path[1:], "/")), nil
+}
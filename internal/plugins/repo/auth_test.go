@@ -0,0 +1,100 @@
+package repoplugin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+func writeSSHKey(t *testing.T, passphrase string) string {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(key, "")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte(passphrase))
+	}
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+	return path
+}
+
+func TestAuthMethod_Token(t *testing.T) {
+	t.Setenv("STREAMY_TEST_TOKEN", "s3cret")
+
+	auth, err := authMethod(&config.RepoStep{URL: "https://example.com/org/repo.git", Auth: &config.RepoAuth{TokenEnv: "STREAMY_TEST_TOKEN"}})
+	require.NoError(t, err)
+	require.Equal(t, &githttp.BasicAuth{Username: "git", Password: "s3cret"}, auth)
+
+	auth, err = authMethod(&config.RepoStep{URL: "https://ci@example.com/org/repo.git", Auth: &config.RepoAuth{TokenEnv: "STREAMY_TEST_TOKEN"}})
+	require.NoError(t, err)
+	require.Equal(t, "ci", auth.(*githttp.BasicAuth).Username)
+
+	auth, err = authMethod(&config.RepoStep{URL: "https://ci@example.com/org/repo.git", Auth: &config.RepoAuth{TokenEnv: "STREAMY_TEST_TOKEN", Username: "oauth2"}})
+	require.NoError(t, err)
+	require.Equal(t, "oauth2", auth.(*githttp.BasicAuth).Username)
+
+	_, err = authMethod(&config.RepoStep{URL: "https://example.com/org/repo.git", Auth: &config.RepoAuth{TokenEnv: "STREAMY_TEST_UNSET"}})
+	require.EqualError(t, err, "environment variable STREAMY_TEST_UNSET is not set")
+}
+
+func TestAuthMethod_SSHKey(t *testing.T) {
+	t.Setenv("STREAMY_TEST_PASSPHRASE", "open sesame")
+	encrypted := writeSSHKey(t, "open sesame")
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(knownHosts, nil, 0o600))
+
+	auth, err := authMethod(&config.RepoStep{
+		URL:  "deploy@example.com:org/repo.git",
+		Auth: &config.RepoAuth{SSHKey: encrypted, PassphraseEnv: "STREAMY_TEST_PASSPHRASE", KnownHosts: knownHosts},
+	})
+	require.NoError(t, err)
+	keys, ok := auth.(*gitssh.PublicKeys)
+	require.True(t, ok)
+	require.Equal(t, "deploy", keys.User)
+	require.NotNil(t, keys.HostKeyCallback)
+
+	auth, err = authMethod(&config.RepoStep{
+		URL:  "ssh://example.com/org/repo.git",
+		Auth: &config.RepoAuth{SSHKey: writeSSHKey(t, ""), InsecureIgnoreHostKey: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "git", auth.(*gitssh.PublicKeys).User)
+	require.NotNil(t, auth.(*gitssh.PublicKeys).HostKeyCallback)
+
+	// Without the passphrase the key cannot be decrypted.
+	_, err = authMethod(&config.RepoStep{URL: "git@example.com:org/repo.git", Auth: &config.RepoAuth{SSHKey: encrypted}})
+	require.ErrorContains(t, err, "load ssh key "+encrypted)
+
+	_, err = authMethod(&config.RepoStep{URL: "git@example.com:org/repo.git", Auth: &config.RepoAuth{SSHKey: encrypted, KnownHosts: filepath.Join(t.TempDir(), "missing")}})
+	require.ErrorContains(t, err, "load known_hosts")
+}
+
+func TestRepoPlugin_EvaluateReportsMissingCredentials(t *testing.T) {
+	step := newRepoStep(t, "private", config.RepoStep{
+		URL:         "https://example.com/org/private.git",
+		Destination: filepath.Join(t.TempDir(), "private"),
+		Auth:        &config.RepoAuth{TokenEnv: "STREAMY_TEST_UNSET"},
+	})
+
+	_, err := New().Evaluate(context.Background(), step)
+	var stateErr *plugin.StateError
+	require.ErrorAs(t, err, &stateErr)
+	require.ErrorContains(t, err, "auth: environment variable STREAMY_TEST_UNSET is not set")
+}
@@ -0,0 +1,233 @@
+package repoplugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	formatconfig "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// sparseDirs normalises sparse paths the way git's cone mode keeps them: without surrounding
+// slashes, sorted, and without directories already covered by a parent.
+func sparseDirs(paths []string) []string {
+	var dirs []string
+	for _, path := range paths {
+		if dir := strings.Trim(path, "/"); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	var out []string
+	for _, dir := range dirs {
+		if n := len(out); n > 0 && (out[n-1] == dir || strings.HasPrefix(dir, out[n-1]+"/")) {
+			continue
+		}
+		out = append(out, dir)
+	}
+	return out
+}
+
+// inSparse reports whether path lies within one of dirs; everything does without a sparse checkout.
+func inSparse(path string, dirs []string) bool {
+	if len(dirs) == 0 {
+		return true
+	}
+	for _, dir := range dirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// currentSparse returns the directories a cone-mode sparse checkout of the clone at destination
+// includes, or nil when the whole tree is checked out.
+func currentSparse(destination string) ([]string, error) {
+	gitDir := filepath.Join(destination, ".git")
+	enabled := false
+	// git sparse-checkout keeps its setting in config.worktree when that is in use.
+	for _, name := range []string{"config", "config.worktree"} {
+		raw, err := os.ReadFile(filepath.Join(gitDir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cfg := formatconfig.New()
+		if err := formatconfig.NewDecoder(bytes.NewReader(raw)).Decode(cfg); err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		if value := cfg.Section("core").Option("sparseCheckout"); value != "" {
+			enabled = strings.EqualFold(value, "true")
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	patterns, err := os.ReadFile(filepath.Join(gitDir, "info", "sparse-checkout"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// In cone mode "/a/" includes a directory, and "!/a/*/" right after narrows it to its files
+	// on the way to a deeper one.
+	var listed []string
+	parents := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(patterns))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "/*" || line == "!/*/":
+		case strings.HasPrefix(line, "!/") && strings.HasSuffix(line, "/*/"):
+			parents[strings.TrimSuffix(strings.TrimPrefix(line, "!/"), "/*/")] = true
+		case strings.HasPrefix(line, "/") && strings.HasSuffix(line, "/"):
+			listed = append(listed, strings.Trim(line, "/"))
+		}
+	}
+	dirs := []string{}
+	for _, dir := range listed {
+		if !parents[dir] {
+			dirs = append(dirs, dir)
+		}
+	}
+	return sparseDirs(dirs), nil
+}
+
+// applySparse limits the clone's checkout to dirs with git sparse-checkout, which go-git cannot
+// keep up across later checkouts.
+func applySparse(ctx context.Context, repo *git.Repository, destination string, dirs []string) error {
+	// git ignores the worktree config sparse-checkout writes to unless the repository format
+	// version is set, and go-git does not set it.
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	if !cfg.Raw.Section("core").HasOption("repositoryformatversion") {
+		if err := runGit(ctx, destination, "config", "core.repositoryformatversion", "0"); err != nil {
+			return err
+		}
+	}
+	return runGit(ctx, destination, append([]string{"sparse-checkout", "set", "--cone", "--"}, dirs...)...)
+}
+
+// missingSubmodules lists the submodules of the clone at dir, at any depth, that are not checked
+// out. Submodules outside the sparse directories are not expected to be.
+func missingSubmodules(repo *git.Repository, dir string, sparse []string) ([]string, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	subs, err := wt.Submodules()
+	if err != nil {
+		return nil, fmt.Errorf("read .gitmodules: %w", err)
+	}
+
+	var missing []string
+	for _, sub := range subs {
+		path := sub.Config().Path
+		if !inSparse(path, sparse) {
+			continue
+		}
+		subDir := filepath.Join(dir, path)
+		if _, err := os.Stat(filepath.Join(subDir, ".git")); err != nil {
+			missing = append(missing, path)
+			continue
+		}
+		subRepo, err := git.PlainOpen(subDir)
+		if err != nil {
+			return nil, fmt.Errorf("open submodule %s: %w", path, err)
+		}
+		nested, err := missingSubmodules(subRepo, subDir, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, nestedPath := range nested {
+			missing = append(missing, path+"/"+nestedPath)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// updateSubmodules checks out the submodules of the clone at dir, at any depth, and returns the
+// paths it initialised. Submodules already checked out are moved to their recorded commit only
+// when moved is set, because HEAD has just changed; otherwise work inside them is left alone.
+func updateSubmodules(ctx context.Context, repo *git.Repository, dir string, sparse []string, auth transport.AuthMethod, moved bool) ([]string, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	subs, err := wt.Submodules()
+	if err != nil {
+		return nil, fmt.Errorf("read .gitmodules: %w", err)
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	var initialised []string
+	for _, sub := range subs {
+		path := sub.Config().Path
+		if !inSparse(path, sparse) {
+			continue
+		}
+		subDir := filepath.Join(dir, path)
+
+		var subRepo *git.Repository
+		update := true
+		if _, err := os.Stat(filepath.Join(subDir, ".git")); err == nil {
+			if subRepo, err = git.PlainOpen(subDir); err != nil {
+				return nil, fmt.Errorf("open submodule %s: %w", path, err)
+			}
+			update = false
+			if moved {
+				entry, err := idx.Entry(path)
+				if err != nil {
+					return nil, fmt.Errorf("submodule %s: %w", path, err)
+				}
+				head, err := subRepo.Head()
+				update = err != nil || head.Hash() != entry.Hash
+			}
+		}
+
+		if update {
+			// go-git checks out nested submodules as part of the update.
+			err := sub.UpdateContext(ctx, &git.SubmoduleUpdateOptions{
+				Init:              true,
+				RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+				Auth:              auth,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("update submodule %s: %w", path, err)
+			}
+			if subRepo == nil {
+				initialised = append(initialised, path)
+			}
+			continue
+		}
+
+		nested, err := updateSubmodules(ctx, subRepo, subDir, nil, auth, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, nestedPath := range nested {
+			initialised = append(initialised, path+"/"+nestedPath)
+		}
+	}
+	return initialised, nil
+}
@@ -0,0 +1,177 @@
+package repoplugin
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+)
+
+// addSubmodule records sub as a submodule of f at path with the git CLI, then pushes f.
+func (f *remoteFixture) addSubmodule(sub *remoteFixture, path string) {
+	f.t.Helper()
+
+	for _, args := range [][]string{
+		{"-c", "protocol.file.allow=always", "submodule", "--quiet", "add", sub.URL, path},
+		{"-c", "user.name=Streamy", "-c", "user.email=streamy@example.com", "commit", "--quiet", "-m", "add " + path},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", f.dir}, args...)...).CombinedOutput()
+		require.NoError(f.t, err, string(out))
+	}
+	f.push()
+}
+
+func TestSparseDirs(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"docs", "services/api"}, sparseDirs([]string{"services/api/", "/docs", "docs/guides", "services/api"}))
+	require.Nil(t, sparseDirs(nil))
+}
+
+func TestRepoPlugin_SparsePaths(t *testing.T) {
+	remote := newRemoteFixture(t)
+	remote.commitFiles("services", map[string]string{
+		"services/api/main.go": "api",
+		"services/web/app.js":  "web",
+		"docs/index.md":        "docs",
+	})
+
+	dest := filepath.Join(t.TempDir(), "clone")
+	step := newRepoStep(t, "mono", config.RepoStep{URL: remote.URL, Destination: dest, Update: true, SparsePaths: []string{"services/api"}})
+	eval, result, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, "Would clone: "+remote.URL+", limited to services/api", eval.Diff)
+	require.Equal(t, "cloned "+remote.URL+", limited to services/api", result.Message)
+	require.FileExists(t, filepath.Join(dest, "services/api/main.go"))
+	require.FileExists(t, filepath.Join(dest, "initial.txt"))
+	require.NoDirExists(t, filepath.Join(dest, "services/web"))
+	require.NoDirExists(t, filepath.Join(dest, "docs"))
+
+	eval, err = New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+
+	// Fast-forwarding keeps to the sparse directories.
+	remote.commitFiles("more services", map[string]string{
+		"services/api/handler.go": "api",
+		"services/web/index.html": "web",
+	})
+	_, result, err = evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Contains(t, result.Message, "fast-forwarded master")
+	require.FileExists(t, filepath.Join(dest, "services/api/handler.go"))
+	require.NoDirExists(t, filepath.Join(dest, "services/web"))
+
+	step = newRepoStep(t, "mono", config.RepoStep{URL: remote.URL, Destination: dest, Update: true, SparsePaths: []string{"services/web", "docs"}})
+	eval, result, err = evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Equal(t, "checkout covers services/api (expected docs, services/web)", eval.Message)
+	require.Equal(t, "Would limit checkout to: docs, services/web", eval.Diff)
+	require.Equal(t, "limited checkout to docs, services/web", result.Message)
+	require.FileExists(t, filepath.Join(dest, "services/web/index.html"))
+	require.FileExists(t, filepath.Join(dest, "docs/index.md"))
+	require.NoDirExists(t, filepath.Join(dest, "services/api"))
+
+	eval, err = New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+}
+
+func TestRepoPlugin_SparseCloneAtRef(t *testing.T) {
+	remote := newRemoteFixture(t)
+	release := remote.commitFiles("release", map[string]string{"api/v1.go": "v1", "web/v1.js": "v1"})
+	remote.tag("v1", release)
+	remote.commitFiles("next", map[string]string{"api/v2.go": "v2"})
+
+	dest := filepath.Join(t.TempDir(), "clone")
+	step := newRepoStep(t, "mono", config.RepoStep{URL: remote.URL, Destination: dest, Ref: "v1", SparsePaths: []string{"api"}})
+	_, result, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, "cloned "+remote.URL+" at v1, limited to api", result.Message)
+	require.Equal(t, release, headOf(t, dest).Hash())
+	require.FileExists(t, filepath.Join(dest, "api/v1.go"))
+	require.NoFileExists(t, filepath.Join(dest, "api/v2.go"))
+	require.NoDirExists(t, filepath.Join(dest, "web"))
+}
+
+func TestRepoPlugin_Submodules(t *testing.T) {
+	deep := newRemoteFixture(t)
+	lib := newRemoteFixture(t)
+	lib.addSubmodule(deep, "vendor/deep")
+	remote := newRemoteFixture(t)
+	remote.addSubmodule(lib, "lib")
+
+	dest := filepath.Join(t.TempDir(), "clone")
+	step := newRepoStep(t, "mono", config.RepoStep{URL: remote.URL, Destination: dest, Submodules: "recursive"})
+	eval, _, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, "Would clone: "+remote.URL+", with submodules", eval.Diff)
+	require.FileExists(t, filepath.Join(dest, "lib/initial.txt"))
+	require.FileExists(t, filepath.Join(dest, "lib/vendor/deep/initial.txt"))
+
+	eval, err = New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+
+	// A clone made without submodules has them all missing.
+	plain := filepath.Join(t.TempDir(), "plain")
+	_, _, err = evaluateAndApply(t, newRepoStep(t, "mono", config.RepoStep{URL: remote.URL, Destination: plain}))
+	require.NoError(t, err)
+	step = newRepoStep(t, "mono", config.RepoStep{URL: remote.URL, Destination: plain, Submodules: "recursive"})
+	eval, result, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Equal(t, "submodules not initialised: lib", eval.Message)
+	require.Equal(t, "Would initialise submodules:\n  lib", eval.Diff)
+	require.Equal(t, "initialised submodules lib", result.Message)
+	require.FileExists(t, filepath.Join(plain, "lib/vendor/deep/initial.txt"))
+
+	// Nested submodules are checked too, here after a non-recursive git submodule update.
+	partial := filepath.Join(t.TempDir(), "partial")
+	_, _, err = evaluateAndApply(t, newRepoStep(t, "mono", config.RepoStep{URL: remote.URL, Destination: partial}))
+	require.NoError(t, err)
+	out, err := exec.Command("git", "-C", partial, "-c", "protocol.file.allow=always", "submodule", "--quiet", "update", "--init").CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.FileExists(t, filepath.Join(partial, "lib/initial.txt"))
+	step = newRepoStep(t, "mono", config.RepoStep{URL: remote.URL, Destination: partial, Submodules: "recursive"})
+	eval, result, err = evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, "submodules not initialised: lib/vendor/deep", eval.Message)
+	require.Equal(t, "initialised submodules lib/vendor/deep", result.Message)
+	require.FileExists(t, filepath.Join(partial, "lib/vendor/deep/initial.txt"))
+
+	eval, err = New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+}
+
+func TestRepoPlugin_SubmodulesFollowUpdates(t *testing.T) {
+	lib := newRemoteFixture(t)
+	remote := newRemoteFixture(t)
+	remote.addSubmodule(lib, "lib")
+
+	dest := filepath.Join(t.TempDir(), "clone")
+	step := newRepoStep(t, "mono", config.RepoStep{URL: remote.URL, Destination: dest, Update: true, Submodules: "recursive"})
+	_, _, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+
+	// Move the recorded submodule commit upstream.
+	newLib := lib.commit("feature")
+	out, err := exec.Command("git", "-C", filepath.Join(remote.dir, "lib"), "-c", "protocol.file.allow=always", "pull", "--quiet", "origin", "master").CombinedOutput()
+	require.NoError(t, err, string(out))
+	out, err = exec.Command("git", "-C", remote.dir, "-c", "user.name=Streamy", "-c", "user.email=streamy@example.com", "commit", "--quiet", "-am", "bump lib").CombinedOutput()
+	require.NoError(t, err, string(out))
+	remote.push()
+
+	_, result, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Contains(t, result.Message, "fast-forwarded master")
+	require.Equal(t, newLib, headOf(t, filepath.Join(dest, "lib")).Hash())
+	require.FileExists(t, filepath.Join(dest, "lib/feature.txt"))
+}
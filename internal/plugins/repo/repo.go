@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 
 	"github.com/alexisbeaulieu97/streamy/internal/config"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
@@ -57,8 +59,11 @@ type repoEvaluationData struct {
 	Branch       string
 	Depth        int
 	CurrentHead  string
+	CurrentSHA   string
 	DesiredHead  string
 	CloneOptions *git.CloneOptions
+	// Sync compares HEAD with the pinned ref or remote tip; nil when the step pins neither.
+	Sync *syncState
 }
 
 func (p *repoPlugin) Evaluate(ctx context.Context, step *config.Step) (*model.EvaluationResult, error) {
@@ -74,6 +79,12 @@ func (p *repoPlugin) Evaluate(ctx context.Context, step *config.Step) (*model.Ev
 		return nil, plugin.NewValidationError(step.ID, err)
 	}
 
+	auth, err := authMethod(repoCfg.RepoStep)
+	if err != nil {
+		return nil, plugin.NewStateError(step.ID, fmt.Errorf("auth: %w", err))
+	}
+	sparse := sparseDirs(repoCfg.SparsePaths)
+
 	// Check destination directory (read-only operation)
 	dirExists := true
 	if _, err := os.Stat(repoCfg.Destination); err != nil {
@@ -89,6 +100,10 @@ func (p *repoPlugin) Evaluate(ctx context.Context, step *config.Step) (*model.Ev
 	isGitRepo := false
 	var actualURL string
 	var currentHead string
+	var currentCommit string
+	var headHash plumbing.Hash
+	var sync *syncState
+	var currentDirs, missingSubs []string
 
 	if dirExists {
 		if _, err := os.Stat(gitDir); err == nil {
@@ -101,6 +116,8 @@ func (p *repoPlugin) Evaluate(ctx context.Context, step *config.Step) (*model.Ev
 				head, err := repo.Head()
 				if err == nil {
 					currentHead = head.Name().Short()
+					currentCommit = head.Hash().String()
+					headHash = head.Hash()
 				}
 
 				// Get remote URL
@@ -108,13 +125,33 @@ func (p *repoPlugin) Evaluate(ctx context.Context, step *config.Step) (*model.Ev
 				if err == nil && len(remote.Config().URLs) > 0 {
 					actualURL = remote.Config().URLs[0]
 				}
+
+				// Compare HEAD with the pinned ref or remote tip when the remote is the expected one
+				if (repoCfg.Ref != "" || repoCfg.Update) && (actualURL == "" || actualURL == repoCfg.URL) {
+					sync, err = inspectSync(ctx, repo, repoCfg.RepoStep, auth, currentHead, headHash)
+					if err != nil {
+						return nil, plugin.NewStateError(step.ID, err)
+					}
+				}
+
+				if len(sparse) > 0 {
+					if currentDirs, err = currentSparse(repoCfg.Destination); err != nil {
+						return nil, plugin.NewStateError(step.ID, fmt.Errorf("read sparse checkout: %w", err))
+					}
+				}
+				if repoCfg.Submodules != "" {
+					if missingSubs, err = missingSubmodules(repo, repoCfg.Destination, sparse); err != nil {
+						return nil, plugin.NewStateError(step.ID, err)
+					}
+				}
 			}
 		}
 	}
 
 	// Store evaluation data to avoid recomputation
 	cloneOpts := &git.CloneOptions{
-		URL: repoCfg.URL,
+		URL:  repoCfg.URL,
+		Auth: auth,
 	}
 	if repoCfg.Depth > 0 {
 		cloneOpts.Depth = repoCfg.Depth
@@ -123,6 +160,14 @@ func (p *repoPlugin) Evaluate(ctx context.Context, step *config.Step) (*model.Ev
 		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(repoCfg.Branch)
 		cloneOpts.SingleBranch = true
 	}
+	if len(sparse) > 0 {
+		// go-git would check out the whole tree; git sparse-checkout fills it in after the clone.
+		cloneOpts.NoCheckout = true
+	}
+	if repoCfg.Submodules != "" && len(sparse) == 0 && repoCfg.Ref == "" {
+		// Otherwise submodules are checked out once HEAD is where the step wants it.
+		cloneOpts.RecurseSubmodules = git.DefaultSubmoduleRecursionDepth
+	}
 
 	internalData := &repoEvaluationData{
 		RepoExists:   dirExists,
@@ -133,18 +178,30 @@ func (p *repoPlugin) Evaluate(ctx context.Context, step *config.Step) (*model.Ev
 		Branch:       repoCfg.Branch,
 		Depth:        repoCfg.Depth,
 		CurrentHead:  currentHead,
+		CurrentSHA:   currentCommit,
 		DesiredHead:  repoCfg.Branch,
 		CloneOptions: cloneOpts,
+		Sync:         sync,
 	}
 
 	// Determine current state
 	if !dirExists {
+		diff := fmt.Sprintf("Would clone: %s", repoCfg.URL)
+		if repoCfg.Ref != "" {
+			diff += fmt.Sprintf(" at %s", repoCfg.Ref)
+		}
+		if len(sparse) > 0 {
+			diff += fmt.Sprintf(", limited to %s", strings.Join(sparse, ", "))
+		}
+		if repoCfg.Submodules != "" {
+			diff += ", with submodules"
+		}
 		return &model.EvaluationResult{
 			StepID:         step.ID,
 			CurrentState:   model.StatusMissing,
 			RequiresAction: true,
 			Message:        fmt.Sprintf("repository directory %s does not exist", repoCfg.Destination),
-			Diff:           fmt.Sprintf("Would clone: %s", repoCfg.URL),
+			Diff:           diff,
 			InternalData:   internalData,
 		}, nil
 	}
@@ -184,6 +241,46 @@ func (p *repoPlugin) Evaluate(ctx context.Context, step *config.Step) (*model.Ev
 		}, nil
 	}
 
+	// Check if HEAD is at the pinned ref or remote tip, with no local changes in the way
+	if sync != nil && !sync.current(headHash) {
+		return &model.EvaluationResult{
+			StepID:         step.ID,
+			CurrentState:   model.StatusDrifted,
+			RequiresAction: true,
+			Message:        fmt.Sprintf("repository at %s: %s", repoCfg.Destination, describeSync(sync)),
+			Diff:           syncDiff(sync, headHash, repoCfg.OnDirty),
+			InternalData:   internalData,
+		}, nil
+	}
+
+	// Check if the sparse checkout covers the configured directories
+	if len(sparse) > 0 && !slices.Equal(currentDirs, sparse) {
+		covered := "the whole tree"
+		if len(currentDirs) > 0 {
+			covered = strings.Join(currentDirs, ", ")
+		}
+		return &model.EvaluationResult{
+			StepID:         step.ID,
+			CurrentState:   model.StatusDrifted,
+			RequiresAction: true,
+			Message:        fmt.Sprintf("checkout covers %s (expected %s)", covered, strings.Join(sparse, ", ")),
+			Diff:           fmt.Sprintf("Would limit checkout to: %s", strings.Join(sparse, ", ")),
+			InternalData:   internalData,
+		}, nil
+	}
+
+	// Check if all submodules are checked out
+	if len(missingSubs) > 0 {
+		return &model.EvaluationResult{
+			StepID:         step.ID,
+			CurrentState:   model.StatusDrifted,
+			RequiresAction: true,
+			Message:        fmt.Sprintf("submodules not initialised: %s", strings.Join(missingSubs, ", ")),
+			Diff:           fmt.Sprintf("Would initialise submodules:\n  %s", strings.Join(missingSubs, "\n  ")),
+			InternalData:   internalData,
+		}, nil
+	}
+
 	// Repository is in correct state
 	return &model.EvaluationResult{
 		StepID:         step.ID,
@@ -191,6 +288,7 @@ func (p *repoPlugin) Evaluate(ctx context.Context, step *config.Step) (*model.Ev
 		RequiresAction: false,
 		Message:        fmt.Sprintf("git repository exists at %s", repoCfg.Destination),
 		InternalData:   internalData,
+		Outputs:        repoOutputs(currentHead, currentCommit),
 	}, nil
 }
 
@@ -235,6 +333,21 @@ func (p *repoPlugin) Apply(ctx context.Context, evalResult *model.EvaluationResu
 		}, nil
 	}
 
+	// Existing clones of the right remote are moved in place rather than recloned
+	if data.IsGitRepo && (data.ActualURL == "" || data.ActualURL == repoCfg.URL) {
+		return p.applyExisting(ctx, step.ID, repoCfg.RepoStep, data.CloneOptions.Auth)
+	}
+
+	// Record what the clone replaces (nothing, or a non-git directory) for rollback
+	if err := plugin.JournalFromContext(ctx).SavePath(repoCfg.Destination); err != nil {
+		return &model.StepResult{
+			StepID:  step.ID,
+			Status:  model.StatusFailed,
+			Message: fmt.Sprintf("failed to journal %s: %v", repoCfg.Destination, err),
+			Error:   err,
+		}, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to journal destination: %w", err))
+	}
+
 	// Create destination directory if needed
 	if err := os.MkdirAll(filepath.Dir(repoCfg.Destination), 0o755); err != nil {
 		return &model.StepResult{
@@ -258,7 +371,8 @@ func (p *repoPlugin) Apply(ctx context.Context, evalResult *model.EvaluationResu
 	}
 
 	// Clone the repository
-	if _, err := git.PlainCloneContext(ctx, repoCfg.Destination, false, data.CloneOptions); err != nil {
+	cloned, err := git.PlainCloneContext(ctx, repoCfg.Destination, false, data.CloneOptions)
+	if err != nil {
 		return &model.StepResult{
 			StepID:  step.ID,
 			Status:  model.StatusFailed,
@@ -267,15 +381,223 @@ func (p *repoPlugin) Apply(ctx context.Context, evalResult *model.EvaluationResu
 		}, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to clone repository: %w", err))
 	}
 
-	return &model.StepResult{
+	message := fmt.Sprintf("cloned %s", repoCfg.URL)
+	sparse := sparseDirs(repoCfg.SparsePaths)
+	if len(sparse) > 0 {
+		if err := applySparse(ctx, cloned, repoCfg.Destination, sparse); err != nil {
+			return failedApply(step.ID, "limit checkout to "+strings.Join(sparse, ", "), err)
+		}
+		// The clone has no checkout yet; this fills in the sparse directories.
+		if err := runGit(ctx, repoCfg.Destination, "reset", "--hard", "--quiet"); err != nil {
+			return failedApply(step.ID, "check out "+repoCfg.Destination, err)
+		}
+	}
+	if repoCfg.Ref != "" {
+		if err := checkoutRef(ctx, cloned, data.CloneOptions.Auth, repoCfg.Destination, repoCfg.Ref, len(sparse) > 0); err != nil {
+			return failedApply(step.ID, "check out "+repoCfg.Ref, err)
+		}
+		message += fmt.Sprintf(" at %s", repoCfg.Ref)
+	}
+	if len(sparse) > 0 {
+		message += fmt.Sprintf(", limited to %s", strings.Join(sparse, ", "))
+	}
+	if repoCfg.Submodules != "" && data.CloneOptions.RecurseSubmodules == git.NoRecurseSubmodules {
+		if _, err := updateSubmodules(ctx, cloned, repoCfg.Destination, sparse, data.CloneOptions.Auth, false); err != nil {
+			return failedApply(step.ID, "check out submodules", err)
+		}
+	}
+
+	result := &model.StepResult{
 		StepID:  step.ID,
 		Status:  model.StatusSuccess,
-		Message: fmt.Sprintf("cloned %s", repoCfg.URL),
-	}, nil
+		Message: message,
+	}
+	if head, err := cloned.Head(); err == nil {
+		result.Outputs = repoOutputs(head.Name().Short(), head.Hash().String())
+	}
+	return result, nil
+}
+
+// applyExisting brings an existing clone to the wanted branch, ref or remote tip, sparse
+// directories and submodules. Local changes are settled by on_dirty first. Only a fast-forward
+// moves a branch, so local commits are never dropped.
+func (p *repoPlugin) applyExisting(ctx context.Context, stepID string, cfg *config.RepoStep, auth transport.AuthMethod) (*model.StepResult, error) {
+	repo, err := git.PlainOpen(cfg.Destination)
+	if err != nil {
+		return failedApply(stepID, "open repository", err)
+	}
+	var done []string
+
+	head, err := repo.Head()
+	if err != nil {
+		return failedApply(stepID, "read HEAD", err)
+	}
+	dirty, err := dirtyPaths(repo)
+	if err != nil {
+		return failedApply(stepID, "inspect repository", err)
+	}
+	saved, err := saveDirty(ctx, cfg.Destination, cfg.OnDirty, dirty)
+	if err != nil {
+		return failedApply(stepID, "update "+cfg.Destination, err)
+	}
+
+	// Record where HEAD was, and the local changes on_dirty is about to put away, for rollback
+	branch := ""
+	if head.Name().IsBranch() {
+		branch = head.Name().Short()
+	}
+	if err := plugin.JournalFromContext(ctx).SaveCheckout(cfg.Destination, branch, head.Hash().String(), saved); err != nil {
+		return failedApply(stepID, "journal "+cfg.Destination, err)
+	}
+
+	settled, err := settleDirty(ctx, cfg.Destination, cfg.OnDirty, saved)
+	if err != nil {
+		return failedApply(stepID, "update "+cfg.Destination, err)
+	}
+	if settled != "" {
+		done = append(done, settled)
+	}
+
+	sparse := sparseDirs(cfg.SparsePaths)
+	if len(sparse) > 0 {
+		current, err := currentSparse(cfg.Destination)
+		if err != nil {
+			return failedApply(stepID, "read sparse checkout", err)
+		}
+		if !slices.Equal(current, sparse) {
+			if err := applySparse(ctx, repo, cfg.Destination, sparse); err != nil {
+				return failedApply(stepID, "limit checkout to "+strings.Join(sparse, ", "), err)
+			}
+			done = append(done, "limited checkout to "+strings.Join(sparse, ", "))
+		}
+	}
+
+	start := head.Hash()
+	if cfg.Branch != "" && head.Name().Short() != cfg.Branch {
+		if err := checkoutBranch(ctx, repo, auth, cfg.Destination, cfg.Branch, cfg.Depth); err != nil {
+			return failedApply(stepID, "check out branch "+cfg.Branch, err)
+		}
+		done = append(done, "checked out branch "+cfg.Branch)
+		if head, err = repo.Head(); err != nil {
+			return failedApply(stepID, "read HEAD", err)
+		}
+	}
+
+	if cfg.Ref != "" || cfg.Update {
+		sync, err := inspectSync(ctx, repo, cfg, auth, head.Name().Short(), head.Hash())
+		if err != nil {
+			return failedApply(stepID, "resolve "+cfg.Destination+" target", err)
+		}
+		if !sync.Fetched {
+			if err := fetchOrigin(ctx, repo, auth, cfg.Depth); err != nil {
+				return failedApply(stepID, "update "+cfg.Destination, err)
+			}
+			if _, err := repo.CommitObject(sync.Target); err != nil && cfg.Ref != "" {
+				if err := fetchRef(ctx, repo, auth, cfg.Ref); err != nil {
+					return failedApply(stepID, "update "+cfg.Destination, err)
+				}
+			}
+			if _, err := repo.CommitObject(sync.Target); err != nil {
+				return failedApply(stepID, "update "+cfg.Destination, fmt.Errorf("%s (%s) not found after fetching origin", sync.TargetName, shortHash(sync.Target)))
+			}
+		}
+
+		if head.Hash() != sync.Target {
+			if cfg.Ref != "" {
+				if err := runGit(ctx, cfg.Destination, "checkout", "--quiet", "--detach", sync.Target.String()); err != nil {
+					return failedApply(stepID, "check out "+cfg.Ref, err)
+				}
+				done = append(done, fmt.Sprintf("checked out %s (%s)", cfg.Ref, shortHash(sync.Target)))
+			} else {
+				ahead, err := commitsMissing(repo, head.Hash(), sync.Target)
+				if err != nil {
+					return failedApply(stepID, "update "+cfg.Destination, err)
+				}
+				if len(ahead) > 0 {
+					return failedApply(stepID, "update "+cfg.Destination, fmt.Errorf("%s has %s not on %s; cannot fast-forward", head.Name().Short(), plural(len(ahead), "commit"), sync.TargetName))
+				}
+				behind, err := commitsMissing(repo, sync.Target, head.Hash())
+				if err != nil {
+					return failedApply(stepID, "update "+cfg.Destination, err)
+				}
+				if err := runGit(ctx, cfg.Destination, "merge", "--quiet", "--ff-only", sync.Target.String()); err != nil {
+					return failedApply(stepID, "fast-forward "+head.Name().Short(), err)
+				}
+				done = append(done, fmt.Sprintf("fast-forwarded %s to %s (%s)", head.Name().Short(), shortHash(sync.Target), plural(len(behind), "commit")))
+			}
+		}
+	}
+
+	if cfg.Submodules != "" {
+		head, err := repo.Head()
+		if err != nil {
+			return failedApply(stepID, "read HEAD", err)
+		}
+		initialised, err := updateSubmodules(ctx, repo, cfg.Destination, sparse, auth, head.Hash() != start)
+		if err != nil {
+			return failedApply(stepID, "check out submodules", err)
+		}
+		if len(initialised) > 0 {
+			done = append(done, "initialised submodules "+strings.Join(initialised, ", "))
+		}
+	}
+
+	result := &model.StepResult{
+		StepID:  stepID,
+		Status:  model.StatusSuccess,
+		Message: strings.Join(done, "; "),
+	}
+	if head, err := repo.Head(); err == nil {
+		result.Outputs = repoOutputs(head.Name().Short(), head.Hash().String())
+	}
+	return result, nil
+}
+
+// checkoutRef detaches HEAD at a tag or commit of a fresh clone, fetching it by name when no
+// cloned branch or tag reaches it. A sparse clone is checked out with git, which keeps to its
+// sparse directories.
+func checkoutRef(ctx context.Context, repo *git.Repository, auth transport.AuthMethod, destination, ref string, sparse bool) error {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		if err := fetchRef(ctx, repo, auth, ref); err != nil {
+			return fmt.Errorf("ref %q not found on origin: %w", ref, err)
+		}
+		if hash, err = repo.ResolveRevision(plumbing.Revision(ref)); err != nil {
+			return fmt.Errorf("ref %q not found on origin", ref)
+		}
+	}
+	if sparse {
+		return runGit(ctx, destination, "checkout", "--quiet", "--detach", hash.String())
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return wt.Checkout(&git.CheckoutOptions{Hash: *hash})
+}
+
+// repoOutputs exposes the checked-out branch and commit SHA to steps that register the repo.
+func repoOutputs(branch, commit string) map[string]any {
+	if commit == "" {
+		return nil
+	}
+	return map[string]any{
+		"branch": branch,
+		"commit": commit,
+	}
 }
 
 // Helper functions
 
+func failedApply(stepID, action string, err error) (*model.StepResult, error) {
+	return &model.StepResult{
+		StepID:  stepID,
+		Status:  model.StatusFailed,
+		Message: fmt.Sprintf("failed to %s: %v", action, err),
+		Error:   err,
+	}, plugin.NewExecutionError(stepID, fmt.Errorf("failed to %s: %w", action, err))
+}
+
 func convertError(stepID string, err error) error {
 	// Convert legacy errors to new plugin errors
 	var valErr *streamyerrors.ValidationError
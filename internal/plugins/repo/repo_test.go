@@ -64,6 +64,12 @@ func TestRepoPlugin_ApplyClonesRepository(t *testing.T) {
 	contents, err := os.ReadFile(filepath.Join(dest, "README.md"))
 	require.NoError(t, err)
 	require.Contains(t, string(contents), "hello repo")
+
+	sourceRepo, err := git.PlainOpen(source)
+	require.NoError(t, err)
+	head, err := sourceRepo.Head()
+	require.NoError(t, err)
+	require.Equal(t, head.Hash().String(), result.Outputs["commit"])
 }
 
 func TestRepoPlugin_EvaluateDetectsExistingClone(t *testing.T) {
@@ -86,6 +92,7 @@ func TestRepoPlugin_EvaluateDetectsExistingClone(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, evalResult.RequiresAction)
 	require.Equal(t, model.StatusSatisfied, evalResult.CurrentState)
+	require.Len(t, evalResult.Outputs["commit"], 40)
 }
 
 func TestRepoPlugin_EvaluateDetectsCorruptedRepo(t *testing.T) {
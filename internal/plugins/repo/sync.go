@@ -0,0 +1,390 @@
+package repoplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/plugins/internalexec"
+)
+
+// maxDiffCommits caps how many commits of a range the diff lists.
+const maxDiffCommits = 20
+
+var fullSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// syncState describes how an existing clone relates to the commit the step wants checked out.
+type syncState struct {
+	// Target is the wanted commit and TargetName what it was resolved from, e.g. "origin/main".
+	Target     plumbing.Hash
+	TargetName string
+	// Fetched reports whether Target is in the local object store; Behind is unknown otherwise.
+	Fetched bool
+	// Ahead lists the commits of HEAD missing from Target, Behind those of Target missing from
+	// HEAD, newest first.
+	Ahead  []*object.Commit
+	Behind []*object.Commit
+	// Dirty lists tracked paths with uncommitted changes, as "M path".
+	Dirty []string
+}
+
+// current reports whether HEAD is at the target with a clean worktree.
+func (s *syncState) current(head plumbing.Hash) bool {
+	return head == s.Target && len(s.Dirty) == 0
+}
+
+// inspectSync resolves the step's ref, or the remote branch tip when updating, and compares it
+// with HEAD. It only reads: remote tips are listed, not fetched.
+func inspectSync(ctx context.Context, repo *git.Repository, cfg *config.RepoStep, auth transport.AuthMethod, branch string, head plumbing.Hash) (*syncState, error) {
+	state := &syncState{}
+
+	var err error
+	switch {
+	case cfg.Ref != "":
+		state.TargetName = cfg.Ref
+		if fullSHA.MatchString(cfg.Ref) {
+			state.TargetName = cfg.Ref[:7]
+		}
+		state.Target, state.Fetched, err = resolveRef(ctx, repo, auth, cfg.Ref)
+	case cfg.Update:
+		if cfg.Branch != "" {
+			branch = cfg.Branch
+		}
+		if branch == "" || branch == plumbing.HEAD.String() {
+			return nil, fmt.Errorf("cannot update a detached HEAD; set branch")
+		}
+		state.TargetName = "origin/" + branch
+		state.Target, err = remoteHash(ctx, repo, auth, plumbing.NewBranchReferenceName(branch))
+		if err == nil {
+			_, lookupErr := repo.CommitObject(state.Target)
+			state.Fetched = lookupErr == nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if head != state.Target && !head.IsZero() {
+		base := state.Target
+		if state.Fetched {
+			if state.Behind, err = commitsMissing(repo, state.Target, head); err != nil {
+				return nil, err
+			}
+		} else if tracking, refErr := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true); cfg.Update && refErr == nil {
+			// The new remote commits are unknown until fetched, but local commits can still be
+			// counted against the last fetched tip.
+			base = tracking.Hash()
+		} else {
+			base = plumbing.ZeroHash
+		}
+		if !base.IsZero() {
+			if state.Ahead, err = commitsMissing(repo, head, base); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if state.Dirty, err = dirtyPaths(repo); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// resolveRef finds the commit of a tag or commit SHA, locally first and then among the remote's
+// tags. A full SHA unknown locally is taken as is, to be fetched.
+func resolveRef(ctx context.Context, repo *git.Repository, auth transport.AuthMethod, ref string) (plumbing.Hash, bool, error) {
+	if hash, err := repo.ResolveRevision(plumbing.Revision(ref)); err == nil {
+		return *hash, true, nil
+	}
+	if fullSHA.MatchString(ref) {
+		return plumbing.NewHash(ref), false, nil
+	}
+	hash, err := remoteHash(ctx, repo, auth, plumbing.NewTagReferenceName(ref))
+	if err != nil {
+		return plumbing.ZeroHash, false, fmt.Errorf("ref %q is neither a local commit nor a tag on origin: %w", ref, err)
+	}
+	return hash, false, nil
+}
+
+// remoteHash lists origin's references and returns the commit name points at, peeling
+// annotated tags.
+func remoteHash(ctx context.Context, repo *git.Repository, auth transport.AuthMethod, name plumbing.ReferenceName) (plumbing.Hash, error) {
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("no origin remote: %w", err)
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth, PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("list origin references: %w", err)
+	}
+	var hash plumbing.Hash
+	for _, ref := range refs {
+		switch ref.Name() {
+		case name + "^{}":
+			return ref.Hash(), nil
+		case name:
+			hash = ref.Hash()
+		}
+	}
+	if hash.IsZero() {
+		return plumbing.ZeroHash, fmt.Errorf("%s not found on origin", name.Short())
+	}
+	return hash, nil
+}
+
+// commitsMissing lists the commits reachable from from but not from base, newest first.
+func commitsMissing(repo *git.Repository, from, base plumbing.Hash) ([]*object.Commit, error) {
+	seen := make(map[plumbing.Hash]bool)
+	baseLog, err := repo.Log(&git.LogOptions{From: base})
+	if err != nil {
+		return nil, fmt.Errorf("read history of %s: %w", base.String()[:7], err)
+	}
+	err = baseLog.ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	// Shallow clones end in commits whose parents are missing.
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("read history of %s: %w", base.String()[:7], err)
+	}
+
+	var missing []*object.Commit
+	fromLog, err := repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return nil, fmt.Errorf("read history of %s: %w", from.String()[:7], err)
+	}
+	err = fromLog.ForEach(func(c *object.Commit) error {
+		if !seen[c.Hash] {
+			missing = append(missing, c)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("read history of %s: %w", from.String()[:7], err)
+	}
+	return missing, nil
+}
+
+// dirtyPaths lists tracked files with staged or unstaged changes. Untracked files are left
+// alone: checking out another commit keeps them.
+func dirtyPaths(repo *git.Repository) ([]string, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("open worktree: %w", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("read worktree status: %w", err)
+	}
+	var dirty []string
+	for path, st := range status {
+		if st.Worktree == git.Untracked {
+			continue
+		}
+		code := st.Worktree
+		if code == git.Unmodified {
+			code = st.Staging
+		}
+		if code != git.Unmodified {
+			dirty = append(dirty, fmt.Sprintf("%c %s", code, path))
+		}
+	}
+	sort.Strings(dirty)
+	return dirty, nil
+}
+
+// describeSync summarises the drift for the evaluation message, e.g.
+// "3 commits behind origin/main, uncommitted changes in 1 file".
+func describeSync(s *syncState) string {
+	var parts []string
+	if !s.Fetched {
+		parts = append(parts, fmt.Sprintf("%s (%s) not fetched yet", s.TargetName, shortHash(s.Target)))
+	}
+	if len(s.Behind) > 0 {
+		parts = append(parts, fmt.Sprintf("%s behind %s", plural(len(s.Behind), "commit"), s.TargetName))
+	}
+	if len(s.Ahead) > 0 {
+		parts = append(parts, fmt.Sprintf("%s ahead of %s", plural(len(s.Ahead), "commit"), s.TargetName))
+	}
+	if len(s.Dirty) > 0 {
+		parts = append(parts, fmt.Sprintf("uncommitted changes in %s", plural(len(s.Dirty), "file")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// syncDiff shows the commit range HEAD would move across: "+" for commits it would check out,
+// "-" for commits it would leave, then the uncommitted changes and what on_dirty does to them.
+func syncDiff(s *syncState, head plumbing.Hash, onDirty string) string {
+	var b strings.Builder
+	if head != s.Target {
+		fmt.Fprintf(&b, "HEAD %s..%s (%s)\n", shortHash(head), shortHash(s.Target), s.TargetName)
+		writeCommits(&b, "+", s.Behind)
+		writeCommits(&b, "-", s.Ahead)
+	}
+	if len(s.Dirty) > 0 {
+		if onDirty == "" {
+			onDirty = config.RepoOnDirtyFail
+		}
+		fmt.Fprintf(&b, "uncommitted changes (on_dirty: %s):\n", onDirty)
+		for _, path := range s.Dirty {
+			fmt.Fprintf(&b, "  %s\n", path)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeCommits(b *strings.Builder, sign string, commits []*object.Commit) {
+	for i, c := range commits {
+		if i == maxDiffCommits {
+			fmt.Fprintf(b, "%s … and %d more\n", sign, len(commits)-maxDiffCommits)
+			return
+		}
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		fmt.Fprintf(b, "%s %s %s\n", sign, shortHash(c.Hash), subject)
+	}
+}
+
+// saveDirty checks the on_dirty policy allows HEAD to move over uncommitted changes and, if there
+// are any, saves them in a stash commit without touching the working tree. It returns the commit,
+// or "" for a clean checkout.
+func saveDirty(ctx context.Context, destination, policy string, dirty []string) (string, error) {
+	if len(dirty) == 0 {
+		return "", nil
+	}
+	if policy != config.RepoOnDirtyStash && policy != config.RepoOnDirtyReset {
+		return "", fmt.Errorf("uncommitted changes in %s; commit them or set on_dirty to stash or reset", plural(len(dirty), "file"))
+	}
+	return gitOutput(ctx, destination, "stash", "create")
+}
+
+// settleDirty applies the on_dirty policy to the changes saveDirty saved before HEAD moves: stash
+// keeps the commit on the stash list, reset only discards the changes. Either way the saved
+// commit lets a rollback bring them back.
+func settleDirty(ctx context.Context, destination, policy, saved string) (string, error) {
+	if saved == "" {
+		return "", nil
+	}
+	message := "discarded local changes"
+	if policy == config.RepoOnDirtyStash {
+		if err := runGit(ctx, destination, "stash", "store", "-m", "streamy: local changes before update", saved); err != nil {
+			return "", err
+		}
+		message = "stashed local changes"
+	}
+	if err := runGit(ctx, destination, "reset", "--hard", "--quiet"); err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+// fetchOrigin fetches origin's branches and tags.
+func fetchOrigin(ctx context.Context, repo *git.Repository, auth transport.AuthMethod, depth int) error {
+	err := repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+		Tags:       git.AllTags,
+		Depth:      depth,
+		Auth:       auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetch origin: %w", err)
+	}
+	return nil
+}
+
+// fetchedRef names the temporary reference a pinned commit is fetched into.
+const fetchedRef = plumbing.ReferenceName("refs/streamy/fetched")
+
+// fetchRef fetches a tag or full commit SHA by name, for refs origin's branches and tags do not
+// reach, such as a commit of a deleted branch or a pull request. Fetching a bare commit needs a
+// server that accepts commits asked for by SHA: GitHub and GitLab do, plain git with
+// uploadpack.allowReachableSHA1InWant.
+func fetchRef(ctx context.Context, repo *git.Repository, auth transport.AuthMethod, ref string) error {
+	spec := gitconfig.RefSpec("+refs/tags/" + ref + ":refs/tags/" + ref)
+	if fullSHA.MatchString(ref) {
+		spec = gitconfig.RefSpec(ref + ":" + fetchedRef.String())
+	}
+	err := repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{spec},
+		Tags:       git.NoTags,
+		Auth:       auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetch %s from origin: %w", ref, err)
+	}
+	// The commit is checked out next; HEAD keeps it from then on.
+	if err := repo.Storer.RemoveReference(fetchedRef); err != nil {
+		return fmt.Errorf("fetch %s from origin: %w", ref, err)
+	}
+	return nil
+}
+
+// checkoutBranch switches to a local branch, creating it from origin's when needed.
+func checkoutBranch(ctx context.Context, repo *git.Repository, auth transport.AuthMethod, destination, branch string, depth int) error {
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true); err == nil {
+		return runGit(ctx, destination, "checkout", "--quiet", branch)
+	}
+
+	remote := plumbing.NewRemoteReferenceName("origin", branch)
+	if _, err := repo.Reference(remote, true); err != nil {
+		if err := fetchOrigin(ctx, repo, auth, depth); err != nil {
+			return err
+		}
+	}
+	if _, err := repo.Reference(remote, true); err != nil {
+		return fmt.Errorf("branch %s not found on origin", branch)
+	}
+	return runGit(ctx, destination, "checkout", "--quiet", "--track", "-b", branch, remote.Short())
+}
+
+// runGit runs the git CLI in an existing clone. go-git's checkout and reset delete untracked
+// files, so anything that moves the worktree of a clone the user may have touched goes through git.
+func runGit(ctx context.Context, destination string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", destination}, args...)...)
+	result, err := internalexec.RunStreamingContext(ctx, cmd)
+	if err != nil {
+		if output := internalexec.PrimaryOutput(result); output != "" {
+			return fmt.Errorf("git %s: %w: %s", args[0], err, output)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// gitOutput runs a git command in destination for its trimmed stdout, which is not streamed.
+func gitOutput(ctx context.Context, destination string, args ...string) (string, error) {
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", destination}, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if output := strings.TrimSpace(stderr.String()); output != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, output)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func shortHash(h plumbing.Hash) string {
+	return h.String()[:7]
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
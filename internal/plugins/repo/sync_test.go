@@ -0,0 +1,412 @@
+package repoplugin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/journal"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+)
+
+// remoteFixture is a bare repository, reached over the file transport, and a working repository pushing to it.
+type remoteFixture struct {
+	t    *testing.T
+	URL  string
+	dir  string
+	work *git.Repository
+}
+
+func newRemoteFixture(t *testing.T) *remoteFixture {
+	t.Helper()
+
+	bare := filepath.Join(t.TempDir(), "remote.git")
+	_, err := git.PlainInit(bare, true)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	work, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	f := &remoteFixture{t: t, URL: bare, dir: dir, work: work}
+	_, err = work.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{f.URL}})
+	require.NoError(t, err)
+
+	f.commit("initial")
+	return f
+}
+
+var fixtureAuthor = &object.Signature{Name: "Streamy", Email: "streamy@example.com", When: time.Unix(1700000000, 0)}
+
+// commit adds a file named after the message on the checked-out branch and pushes everything.
+func (f *remoteFixture) commit(message string) plumbing.Hash {
+	f.t.Helper()
+	return f.commitFiles(message, map[string]string{message + ".txt": message})
+}
+
+// commitFiles writes files, relative to the repository root, and commits and pushes them.
+func (f *remoteFixture) commitFiles(message string, files map[string]string) plumbing.Hash {
+	f.t.Helper()
+
+	wt, err := f.work.Worktree()
+	require.NoError(f.t, err)
+	for name, content := range files {
+		path := filepath.Join(f.dir, name)
+		require.NoError(f.t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(f.t, os.WriteFile(path, []byte(content), 0o644))
+		_, err = wt.Add(name)
+		require.NoError(f.t, err)
+	}
+	hash, err := wt.Commit(message, &git.CommitOptions{Author: fixtureAuthor})
+	require.NoError(f.t, err)
+	f.push()
+	return hash
+}
+
+func (f *remoteFixture) tag(name string, hash plumbing.Hash) {
+	f.t.Helper()
+
+	_, err := f.work.CreateTag(name, hash, &git.CreateTagOptions{Tagger: fixtureAuthor, Message: name})
+	require.NoError(f.t, err)
+	f.push()
+}
+
+func (f *remoteFixture) push() {
+	f.t.Helper()
+
+	err := f.work.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{"refs/heads/*:refs/heads/*", "refs/tags/*:refs/tags/*"},
+		Force:      true,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		require.NoError(f.t, err)
+	}
+}
+
+func evaluateAndApply(t *testing.T, step *config.Step) (*model.EvaluationResult, *model.StepResult, error) {
+	t.Helper()
+
+	p := New()
+	eval, err := p.Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	result, err := p.Apply(context.Background(), eval, step)
+	return eval, result, err
+}
+
+func headOf(t *testing.T, dir string) *plumbing.Reference {
+	t.Helper()
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	return head
+}
+
+func TestRepoPlugin_UpdateFastForwards(t *testing.T) {
+	remote := newRemoteFixture(t)
+	dest := filepath.Join(t.TempDir(), "clone")
+	step := newRepoStep(t, "dotfiles", config.RepoStep{URL: remote.URL, Destination: dest, Update: true})
+
+	_, _, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+	first := headOf(t, dest).Hash()
+
+	second := remote.commit("second")
+	third := remote.commit("third")
+
+	// Evaluation lists the remote tip without fetching it.
+	eval, err := New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Contains(t, eval.Message, "origin/master ("+shortHash(third)+") not fetched yet")
+	require.Equal(t, "HEAD "+shortHash(first)+".."+shortHash(third)+" (origin/master)", eval.Diff)
+
+	// Once the commits are local, the range lists them.
+	clone, err := git.PlainOpen(dest)
+	require.NoError(t, err)
+	require.NoError(t, clone.Fetch(&git.FetchOptions{}))
+	eval, err = New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Contains(t, eval.Message, "2 commits behind origin/master")
+	require.Equal(t, "HEAD "+shortHash(first)+".."+shortHash(third)+" (origin/master)\n"+
+		"+ "+shortHash(third)+" third\n"+
+		"+ "+shortHash(second)+" second", eval.Diff)
+
+	result, err := New().Apply(context.Background(), eval, step)
+	require.NoError(t, err)
+	require.Equal(t, "fast-forwarded master to "+shortHash(third)+" (2 commits)", result.Message)
+	require.Equal(t, third, headOf(t, dest).Hash())
+	require.Equal(t, "master", headOf(t, dest).Name().Short())
+	require.FileExists(t, filepath.Join(dest, "third.txt"))
+
+	eval, err = New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+	require.Equal(t, third.String(), eval.Outputs["commit"])
+}
+
+func TestRepoPlugin_UpdateRefusesToDropLocalCommits(t *testing.T) {
+	remote := newRemoteFixture(t)
+	dest := filepath.Join(t.TempDir(), "clone")
+	step := newRepoStep(t, "dotfiles", config.RepoStep{URL: remote.URL, Destination: dest, Update: true})
+	_, _, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+
+	clone, err := git.PlainOpen(dest)
+	require.NoError(t, err)
+	wt, err := clone.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "local.txt"), []byte("local"), 0o644))
+	_, err = wt.Add("local.txt")
+	require.NoError(t, err)
+	local, err := wt.Commit("local work", &git.CommitOptions{Author: fixtureAuthor})
+	require.NoError(t, err)
+	remote.commit("upstream")
+
+	eval, err := New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Contains(t, eval.Message, "1 commit ahead of origin/master")
+	require.Contains(t, eval.Diff, "- "+shortHash(local)+" local work")
+
+	result, err := New().Apply(context.Background(), eval, step)
+	require.Error(t, err)
+	require.Equal(t, model.StatusFailed, result.Status)
+	require.Contains(t, result.Message, "master has 1 commit not on origin/master; cannot fast-forward")
+	require.Equal(t, local, headOf(t, dest).Hash())
+}
+
+func TestRepoPlugin_PinsTagsAndCommits(t *testing.T) {
+	remote := newRemoteFixture(t)
+	release := headOf(t, remote.dir).Hash()
+	remote.tag("v1.0.0", release)
+	next := remote.commit("next")
+
+	dest := filepath.Join(t.TempDir(), "clone")
+	step := newRepoStep(t, "tool", config.RepoStep{URL: remote.URL, Destination: dest, Ref: "v1.0.0"})
+	eval, result, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, "Would clone: "+remote.URL+" at v1.0.0", eval.Diff)
+	require.Equal(t, "cloned "+remote.URL+" at v1.0.0", result.Message)
+	require.Equal(t, release, headOf(t, dest).Hash())
+
+	eval, err = New().Evaluate(context.Background(), step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusSatisfied, eval.CurrentState)
+
+	step = newRepoStep(t, "tool", config.RepoStep{URL: remote.URL, Destination: dest, Ref: next.String()})
+	eval, result, err = evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Contains(t, eval.Message, "1 commit behind "+shortHash(next))
+	require.Equal(t, "checked out "+next.String()+" ("+shortHash(next)+")", result.Message)
+	require.Equal(t, next, headOf(t, dest).Hash())
+
+	// Tags created after the clone are found on the remote and fetched on apply.
+	remote.tag("v1.1.0", next)
+	latest := remote.commit("latest")
+	remote.tag("v2.0.0", latest)
+	step = newRepoStep(t, "tool", config.RepoStep{URL: remote.URL, Destination: dest, Ref: "v2.0.0"})
+	eval, _, err = evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Contains(t, eval.Message, "v2.0.0 ("+shortHash(latest)+") not fetched yet")
+	require.Equal(t, latest, headOf(t, dest).Hash())
+
+	step = newRepoStep(t, "tool", config.RepoStep{URL: remote.URL, Destination: dest, Ref: "v9.9.9"})
+	_, err = New().Evaluate(context.Background(), step)
+	var stateErr *plugin.StateError
+	require.ErrorAs(t, err, &stateErr)
+	require.ErrorContains(t, err, `ref "v9.9.9" is neither a local commit nor a tag on origin`)
+}
+
+func TestRepoPlugin_FetchesRefsOffTheDefaultBranch(t *testing.T) {
+	remote := newRemoteFixture(t)
+	wt, err := remote.work.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("hotfix"), Create: true}))
+	hotfix := remote.commit("hotfix")
+	remote.tag("v1.0.1", hotfix)
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("experiment"), Create: true}))
+	experiment := remote.commit("experiment")
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("master")}))
+	remote.commit("mainline")
+
+	// Once the branches are gone, only the tag reaches the hotfix and nothing the experiment.
+	bare, err := git.PlainOpen(remote.URL)
+	require.NoError(t, err)
+	require.NoError(t, bare.Storer.RemoveReference(plumbing.NewBranchReferenceName("hotfix")))
+	require.NoError(t, bare.Storer.RemoveReference(plumbing.NewBranchReferenceName("experiment")))
+	// Hosted servers accept commits asked for by SHA; plain git needs telling to.
+	require.NoError(t, exec.Command("git", "-C", remote.URL, "config", "uploadpack.allowAnySHA1InWant", "true").Run())
+
+	dest := filepath.Join(t.TempDir(), "clone")
+	step := newRepoStep(t, "tool", config.RepoStep{URL: remote.URL, Destination: dest, Ref: "v1.0.1"})
+	_, result, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, "cloned "+remote.URL+" at v1.0.1", result.Message)
+	require.Equal(t, hotfix, headOf(t, dest).Hash())
+	require.FileExists(t, filepath.Join(dest, "hotfix.txt"))
+
+	// An existing clone fetches the pinned commit by its SHA.
+	step = newRepoStep(t, "tool", config.RepoStep{URL: remote.URL, Destination: dest, Ref: experiment.String()})
+	_, _, err = evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, experiment, headOf(t, dest).Hash())
+
+	// So does a fresh one.
+	fresh := filepath.Join(t.TempDir(), "clone")
+	step = newRepoStep(t, "tool", config.RepoStep{URL: remote.URL, Destination: fresh, Ref: experiment.String()})
+	_, _, err = evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, experiment, headOf(t, fresh).Hash())
+	clone, err := git.PlainOpen(fresh)
+	require.NoError(t, err)
+	_, err = clone.Reference(fetchedRef, false)
+	require.ErrorIs(t, err, plumbing.ErrReferenceNotFound)
+}
+
+func TestRepoPlugin_OnDirty(t *testing.T) {
+	for _, policy := range []string{"", config.RepoOnDirtyReset, config.RepoOnDirtyStash} {
+		t.Run("policy "+policy, func(t *testing.T) {
+			t.Setenv("GIT_AUTHOR_NAME", "Streamy")
+			t.Setenv("GIT_AUTHOR_EMAIL", "streamy@example.com")
+			t.Setenv("GIT_COMMITTER_NAME", "Streamy")
+			t.Setenv("GIT_COMMITTER_EMAIL", "streamy@example.com")
+
+			remote := newRemoteFixture(t)
+			dest := filepath.Join(t.TempDir(), "clone")
+			step := newRepoStep(t, "dotfiles", config.RepoStep{URL: remote.URL, Destination: dest, Update: true, OnDirty: policy})
+			_, _, err := evaluateAndApply(t, step)
+			require.NoError(t, err)
+
+			require.NoError(t, os.WriteFile(filepath.Join(dest, "initial.txt"), []byte("edited"), 0o644))
+			require.NoError(t, os.WriteFile(filepath.Join(dest, "untracked.txt"), []byte("new"), 0o644))
+			tip := remote.commit("upstream")
+
+			eval, result, err := evaluateAndApply(t, step)
+			require.Equal(t, model.StatusDrifted, eval.CurrentState)
+			require.Contains(t, eval.Message, "uncommitted changes in 1 file")
+			shown := policy
+			if shown == "" {
+				shown = "fail"
+			}
+			require.Contains(t, eval.Diff, "uncommitted changes (on_dirty: "+shown+"):\n  M initial.txt")
+
+			switch policy {
+			case "":
+				require.Error(t, err)
+				require.Contains(t, result.Message, "uncommitted changes in 1 file; commit them or set on_dirty to stash or reset")
+				content, readErr := os.ReadFile(filepath.Join(dest, "initial.txt"))
+				require.NoError(t, readErr)
+				require.Equal(t, "edited", string(content))
+				return
+			case config.RepoOnDirtyReset:
+				require.NoError(t, err)
+				require.Equal(t, "discarded local changes; fast-forwarded master to "+shortHash(tip)+" (1 commit)", result.Message)
+			case config.RepoOnDirtyStash:
+				require.NoError(t, err)
+				require.Contains(t, result.Message, "stashed local changes")
+				out, gitErr := exec.Command("git", "-C", dest, "stash", "list").Output()
+				require.NoError(t, gitErr)
+				require.Contains(t, string(out), "streamy: local changes before update")
+			}
+
+			content, err := os.ReadFile(filepath.Join(dest, "initial.txt"))
+			require.NoError(t, err)
+			require.Equal(t, "initial", string(content))
+			require.FileExists(t, filepath.Join(dest, "untracked.txt"))
+			require.Equal(t, tip, headOf(t, dest).Hash())
+		})
+	}
+}
+
+func TestRepoPlugin_RollbackRestoresUpdatedClone(t *testing.T) {
+	for _, policy := range []string{config.RepoOnDirtyReset, config.RepoOnDirtyStash} {
+		t.Run("policy "+policy, func(t *testing.T) {
+			t.Setenv("GIT_AUTHOR_NAME", "Streamy")
+			t.Setenv("GIT_AUTHOR_EMAIL", "streamy@example.com")
+			t.Setenv("GIT_COMMITTER_NAME", "Streamy")
+			t.Setenv("GIT_COMMITTER_EMAIL", "streamy@example.com")
+
+			remote := newRemoteFixture(t)
+			dest := filepath.Join(t.TempDir(), "clone")
+			step := newRepoStep(t, "dotfiles", config.RepoStep{URL: remote.URL, Destination: dest, Update: true, OnDirty: policy})
+			_, _, err := evaluateAndApply(t, step)
+			require.NoError(t, err)
+			first := headOf(t, dest).Hash()
+
+			require.NoError(t, os.WriteFile(filepath.Join(dest, "initial.txt"), []byte("edited"), 0o644))
+			tip := remote.commit("upstream")
+
+			root := t.TempDir()
+			j, err := journal.Create(root, "streamy.yaml")
+			require.NoError(t, err)
+			ctx := plugin.ContextWithJournal(context.Background(), j.Step(step.ID, "repo", New().PluginMetadata().Irreversible))
+
+			p := New()
+			eval, err := p.Evaluate(ctx, step)
+			require.NoError(t, err)
+			_, err = p.Apply(ctx, eval, step)
+			require.NoError(t, err)
+			require.Equal(t, tip, headOf(t, dest).Hash())
+			_, err = j.Close()
+			require.NoError(t, err)
+
+			// Only HEAD and the saved changes are journaled, not a copy of the clone.
+			run, err := journal.Load(root, j.ID())
+			require.NoError(t, err)
+			require.Len(t, run.Steps, 1)
+			require.Len(t, run.Steps[0].Operations, 1)
+			op := run.Steps[0].Operations[0]
+			require.Equal(t, journal.OpRestoreCheckout, op.Kind)
+			require.Equal(t, "master", op.Branch)
+			require.Equal(t, first.String(), op.Commit)
+			require.NotEmpty(t, op.Stash)
+			backups, err := os.ReadDir(filepath.Join(root, j.ID(), "backups"))
+			require.NoError(t, err)
+			require.Empty(t, backups)
+
+			_, err = journal.Rollback(root, run, false)
+			require.NoError(t, err)
+
+			require.Equal(t, first, headOf(t, dest).Hash())
+			require.Equal(t, "master", headOf(t, dest).Name().Short())
+			content, err := os.ReadFile(filepath.Join(dest, "initial.txt"))
+			require.NoError(t, err)
+			require.Equal(t, "edited", string(content))
+		})
+	}
+}
+
+func TestRepoPlugin_ChecksOutConfiguredBranch(t *testing.T) {
+	remote := newRemoteFixture(t)
+	dest := filepath.Join(t.TempDir(), "clone")
+	_, _, err := evaluateAndApply(t, newRepoStep(t, "dotfiles", config.RepoStep{URL: remote.URL, Destination: dest}))
+	require.NoError(t, err)
+
+	wt, err := remote.work.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("dev"), Create: true}))
+	dev := remote.commit("dev work")
+
+	step := newRepoStep(t, "dotfiles", config.RepoStep{URL: remote.URL, Destination: dest, Branch: "dev", Update: true})
+	eval, result, err := evaluateAndApply(t, step)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusDrifted, eval.CurrentState)
+	require.Contains(t, eval.Message, "current branch is master")
+	require.Equal(t, "checked out branch dev", result.Message)
+	require.Equal(t, "dev", headOf(t, dest).Name().Short())
+	require.Equal(t, dev, headOf(t, dest).Hash())
+}
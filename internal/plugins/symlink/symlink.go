@@ -114,6 +114,11 @@ func (p *symlinkPlugin) Apply(ctx context.Context, evalResult *model.EvaluationR
 		return nil, plugin.NewValidationError(step.ID, err)
 	}
 
+	// Record the link's prior state (old target, replaced file or absence) for rollback
+	if err := plugin.JournalFromContext(ctx).SavePath(cfg.Target); err != nil {
+		return nil, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to journal %s: %w", cfg.Target, err))
+	}
+
 	if err := os.MkdirAll(filepath.Dir(cfg.Target), 0o755); err != nil {
 		return nil, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to create directory: %w", err))
 	}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/alexisbeaulieu97/streamy/internal/config"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
 	"gopkg.in/yaml.v3"
 )
 
@@ -142,6 +143,40 @@ func TestSymlinkPlugin_ApplyOverwritesExisting(t *testing.T) {
 	require.Equal(t, sourceFile, target)
 }
 
+// recordingJournal captures the paths a plugin journals before changing them.
+type recordingJournal struct {
+	paths []string
+}
+
+func (r *recordingJournal) SavePath(path string) error {
+	r.paths = append(r.paths, path)
+	return nil
+}
+
+func (r *recordingJournal) SaveCheckout(dir, _, _, _ string) error {
+	r.paths = append(r.paths, dir)
+	return nil
+}
+
+func TestSymlinkPlugin_ApplyJournalsTarget(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := filepath.Join(t.TempDir(), "linked")
+
+	sourceFile := filepath.Join(sourceDir, "file.txt")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("hello"), 0o644))
+
+	step := &config.Step{ID: "journal_link", Type: "symlink"}
+	require.NoError(t, step.SetConfig(config.SymlinkStep{Source: sourceFile, Target: targetDir}))
+
+	journal := &recordingJournal{}
+	ctx := plugin.ContextWithJournal(context.Background(), journal)
+
+	_, err := New().Apply(ctx, &model.EvaluationResult{StepID: step.ID, RequiresAction: true}, step)
+	require.NoError(t, err)
+	require.Equal(t, []string{targetDir}, journal.paths)
+	require.False(t, New().PluginMetadata().Irreversible)
+}
+
 func TestSymlinkPlugin_EvaluateForDryRun(t *testing.T) {
 	sourceDir := t.TempDir()
 	targetDir := filepath.Join(t.TempDir(), "linked")
@@ -8,9 +8,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
 	"github.com/alexisbeaulieu97/streamy/pkg/diff"
@@ -30,6 +32,9 @@ type templateEvaluationData struct {
 
 type templatePlugin struct{}
 
+// factsKey is the template field host facts are exposed under.
+const factsKey = "Facts"
+
 // New creates a new instance of the template plugin.
 func New() plugin.Plugin {
 	return &templatePlugin{}
@@ -90,7 +95,7 @@ func (p *templatePlugin) Evaluate(ctx context.Context, step *config.Step) (*mode
 	var renderedHash string
 	var renderErr error
 
-	if len(cfg.Vars) == 0 {
+	if !needsRendering(cfg) {
 		// No variables - treat as literal copy, but validate template syntax first
 		templateContent, readErr := os.ReadFile(cfg.Source)
 		if readErr != nil {
@@ -233,6 +238,16 @@ func (p *templatePlugin) Apply(ctx context.Context, evalResult *model.Evaluation
 		}, nil
 	}
 
+	// Record the destination's prior state so the write can be rolled back
+	if err := plugin.JournalFromContext(ctx).SavePath(cfg.Destination); err != nil {
+		return &model.StepResult{
+			StepID:  step.ID,
+			Status:  model.StatusFailed,
+			Message: fmt.Sprintf("failed to journal %s: %v", cfg.Destination, err),
+			Error:   err,
+		}, plugin.NewExecutionError(step.ID, fmt.Errorf("failed to journal destination: %w", err))
+	}
+
 	// Create destination directory if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(cfg.Destination), 0755); err != nil {
 		return &model.StepResult{
@@ -313,13 +328,42 @@ func (p *templatePlugin) renderTemplate(ctx context.Context, cfg *config.Templat
 	}
 
 	var renderedContent bytes.Buffer
-	if err := tmpl.Execute(&renderedContent, cfg.Vars); err != nil {
+	if err := tmpl.Execute(&renderedContent, templateData(ctx, cfg, string(templateContent))); err != nil {
 		return "", fmt.Errorf("render template %q: %w", cfg.Source, err)
 	}
 
 	return renderedContent.String(), nil
 }
 
+// needsRendering reports whether the template must be executed rather than copied literally:
+// either variables were supplied or the template refers to host facts.
+func needsRendering(cfg *config.TemplateStep) bool {
+	if len(cfg.Vars) > 0 {
+		return true
+	}
+	content, err := os.ReadFile(cfg.Source)
+	if err != nil {
+		return false
+	}
+	return referencesFacts(string(content))
+}
+
+func referencesFacts(content string) bool {
+	return strings.Contains(content, "."+factsKey)
+}
+
+// templateData merges the step's variables with host facts, exposed as .Facts unless a variable shadows it.
+func templateData(ctx context.Context, cfg *config.TemplateStep, content string) map[string]any {
+	data := make(map[string]any, len(cfg.Vars)+1)
+	for k, v := range cfg.Vars {
+		data[k] = v
+	}
+	if _, shadowed := data[factsKey]; !shadowed && referencesFacts(content) {
+		data[factsKey] = facts.FromContext(ctx).Map()
+	}
+	return data
+}
+
 func hashContent(content string) string {
 	hasher := sha256.New()
 	hasher.Write([]byte(content))
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/facts"
 	"github.com/alexisbeaulieu97/streamy/internal/model"
 	"github.com/alexisbeaulieu97/streamy/internal/plugin"
 	streamyerrors "github.com/alexisbeaulieu97/streamy/pkg/errors"
@@ -167,6 +168,30 @@ func TestTemplatePlugin_ApplyWithVariables(t *testing.T) {
 	require.Equal(t, "Hello Streamy!", string(content))
 }
 
+func TestTemplatePlugin_RendersHostFacts(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join(t.TempDir(), "motd.tmpl")
+	dst := filepath.Join(t.TempDir(), "motd")
+	require.NoError(t, os.WriteFile(src, []byte("{{.Facts.hostname}} runs {{.Facts.distro}}"), 0o644))
+
+	step := &config.Step{ID: "motd", Type: "template"}
+	require.NoError(t, step.SetConfig(config.TemplateStep{Source: src, Destination: dst}))
+
+	ctx := facts.NewContext(context.Background(), facts.NewCache(&facts.Facts{Hostname: "laptop", Distro: "fedora"}))
+
+	p := New()
+	evalResult, err := p.Evaluate(ctx, step)
+	require.NoError(t, err)
+
+	_, err = p.Apply(ctx, evalResult, step)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "laptop runs fedora", string(content))
+}
+
 func TestTemplatePlugin_EvaluateUsesRawConfigWhenStructNil(t *testing.T) {
 	src := filepath.Join(t.TempDir(), "template.txt.tmpl")
 	dst := filepath.Join(t.TempDir(), "output.txt")
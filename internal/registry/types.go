@@ -81,26 +81,30 @@ func (s PipelineStatus) String() string {
 
 // ExecutionResult captures the outcome of a verify or apply operation
 type ExecutionResult struct {
-	PipelineID  string         `json:"pipeline_id"`
-	Operation   string         `json:"operation"` // "verify" or "apply"
-	Status      PipelineStatus `json:"status"`
-	Success     bool           `json:"success"`
-	Summary     string         `json:"summary"`
-	StepCount   int            `json:"step_count"`
-	FailedSteps []string       `json:"failed_steps,omitempty"`
-	StepResults []StepResult   `json:"step_results"`
-	Duration    time.Duration  `json:"duration"`
-	CompletedAt time.Time      `json:"completed_at"`
-	Error       *ErrorDetail   `json:"error,omitempty"`
+	PipelineID     string         `json:"pipeline_id"`
+	Operation      string         `json:"operation"` // "verify" or "apply"
+	Status         PipelineStatus `json:"status"`
+	Success        bool           `json:"success"`
+	Summary        string         `json:"summary"`
+	StepCount      int            `json:"step_count"`
+	FailedSteps    []string       `json:"failed_steps,omitempty"`
+	StepResults    []StepResult   `json:"step_results"`
+	HandlerResults []StepResult   `json:"handler_results,omitempty"` // Handlers notified by changed steps (apply only)
+	Duration       time.Duration  `json:"duration"`
+	CompletedAt    time.Time      `json:"completed_at"`
+	Error          *ErrorDetail   `json:"error,omitempty"`
 }
 
 // StepResult represents the outcome of a single step
 type StepResult struct {
-	StepID   string        `json:"step_id"`
-	Status   string        `json:"status"` // "pending", "running", "success", "failed", "skipped"
-	Message  string        `json:"message,omitempty"`
-	Duration time.Duration `json:"duration"`
-	Error    *ErrorDetail  `json:"error,omitempty"`
+	StepID      string        `json:"step_id"`
+	Status      string        `json:"status"` // "pending", "running", "success", "failed", "skipped"
+	Message     string        `json:"message,omitempty"`
+	SkipReason  string        `json:"skip_reason,omitempty"`
+	Attempts    int           `json:"attempts,omitempty"`     // Set when the step was retried
+	RetryErrors []string      `json:"retry_errors,omitempty"` // Errors of the attempts that were retried
+	Duration    time.Duration `json:"duration"`
+	Error       *ErrorDetail  `json:"error,omitempty"`
 }
 
 // ErrorDetail provides structured error information
@@ -0,0 +1,289 @@
+// Package runlock keeps Streamy processes from changing the same pipeline at once. Each pipeline
+// has an advisory lock file listing the processes holding it: a single apply holds it
+// exclusively, while any number of verifications can share it.
+package runlock
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Mode is how a lock is held.
+type Mode string
+
+const (
+	// Exclusive excludes every other holder; apply takes it.
+	Exclusive Mode = "exclusive"
+	// Shared can be held by several processes at once, but not alongside an exclusive holder.
+	Shared Mode = "shared"
+)
+
+const (
+	defaultPollInterval = 500 * time.Millisecond
+	guardRetryInterval  = 10 * time.Millisecond
+	guardTimeout        = 5 * time.Second
+	// staleGuardAge is how old a guard file must be before it is treated as left behind by a
+	// process that crashed while updating the lock file.
+	staleGuardAge = 10 * time.Second
+)
+
+// ErrLocked matches errors returned when another process holds a conflicting lock.
+var ErrLocked = errors.New("pipeline is locked")
+
+// Holder describes a process holding a pipeline's lock.
+type Holder struct {
+	PID       int       `json:"pid"`
+	Operation string    `json:"operation"`
+	Mode      Mode      `json:"mode"`
+	StartedAt time.Time `json:"started_at"`
+	// Token tells apart holders within one process, such as the dashboard verifying two pipelines.
+	Token string `json:"token"`
+}
+
+// String describes the holder for messages, e.g. "apply (pid 4242, since 15:04:05)".
+func (h Holder) String() string {
+	return fmt.Sprintf("%s (pid %d, since %s)", h.Operation, h.PID, h.StartedAt.Local().Format("15:04:05"))
+}
+
+// LockedError reports the holders that prevented a lock from being taken.
+type LockedError struct {
+	ConfigPath string
+	Holders    []Holder
+}
+
+func (e *LockedError) Error() string {
+	holders := make([]string, 0, len(e.Holders))
+	for _, h := range e.Holders {
+		holders = append(holders, h.String())
+	}
+	return fmt.Sprintf("pipeline %s is locked by %s", e.ConfigPath, strings.Join(holders, ", "))
+}
+
+// Is reports whether target is ErrLocked.
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// lockFile is the on-disk content of a pipeline's lock.
+type lockFile struct {
+	ConfigPath string   `json:"config_path"`
+	Holders    []Holder `json:"holders"`
+}
+
+// Manager takes and inspects pipeline locks stored in one directory.
+type Manager struct {
+	dir          string
+	pid          int
+	alive        func(pid int) bool
+	pollInterval time.Duration
+}
+
+// NewManager returns a manager keeping lock files in dir; the directory is created on first use.
+func NewManager(dir string) *Manager {
+	return &Manager{
+		dir:          dir,
+		pid:          os.Getpid(),
+		alive:        processAlive,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// Acquire takes the lock of the pipeline at configPath for operation. When another process holds
+// it in a conflicting mode, Acquire returns a *LockedError, or with wait set, blocks until the
+// lock frees or ctx is done.
+func (m *Manager) Acquire(ctx context.Context, configPath, operation string, mode Mode, wait bool) (*Lock, error) {
+	for {
+		lock, err := m.tryAcquire(configPath, operation, mode)
+		if err == nil || !wait || !errors.Is(err, ErrLocked) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(m.pollInterval):
+		}
+	}
+}
+
+func (m *Manager) tryAcquire(configPath, operation string, mode Mode) (*Lock, error) {
+	abs, path, err := m.path(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	holder := Holder{PID: m.pid, Operation: operation, Mode: mode, StartedAt: time.Now().UTC(), Token: newToken()}
+	err = m.update(path, func(f *lockFile) error {
+		for _, h := range f.Holders {
+			if mode == Exclusive || h.Mode == Exclusive {
+				return &LockedError{ConfigPath: abs, Holders: append([]Holder(nil), f.Holders...)}
+			}
+		}
+		f.ConfigPath = abs
+		f.Holders = append(f.Holders, holder)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{manager: m, path: path, holder: holder}, nil
+}
+
+// Holders lists the live processes holding the lock of the pipeline at configPath.
+func (m *Manager) Holders(configPath string) ([]Holder, error) {
+	_, path, err := m.path(configPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := readLockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return m.live(f.Holders), nil
+}
+
+// update applies fn to a pipeline's lock file while holding its guard. Holders whose process
+// has exited are dropped first, so a crashed run never keeps a pipeline locked.
+func (m *Manager) update(path string, fn func(*lockFile) error) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	release, err := m.guard(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	f, err := readLockFile(path)
+	if err != nil {
+		return err
+	}
+	f.Holders = m.live(f.Holders)
+	if err := fn(f); err != nil {
+		return err
+	}
+	return writeLockFile(path, f)
+}
+
+// guard serialises updates of a lock file through a companion file created exclusively.
+func (m *Manager) guard(path string) (func(), error) {
+	guardPath := path + ".guard"
+	deadline := time.Now().Add(guardTimeout)
+	for {
+		file, err := os.OpenFile(guardPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = file.Close()
+			return func() { _ = os.Remove(guardPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if info, statErr := os.Stat(guardPath); statErr == nil && time.Since(info.ModTime()) > staleGuardAge {
+			_ = os.Remove(guardPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting to update %s", path)
+		}
+		time.Sleep(guardRetryInterval)
+	}
+}
+
+func (m *Manager) live(holders []Holder) []Holder {
+	live := make([]Holder, 0, len(holders))
+	for _, h := range holders {
+		if m.alive(h.PID) {
+			live = append(live, h)
+		}
+	}
+	return live
+}
+
+// path names lock files after the absolute config path so one pipeline maps to one file.
+func (m *Manager) path(configPath string) (string, string, error) {
+	abs, err := filepath.Abs(configPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %w", configPath, err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return abs, filepath.Join(m.dir, hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// Lock is a held pipeline lock.
+type Lock struct {
+	manager *Manager
+	path    string
+	holder  Holder
+}
+
+// Holder describes this lock's entry in the lock file.
+func (l *Lock) Holder() Holder {
+	return l.holder
+}
+
+// Release gives up the lock; releasing twice is harmless.
+func (l *Lock) Release() error {
+	return l.manager.update(l.path, func(f *lockFile) error {
+		kept := f.Holders[:0]
+		for _, h := range f.Holders {
+			if h.Token != l.holder.Token {
+				kept = append(kept, h)
+			}
+		}
+		f.Holders = kept
+		return nil
+	})
+}
+
+func readLockFile(path string) (*lockFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &lockFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	var f lockFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// writeLockFile replaces the lock file atomically, removing it once nobody holds the lock.
+func writeLockFile(path string, f *lockFile) error {
+	if len(f.Holders) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove lock file: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock file: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return nil
+}
+
+func newToken() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
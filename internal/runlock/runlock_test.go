@@ -0,0 +1,138 @@
+package runlock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager(filepath.Join(t.TempDir(), "locks"))
+	m.pollInterval = 10 * time.Millisecond
+	return m
+}
+
+func TestManager_ExclusiveExcludesEveryone(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	apply, err := m.Acquire(ctx, "streamy.yaml", "apply", Exclusive, false)
+	require.NoError(t, err)
+
+	_, err = m.Acquire(ctx, "streamy.yaml", "apply", Exclusive, false)
+	require.ErrorIs(t, err, ErrLocked)
+	var locked *LockedError
+	require.ErrorAs(t, err, &locked)
+	require.Len(t, locked.Holders, 1)
+	require.Equal(t, "apply", locked.Holders[0].Operation)
+	require.Equal(t, os.Getpid(), locked.Holders[0].PID)
+	require.Contains(t, err.Error(), "is locked by apply (pid")
+
+	_, err = m.Acquire(ctx, "streamy.yaml", "verify", Shared, false)
+	require.ErrorIs(t, err, ErrLocked)
+
+	other, err := m.Acquire(ctx, "other.yaml", "apply", Exclusive, false)
+	require.NoError(t, err, "locks are per pipeline")
+	require.NoError(t, other.Release())
+
+	require.NoError(t, apply.Release())
+	require.NoError(t, apply.Release(), "releasing twice is harmless")
+
+	verify, err := m.Acquire(ctx, "streamy.yaml", "verify", Shared, false)
+	require.NoError(t, err)
+	require.NoError(t, verify.Release())
+}
+
+func TestManager_SharedHoldersCoexist(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	first, err := m.Acquire(ctx, "streamy.yaml", "verify", Shared, false)
+	require.NoError(t, err)
+	second, err := m.Acquire(ctx, "streamy.yaml", "verify", Shared, false)
+	require.NoError(t, err)
+
+	holders, err := m.Holders("streamy.yaml")
+	require.NoError(t, err)
+	require.Len(t, holders, 2)
+
+	_, err = m.Acquire(ctx, "streamy.yaml", "apply", Exclusive, false)
+	require.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, first.Release())
+	require.NoError(t, second.Release())
+
+	holders, err = m.Holders("streamy.yaml")
+	require.NoError(t, err)
+	require.Empty(t, holders)
+	entries, err := os.ReadDir(m.dir)
+	require.NoError(t, err)
+	require.Empty(t, entries, "the lock file is removed once nobody holds it")
+}
+
+func TestManager_StaleHoldersAreIgnored(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	crashed, err := m.Acquire(ctx, "streamy.yaml", "apply", Exclusive, false)
+	require.NoError(t, err)
+
+	dead := crashed.Holder().PID
+	m.alive = func(pid int) bool { return pid != dead }
+	m.pid = dead + 1
+
+	holders, err := m.Holders("streamy.yaml")
+	require.NoError(t, err)
+	require.Empty(t, holders)
+
+	lock, err := m.Acquire(ctx, "streamy.yaml", "apply", Exclusive, false)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+}
+
+func TestManager_WaitBlocksUntilReleased(t *testing.T) {
+	m := newTestManager(t)
+
+	held, err := m.Acquire(context.Background(), "streamy.yaml", "apply", Exclusive, false)
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = held.Release()
+	}()
+
+	lock, err := m.Acquire(context.Background(), "streamy.yaml", "verify", Shared, true)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+
+	held, err = m.Acquire(context.Background(), "streamy.yaml", "apply", Exclusive, false)
+	require.NoError(t, err)
+	defer func() { _ = held.Release() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = m.Acquire(ctx, "streamy.yaml", "apply", Exclusive, true)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestManager_RecoversStaleGuard(t *testing.T) {
+	m := newTestManager(t)
+	_, path, err := m.path("streamy.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(m.dir, 0o700))
+
+	guardPath := path + ".guard"
+	require.NoError(t, os.WriteFile(guardPath, nil, 0o600))
+	old := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(guardPath, old, old))
+
+	lock, err := m.Acquire(context.Background(), "streamy.yaml", "apply", Exclusive, false)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+}
@@ -3,6 +3,8 @@ package components
 import (
 	"fmt"
 	"strings"
+
+	"github.com/alexisbeaulieu97/streamy/internal/model"
 )
 
 // ValidationStatus represents a validation outcome for summary rendering.
@@ -11,12 +13,20 @@ type ValidationStatus struct {
 	Message string
 }
 
+// HandlerStatus represents a handler that ran at the end of the run.
+type HandlerStatus struct {
+	ID      string
+	Status  string
+	Message string
+}
+
 // SummaryData aggregates counts for rendering summaries.
 type SummaryData struct {
 	Total       int
 	Completed   int
 	Finished    bool
 	Cancelled   bool
+	Handlers    []HandlerStatus
 	Validations []ValidationStatus
 }
 
@@ -47,6 +57,17 @@ func (s Summary) View() string {
 		}
 	}
 
+	if len(s.data.Handlers) > 0 {
+		lines = append(lines, "Handlers:")
+		for _, h := range s.data.Handlers {
+			line := fmt.Sprintf("  %s %s", handlerIcon(h.Status), h.ID)
+			if strings.TrimSpace(h.Message) != "" {
+				line = fmt.Sprintf("%s — %s", line, h.Message)
+			}
+			lines = append(lines, line)
+		}
+	}
+
 	if len(s.data.Validations) > 0 {
 		lines = append(lines, "Validations:")
 		for _, v := range s.data.Validations {
@@ -60,3 +81,16 @@ func (s Summary) View() string {
 
 	return strings.Join(lines, "\n")
 }
+
+func handlerIcon(status string) string {
+	switch status {
+	case model.StatusSuccess, model.StatusWouldCreate, model.StatusWouldUpdate:
+		return "✓"
+	case model.StatusFailed:
+		return "✗"
+	case model.StatusFailedIgnored:
+		return "!"
+	default:
+		return "⊘"
+	}
+}
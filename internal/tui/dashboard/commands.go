@@ -8,6 +8,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 
 	pipelineapp "github.com/alexisbeaulieu97/streamy/internal/app/pipeline"
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
 	"github.com/alexisbeaulieu97/streamy/internal/logger"
 	"github.com/alexisbeaulieu97/streamy/internal/registry"
 )
@@ -62,14 +63,27 @@ func verifyCmd(ctx context.Context, pipelineID string, configPath string, svc *p
 	}
 }
 
-// applyCmd runs apply for a pipeline asynchronously
-func applyCmd(ctx context.Context, pipelineID string, configPath string, svc *pipelineapp.Service) tea.Cmd {
+// applyCmd runs apply for a pipeline asynchronously. When progress is non-nil, step events
+// are sent to it while the run is in progress and it is closed once the run ends.
+func applyCmd(ctx context.Context, pipelineID string, configPath string, svc *pipelineapp.Service, progress chan<- ApplyProgressMsg) tea.Cmd {
 	return func() tea.Msg {
-		outcome, err := svc.Apply(ctx, pipelineapp.ApplyRequest{
+		req := pipelineapp.ApplyRequest{
 			ConfigPath:      configPath,
 			LoggerOptions:   logger.Options{Level: "error", HumanReadable: false},
 			ContinueOnError: false,
-		})
+		}
+		if progress != nil {
+			defer close(progress)
+			req.OnEvent = func(e engine.Event) {
+				// Never stall the run on a slow UI; progress is best effort.
+				select {
+				case progress <- ApplyProgressMsg{PipelineID: pipelineID, Event: e}:
+				default:
+				}
+			}
+		}
+
+		outcome, err := svc.Apply(ctx, req)
 
 		if err != nil {
 			// Context cancellation
@@ -97,6 +111,31 @@ func applyCmd(ctx context.Context, pipelineID string, configPath string, svc *pi
 	}
 }
 
+// lockHoldersCmd looks up who holds a pipeline's run lock
+func lockHoldersCmd(pipelineID string, configPath string, svc *pipelineapp.Service) tea.Cmd {
+	if svc == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		// An unreadable lock file only hides the holder; the next operation reports the error.
+		holders, _ := svc.LockHolders(configPath)
+		return LockHoldersMsg{PipelineID: pipelineID, Holders: holders}
+	}
+}
+
+// listenApplyProgressCmd waits for the next event of a running apply; it returns nil once the
+// apply has finished.
+func listenApplyProgressCmd(progress <-chan ApplyProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-progress
+		if !ok {
+			return nil
+		}
+		msg.source = progress
+		return msg
+	}
+}
+
 // refreshAllCmd runs verification for all pipelines in parallel
 func refreshAllCmd(ctx context.Context, pipelines []registry.Pipeline, _ *pipelineapp.Service) tea.Cmd {
 	return func() tea.Msg {
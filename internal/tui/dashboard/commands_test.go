@@ -93,7 +93,7 @@ steps:
 	svc := pipelineapp.NewService(pluginReg)
 	ctx := context.Background()
 
-	cmd := applyCmd(ctx, "test-1", configPath, svc)
+	cmd := applyCmd(ctx, "test-1", configPath, svc, nil)
 	assert.NotNil(t, cmd)
 
 	// Execute command
@@ -197,7 +197,7 @@ func TestApplyCmd_InvalidPipeline(t *testing.T) {
 	svc := pipelineapp.NewService(pluginReg)
 	ctx := context.Background()
 
-	cmd := applyCmd(ctx, "test-1", "/nonexistent/path.yaml", svc)
+	cmd := applyCmd(ctx, "test-1", "/nonexistent/path.yaml", svc, nil)
 	assert.NotNil(t, cmd)
 
 	msg := cmd()
@@ -3,7 +3,9 @@ package dashboard
 import (
 	"time"
 
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
 	"github.com/alexisbeaulieu97/streamy/internal/registry"
+	"github.com/alexisbeaulieu97/streamy/internal/runlock"
 )
 
 // ViewMode determines which screen to render
@@ -64,6 +66,14 @@ type ApplyStartedMsg struct {
 	StartTime  time.Time
 }
 
+// ApplyProgressMsg carries a step event from a running apply
+type ApplyProgressMsg struct {
+	PipelineID string
+	Event      engine.Event
+
+	source <-chan ApplyProgressMsg
+}
+
 // ApplyCompleteMsg indicates apply completed successfully
 type ApplyCompleteMsg struct {
 	PipelineID string
@@ -148,6 +158,14 @@ type ConfirmResponseMsg struct {
 	Confirmed  bool
 }
 
+// Lock Messages
+
+// LockHoldersMsg reports which processes hold a pipeline's run lock
+type LockHoldersMsg struct {
+	PipelineID string
+	Holders    []runlock.Holder
+}
+
 // Cancel Messages
 
 // CancelOperationMsg requests cancellation of operation
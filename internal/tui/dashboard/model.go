@@ -10,6 +10,7 @@ import (
 
 	pipelineapp "github.com/alexisbeaulieu97/streamy/internal/app/pipeline"
 	"github.com/alexisbeaulieu97/streamy/internal/registry"
+	"github.com/alexisbeaulieu97/streamy/internal/runlock"
 )
 
 // Model is the main dashboard model
@@ -33,6 +34,8 @@ type Model struct {
 	loading       map[string]bool
 	operations    map[string]Operation
 	operationCtxs map[string]context.CancelFunc
+	progress      map[string]ApplyProgress
+	lockHolders   map[string][]runlock.Holder
 	errors        map[string]string
 	showError     bool
 	errorMsg      string
@@ -64,6 +67,13 @@ type Operation struct {
 	StartedAt  time.Time
 }
 
+// ApplyProgress summarises the live step events of a running apply
+type ApplyProgress struct {
+	Finished    int
+	CurrentStep string
+	LastLine    string
+}
+
 // NewModel creates a new dashboard model
 func NewModel(pipelines []registry.Pipeline, reg *registry.Registry, cache *registry.StatusCache, svc *pipelineapp.Service) Model {
 	s := spinner.New()
@@ -80,6 +90,8 @@ func NewModel(pipelines []registry.Pipeline, reg *registry.Registry, cache *regi
 		loading:         make(map[string]bool),
 		operations:      make(map[string]Operation),
 		operationCtxs:   make(map[string]context.CancelFunc),
+		progress:        make(map[string]ApplyProgress),
+		lockHolders:     make(map[string][]runlock.Holder),
 		errors:          make(map[string]string),
 		spinner:         s,
 		confirmations:   true,
@@ -2,6 +2,7 @@ package dashboard
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -9,7 +10,9 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
 	"github.com/alexisbeaulieu97/streamy/internal/registry"
+	"github.com/alexisbeaulieu97/streamy/internal/runlock"
 )
 
 // Update handles incoming messages and updates the model
@@ -72,10 +75,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, saveVerifyStatusToCacheCmd(m.statusCache, msg.PipelineID, msg.Result)
 
 	case VerifyErrorMsg:
-		m.UpdatePipelineStatus(msg.PipelineID, registry.StatusFailed, time.Now())
 		delete(m.loading, msg.PipelineID)
 		delete(m.operations, msg.PipelineID)
 		delete(m.operationCtxs, msg.PipelineID)
+		if m.handleLocked(msg.PipelineID, msg.Error) {
+			return m, nil
+		}
+		m.UpdatePipelineStatus(msg.PipelineID, registry.StatusFailed, time.Now())
 		m.errors[msg.PipelineID] = msg.Error.Error()
 		m.showError = true
 		m.errorMsg = fmt.Sprintf("Verification failed: %s", msg.Error.Error())
@@ -91,8 +97,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ApplyStartedMsg:
 		return m, m.spinner.Tick
 
+	case ApplyProgressMsg:
+		progress := m.progress[msg.PipelineID]
+		switch msg.Event.Type {
+		case engine.StepStarted:
+			progress.CurrentStep = msg.Event.StepID
+		case engine.StepOutput:
+			progress.CurrentStep = msg.Event.StepID
+			progress.LastLine = msg.Event.Line
+		case engine.StepFinished:
+			progress.Finished++
+		}
+		m.progress[msg.PipelineID] = progress
+		if msg.source == nil {
+			return m, nil
+		}
+		return m, listenApplyProgressCmd(msg.source)
+
 	case ApplyCompleteMsg:
 		m.UpdatePipelineStatus(msg.PipelineID, msg.Result.Status, time.Now())
+		delete(m.progress, msg.PipelineID)
 		delete(m.loading, msg.PipelineID)
 		delete(m.operations, msg.PipelineID)
 		delete(m.operationCtxs, msg.PipelineID)
@@ -116,16 +140,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 
 	case ApplyErrorMsg:
-		m.UpdatePipelineStatus(msg.PipelineID, registry.StatusFailed, time.Now())
+		delete(m.progress, msg.PipelineID)
 		delete(m.loading, msg.PipelineID)
 		delete(m.operations, msg.PipelineID)
 		delete(m.operationCtxs, msg.PipelineID)
+		if m.handleLocked(msg.PipelineID, msg.Error) {
+			return m, nil
+		}
+		m.UpdatePipelineStatus(msg.PipelineID, registry.StatusFailed, time.Now())
 		m.errors[msg.PipelineID] = msg.Error.Error()
 		m.showError = true
 		m.errorMsg = fmt.Sprintf("Apply failed: %s", msg.Error.Error())
 		return m, nil
 
 	case ApplyCancelledMsg:
+		delete(m.progress, msg.PipelineID)
 		delete(m.loading, msg.PipelineID)
 		delete(m.operations, msg.PipelineID)
 		delete(m.operationCtxs, msg.PipelineID)
@@ -181,11 +210,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.refreshTotal = 0
 		return m, nil
 
+	case LockHoldersMsg:
+		if len(msg.Holders) == 0 {
+			delete(m.lockHolders, msg.PipelineID)
+		} else {
+			m.lockHolders[msg.PipelineID] = msg.Holders
+		}
+		return m, nil
+
 	// Navigation messages (will be fully implemented in US2)
 	case PipelineSelectedMsg:
 		m.selectedID = msg.Pipeline.ID
 		m.viewMode = ViewDetail
-		return m, nil
+		return m, lockHoldersCmd(msg.Pipeline.ID, msg.Pipeline.Path, m.service)
 
 	case BackToListMsg:
 		m.viewMode = ViewList
@@ -207,6 +244,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleLocked reports an operation refused because another process holds the pipeline's run
+// lock. The pipeline was not evaluated, so its status is left alone.
+func (m *Model) handleLocked(pipelineID string, err error) bool {
+	var locked *runlock.LockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	m.lockHolders[pipelineID] = locked.Holders
+	m.showError = true
+	m.errorMsg = fmt.Sprintf("Pipeline busy: %s", err.Error())
+	return true
+}
+
 // handleKeyPress handles keyboard input based on current view mode
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch m.viewMode {
@@ -261,6 +311,7 @@ func (m Model) handleListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if selected, ok := m.GetSelectedPipeline(); ok {
 			m.selectedID = selected.ID
 			m.viewMode = ViewDetail
+			return m, lockHoldersCmd(selected.ID, selected.Path, m.service)
 		}
 		return m, nil
 
@@ -485,9 +536,11 @@ func (m Model) handleConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				StartedAt:  time.Now(),
 			}
 
-			// Return to detail view and start apply
+			// Return to detail view and start apply, following its progress as it runs
+			progress := make(chan ApplyProgressMsg, 64)
+			m.progress[selected.ID] = ApplyProgress{}
 			m.viewMode = ViewDetail
-			return m, applyCmd(ctx, selected.ID, selected.Path, m.service)
+			return m, tea.Batch(applyCmd(ctx, selected.ID, selected.Path, m.service, progress), listenApplyProgressCmd(progress))
 
 		case "cancel_verify", "cancel_apply":
 			// Cancel the operation
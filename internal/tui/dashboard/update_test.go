@@ -10,7 +10,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
 	"github.com/alexisbeaulieu97/streamy/internal/registry"
+	"github.com/alexisbeaulieu97/streamy/internal/runlock"
 )
 
 func TestUpdate_WindowSizeMsg(t *testing.T) {
@@ -181,6 +183,45 @@ func TestUpdate_ApplyCompleteMsg(t *testing.T) {
 	assert.True(t, dashModel.loading["test-1"])
 }
 
+func TestUpdate_ApplyProgressMsg(t *testing.T) {
+	tmpDir := t.TempDir()
+	reg, err := registry.NewRegistry(filepath.Join(tmpDir, "registry.json"))
+	require.NoError(t, err)
+
+	cache, err := registry.NewStatusCache(filepath.Join(tmpDir, "cache.json"))
+	require.NoError(t, err)
+
+	pipelines := []registry.Pipeline{
+		{ID: "test-1", Name: "Test 1", Status: registry.StatusDrifted},
+	}
+	m := NewModel(pipelines, reg, cache, nil)
+	m.loading["test-1"] = true
+
+	progress := make(chan ApplyProgressMsg, 3)
+	progress <- ApplyProgressMsg{PipelineID: "test-1", Event: engine.Event{Type: engine.StepFinished, StepID: "install"}}
+	progress <- ApplyProgressMsg{PipelineID: "test-1", Event: engine.Event{Type: engine.StepStarted, StepID: "configure"}}
+	progress <- ApplyProgressMsg{PipelineID: "test-1", Event: engine.Event{Type: engine.StepOutput, StepID: "configure", Line: "writing ~/.gitconfig"}}
+	close(progress)
+
+	var model tea.Model = m
+	cmd := listenApplyProgressCmd(progress)
+	for cmd != nil {
+		msg := cmd()
+		if msg == nil {
+			break
+		}
+		model, cmd = model.Update(msg)
+	}
+
+	dashModel, ok := model.(Model)
+	require.True(t, ok)
+	assert.Equal(t, ApplyProgress{Finished: 1, CurrentStep: "configure", LastLine: "writing ~/.gitconfig"}, dashModel.progress["test-1"])
+
+	newModel, _ := dashModel.Update(ApplyErrorMsg{PipelineID: "test-1", Error: assert.AnError})
+	dashModel = newModel.(Model)
+	assert.NotContains(t, dashModel.progress, "test-1")
+}
+
 func TestUpdate_ApplyErrorMsg(t *testing.T) {
 	tmpDir := t.TempDir()
 	reg, err := registry.NewRegistry(filepath.Join(tmpDir, "registry.json"))
@@ -210,6 +251,43 @@ func TestUpdate_ApplyErrorMsg(t *testing.T) {
 	assert.True(t, dashModel.showError)
 }
 
+func TestUpdate_ApplyErrorMsg_Locked(t *testing.T) {
+	tmpDir := t.TempDir()
+	reg, err := registry.NewRegistry(filepath.Join(tmpDir, "registry.json"))
+	require.NoError(t, err)
+
+	cache, err := registry.NewStatusCache(filepath.Join(tmpDir, "cache.json"))
+	require.NoError(t, err)
+
+	pipelines := []registry.Pipeline{
+		{ID: "test-1", Name: "Test 1", Status: registry.StatusDrifted},
+	}
+	m := NewModel(pipelines, reg, cache, nil)
+	m.loading["test-1"] = true
+	m.selectedID = "test-1"
+	m.viewMode = ViewDetail
+
+	holder := runlock.Holder{PID: 4242, Operation: "apply", Mode: runlock.Exclusive, StartedAt: time.Now()}
+	newModel, _ := m.Update(ApplyErrorMsg{
+		PipelineID: "test-1",
+		Error:      &runlock.LockedError{ConfigPath: "/tmp/streamy.yaml", Holders: []runlock.Holder{holder}},
+	})
+	dashModel, ok := newModel.(Model)
+	require.True(t, ok)
+
+	// The pipeline was never evaluated, so its status stays as it was.
+	assert.Equal(t, m.pipelines[0].Status, dashModel.pipelines[0].Status)
+	assert.NotEqual(t, registry.StatusFailed, dashModel.pipelines[0].Status)
+	assert.False(t, dashModel.loading["test-1"])
+	assert.True(t, dashModel.showError)
+	assert.Contains(t, dashModel.errorMsg, "Pipeline busy")
+	assert.Contains(t, dashModel.View(), "Locked By")
+
+	newModel, _ = dashModel.Update(LockHoldersMsg{PipelineID: "test-1"})
+	dashModel = newModel.(Model)
+	assert.NotContains(t, dashModel.lockHolders, "test-1")
+}
+
 func TestUpdate_RefreshStartedMsg(t *testing.T) {
 	tmpDir := t.TempDir()
 	reg, err := registry.NewRegistry(filepath.Join(tmpDir, "registry.json"))
@@ -323,6 +323,13 @@ func (m Model) renderDetailView() string {
 	if !selected.LastRun.IsZero() {
 		metaRows = append(metaRows, formatDetailRow("Last Run", FormatLastRun(selected.LastRun)))
 	}
+	if holders := m.lockHolders[selected.ID]; len(holders) > 0 {
+		names := make([]string, 0, len(holders))
+		for _, holder := range holders {
+			names = append(names, holder.String())
+		}
+		metaRows = append(metaRows, formatDetailRow("Locked By", strings.Join(names, ", ")))
+	}
 	if metaSection := renderSection("Metadata", metaRows); metaSection != "" {
 		content.WriteString(metaSection)
 		content.WriteString("\n")
@@ -346,6 +353,7 @@ func (m Model) renderDetailView() string {
 		// Count step statuses
 		successCount := 0
 		failedCount := 0
+		conditionSkipped := 0
 		for _, step := range selected.LastResult.StepResults {
 			switch step.Status {
 			case "success":
@@ -353,8 +361,22 @@ func (m Model) renderDetailView() string {
 			case "failed":
 				failedCount++
 			}
+			if step.SkipReason != "" {
+				conditionSkipped++
+			}
+		}
+		stepSummary := fmt.Sprintf("%d success, %d failed", successCount, failedCount)
+		if conditionSkipped > 0 {
+			stepSummary = fmt.Sprintf("%s, %d skipped by condition", stepSummary, conditionSkipped)
+		}
+		execRows = append(execRows, formatDetailRow("Summary", stepSummary))
+		if handlers := selected.LastResult.HandlerResults; len(handlers) > 0 {
+			ids := make([]string, 0, len(handlers))
+			for _, handler := range handlers {
+				ids = append(ids, fmt.Sprintf("%s (%s)", handler.StepID, handler.Status))
+			}
+			execRows = append(execRows, formatDetailRow("Handlers", strings.Join(ids, ", ")))
 		}
-		execRows = append(execRows, formatDetailRow("Summary", fmt.Sprintf("%d success, %d failed", successCount, failedCount)))
 
 		// Show error if present
 		if selected.LastResult.Error != nil {
@@ -382,6 +404,14 @@ func (m Model) renderDetailView() string {
 			opMsg := fmt.Sprintf("%s %s in progress...", m.spinner.View(), op.Type)
 			content.WriteString(progressStyle.Render(opMsg))
 			content.WriteString("\n")
+			if progress, ok := m.progress[selected.ID]; ok && progress.CurrentStep != "" {
+				content.WriteString(detailValueStyle.Render(fmt.Sprintf("  %d steps finished • running %s", progress.Finished, progress.CurrentStep)))
+				content.WriteString("\n")
+				if progress.LastLine != "" {
+					content.WriteString(detailValueStyle.Render("  " + progress.LastLine))
+					content.WriteString("\n")
+				}
+			}
 		}
 	}
 
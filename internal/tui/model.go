@@ -22,6 +22,18 @@ type StepCompleteMsg struct {
 	Result model.StepResult
 }
 
+// StepOutputMsg carries one line of output written by a running step.
+type StepOutputMsg struct {
+	ID     string
+	Stream string
+	Line   string
+}
+
+// HandlerCompleteMsg reports a handler that ran at the end of the run.
+type HandlerCompleteMsg struct {
+	Result model.StepResult
+}
+
 // ValidationMsg carries the outcome of a validation.
 type ValidationMsg struct {
 	Passed  bool
@@ -30,18 +42,39 @@ type ValidationMsg struct {
 
 type tickMsg struct{}
 
+// maxLogLines bounds how many recent output lines are kept per step.
+const maxLogLines = 10
+
+// EventMsg translates an engine event into the message the model handles, or nil
+// when the model has no use for it.
+func EventMsg(e engine.Event) tea.Msg {
+	switch e.Type {
+	case engine.StepStarted:
+		return StepStartMsg{ID: e.StepID, Time: e.Time}
+	case engine.StepOutput:
+		return StepOutputMsg{ID: e.StepID, Stream: e.Stream, Line: e.Line}
+	default:
+		return nil
+	}
+}
+
 // Model contains the Bubbletea state for Streamy's execution TUI.
 type Model struct {
 	cfg            *config.Config
 	plan           *engine.ExecutionPlan
 	steps          map[string]model.StepResult
 	order          []string
+	filtered       []string
+	handlers       []components.HandlerStatus
 	validations    []components.ValidationStatus
+	logs           map[string][]string
+	logStep        string
 	total          int
 	completed      int
 	finished       bool
 	cancelled      bool
 	nonInteractive bool
+	onCancel       func()
 }
 
 // NewModel constructs a new TUI model for the given configuration and plan.
@@ -52,6 +85,7 @@ func NewModel(cfg *config.Config, plan *engine.ExecutionPlan, nonInteractive boo
 		steps:          make(map[string]model.StepResult),
 		order:          make([]string, 0),
 		validations:    make([]components.ValidationStatus, 0),
+		logs:           make(map[string][]string),
 		nonInteractive: nonInteractive,
 	}
 
@@ -67,6 +101,22 @@ func NewModel(cfg *config.Config, plan *engine.ExecutionPlan, nonInteractive boo
 		}
 	}
 
+	// Enabled steps missing from the plan were left out by --tags, --only and friends.
+	if cfg != nil && plan != nil {
+		for _, step := range cfg.Steps {
+			if _, planned := m.steps[step.ID]; step.Enabled && !planned {
+				m.filtered = append(m.filtered, step.ID)
+			}
+		}
+	}
+
+	return m
+}
+
+// WithCancel returns a copy of the model that calls cancel when the user presses Ctrl-C, which
+// the terminal delivers as a key press rather than a signal while the TUI is running.
+func (m Model) WithCancel(cancel func()) Model {
+	m.onCancel = cancel
 	return m
 }
 
@@ -101,6 +151,15 @@ func (m *Model) ensureStep(id string) {
 	}
 }
 
+func (m *Model) appendLog(id, line string) {
+	lines := append(m.logs[id], line)
+	if len(lines) > maxLogLines {
+		lines = lines[len(lines)-maxLogLines:]
+	}
+	m.logs[id] = lines
+	m.logStep = id
+}
+
 func (m *Model) markFinishedIfComplete() {
 	if m.total > 0 && m.completed >= m.total {
 		m.finished = true
@@ -9,7 +9,9 @@ var (
 	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
 	runningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("33"))
 	failureStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 	skippedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
 	pendingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 	summaryStyle = lipgloss.NewStyle().MarginTop(1)
+	logStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).PaddingLeft(2)
 )
@@ -18,6 +18,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		step.Status = model.StatusRunning
 		m.steps[msg.ID] = step
 		return m, nil
+	case StepOutputMsg:
+		if msg.ID == "" {
+			return m, nil
+		}
+		m.ensureStep(msg.ID)
+		m.appendLog(msg.ID, msg.Line)
+		return m, nil
 	case StepCompleteMsg:
 		id := msg.Result.StepID
 		if id == "" {
@@ -25,7 +32,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.ensureStep(id)
 		existing := m.steps[id]
-		previouslyCompleted := existing.Status == model.StatusSuccess || existing.Status == model.StatusSkipped || existing.Status == model.StatusFailed || existing.Status == model.StatusWouldCreate || existing.Status == model.StatusWouldUpdate
+		previouslyCompleted := existing.Status == model.StatusSuccess || existing.Status == model.StatusSkipped || existing.Status == model.StatusFailed || existing.Status == model.StatusFailedIgnored || existing.Status == model.StatusWouldCreate || existing.Status == model.StatusWouldUpdate
 		m.steps[id] = msg.Result
 		if !previouslyCompleted {
 			m.completed++
@@ -35,6 +42,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.finished = true
 		}
 		return m, nil
+	case HandlerCompleteMsg:
+		m.handlers = append(m.handlers, components.HandlerStatus{ID: msg.Result.StepID, Status: msg.Result.Status, Message: msg.Result.Message})
+		return m, nil
 	case ValidationMsg:
 		m.validations = append(m.validations, components.ValidationStatus{Passed: msg.Passed, Message: msg.Message})
 		return m, nil
@@ -42,6 +52,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.Type == tea.KeyCtrlC {
 			m.cancelled = true
 			m.finished = true
+			if m.onCancel != nil {
+				m.onCancel()
+			}
 			return m, nil
 		}
 	case tea.QuitMsg:
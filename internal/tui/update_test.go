@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -28,6 +29,24 @@ func TestUpdateHandlesStepCompletion(t *testing.T) {
 	require.Equal(t, 1, m.completed)
 }
 
+func TestUpdateKeepsRecentStepOutput(t *testing.T) {
+	m := NewModel(&config.Config{}, &engine.ExecutionPlan{Levels: []engine.ExecutionLevel{{StepIDs: []string{"step"}}}}, false)
+	for i := 0; i < maxLogLines+3; i++ {
+		updated, _ := m.Update(StepOutputMsg{ID: "step", Stream: "stdout", Line: fmt.Sprintf("line %d", i)})
+		m = updated.(Model)
+	}
+	require.Len(t, m.logs["step"], maxLogLines)
+	require.Equal(t, "line 3", m.logs["step"][0])
+	require.Equal(t, "step", m.logStep)
+}
+
+func TestEventMsg(t *testing.T) {
+	now := time.Now()
+	require.Equal(t, StepStartMsg{ID: "a", Time: now}, EventMsg(engine.Event{Type: engine.StepStarted, StepID: "a", Time: now}))
+	require.Equal(t, StepOutputMsg{ID: "a", Stream: "stderr", Line: "boom"}, EventMsg(engine.Event{Type: engine.StepOutput, StepID: "a", Stream: "stderr", Line: "boom"}))
+	require.Nil(t, EventMsg(engine.Event{Type: engine.StepQueued, StepID: "a"}))
+}
+
 func TestUpdateHandlesValidationMessages(t *testing.T) {
 	m := NewModel(&config.Config{}, &engine.ExecutionPlan{}, false)
 	msg := ValidationMsg{Passed: false, Message: "missing path"}
@@ -37,10 +56,24 @@ func TestUpdateHandlesValidationMessages(t *testing.T) {
 	require.False(t, m.validations[0].Passed)
 }
 
+func TestUpdateHandlesHandlerResults(t *testing.T) {
+	m := NewModel(&config.Config{}, &engine.ExecutionPlan{Levels: []engine.ExecutionLevel{{StepIDs: []string{"step"}}}}, false)
+	updated, _ := m.Update(HandlerCompleteMsg{Result: model.StepResult{StepID: "restart", Status: model.StatusSuccess, Message: "ok"}})
+	m = updated.(Model)
+	require.Len(t, m.handlers, 1)
+	require.Equal(t, 1, m.TotalSteps(), "handlers are not counted as steps")
+	require.Contains(t, m.View(), "✓ restart — ok")
+}
+
 func TestUpdateHandlesTeaMessages(t *testing.T) {
 	m := NewModel(&config.Config{}, &engine.ExecutionPlan{}, false)
 	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
 	require.Nil(t, cmd)
 	m = updated.(Model)
 	require.True(t, m.cancelled)
+
+	calls := 0
+	m = NewModel(&config.Config{}, &engine.ExecutionPlan{}, false).WithCancel(func() { calls++ })
+	_, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	require.Equal(t, 1, calls)
 }
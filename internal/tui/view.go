@@ -27,12 +27,24 @@ func (m Model) View() string {
 		sections = append(sections, sectionStyle.Render("Steps"))
 		sections = append(sections, renderStepEntries(entries))
 	}
+	if len(m.filtered) > 0 {
+		lines := make([]string, 0, len(m.filtered))
+		for _, id := range m.filtered {
+			lines = append(lines, fmt.Sprintf(" %s %s — filtered out", pendingStyle.Render("-"), id))
+		}
+		sections = append(sections, sectionStyle.Render(fmt.Sprintf("Filtered out (%d)", len(m.filtered))), skippedStyle.Render(strings.Join(lines, "\n")))
+	}
+
+	if logs := m.activeLogs(); len(logs) > 0 {
+		sections = append(sections, sectionStyle.Render(fmt.Sprintf("Logs • %s", m.logStep)), logStyle.Render(strings.Join(logs, "\n")))
+	}
 
 	summary := components.NewSummary(components.SummaryData{
 		Total:       m.total,
 		Completed:   m.completed,
 		Finished:    m.finished,
 		Cancelled:   m.cancelled,
+		Handlers:    m.handlers,
 		Validations: m.validations,
 	}).View()
 	if strings.TrimSpace(summary) != "" {
@@ -48,9 +60,14 @@ func renderStepEntries(entries []components.StepEntry) string {
 		res := entry.Result
 		icon := StatusIcon(res.Status)
 		line := fmt.Sprintf(" %s %s", icon, entry.ID)
-		if strings.TrimSpace(res.Message) != "" {
+		if res.SkipReason != "" {
+			line = fmt.Sprintf("%s — skipped (%s)", line, res.SkipReason)
+		} else if strings.TrimSpace(res.Message) != "" {
 			line = fmt.Sprintf("%s — %s", line, res.Message)
 		}
+		if attempts := res.AttemptCount(); attempts > 1 {
+			line = fmt.Sprintf("%s [%d attempts]", line, attempts)
+		}
 		if res.Duration > 0 {
 			line = fmt.Sprintf("%s (%s)", line, res.Duration.Truncate(10*time.Millisecond))
 		}
@@ -59,6 +76,14 @@ func renderStepEntries(entries []components.StepEntry) string {
 	return strings.Join(lines, "\n")
 }
 
+// activeLogs returns the recent output of the step that last wrote output while it is still running.
+func (m Model) activeLogs() []string {
+	if m.logStep == "" || m.steps[m.logStep].Status != model.StatusRunning {
+		return nil
+	}
+	return m.logs[m.logStep]
+}
+
 func (m Model) title() string {
 	if m.cfg != nil && strings.TrimSpace(m.cfg.Name) != "" {
 		return m.cfg.Name
@@ -75,6 +100,8 @@ func StatusIcon(status string) string {
 		return runningStyle.Render("⏳")
 	case model.StatusFailed:
 		return failureStyle.Render("✗")
+	case model.StatusFailedIgnored:
+		return warningStyle.Render("!")
 	case model.StatusSkipped:
 		return skippedStyle.Render("⊘")
 	case model.StatusWouldCreate:
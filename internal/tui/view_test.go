@@ -24,6 +24,47 @@ func TestViewRendersBasicLayout(t *testing.T) {
 	require.Contains(t, view, "done")
 }
 
+func TestViewShowsAttemptCount(t *testing.T) {
+	plan := &engine.ExecutionPlan{Levels: []engine.ExecutionLevel{{StepIDs: []string{"flaky", "steady"}}}}
+	m := NewModel(&config.Config{Name: "Retries"}, plan, false)
+	m.steps["flaky"] = model.StepResult{StepID: "flaky", Status: model.StatusSuccess, Message: "done", Attempts: make([]model.Attempt, 3)}
+	m.steps["steady"] = model.StepResult{StepID: "steady", Status: model.StatusSuccess, Message: "done", Attempts: make([]model.Attempt, 1)}
+
+	view := m.View()
+	require.Contains(t, view, "flaky — done [3 attempts]")
+	require.NotContains(t, view, "[1 attempts]")
+}
+
+func TestViewShowsLogsForRunningStep(t *testing.T) {
+	plan := &engine.ExecutionPlan{Levels: []engine.ExecutionLevel{{StepIDs: []string{"build"}}}}
+	m := NewModel(&config.Config{Name: "Logs"}, plan, false)
+	m.steps["build"] = model.StepResult{StepID: "build", Status: model.StatusRunning}
+	m.appendLog("build", "compiling main.go")
+
+	view := m.View()
+	require.Contains(t, view, "Logs • build")
+	require.Contains(t, view, "compiling main.go")
+
+	m.steps["build"] = model.StepResult{StepID: "build", Status: model.StatusSuccess}
+	require.NotContains(t, m.View(), "compiling main.go")
+}
+
+func TestViewShowsFilteredSteps(t *testing.T) {
+	cfg := &config.Config{Name: "Selective", Steps: []config.Step{
+		{ID: "dotfiles", Enabled: true},
+		{ID: "packages", Enabled: true},
+		{ID: "disabled", Enabled: false},
+	}}
+	plan := &engine.ExecutionPlan{Levels: []engine.ExecutionLevel{{StepIDs: []string{"dotfiles"}}}}
+	m := NewModel(cfg, plan, false)
+
+	require.Equal(t, 1, m.TotalSteps())
+	view := m.View()
+	require.Contains(t, view, "Filtered out (1)")
+	require.Contains(t, view, "packages — filtered out")
+	require.NotContains(t, view, "disabled")
+}
+
 func TestViewShowsSummaryWhenFinished(t *testing.T) {
 	m := NewModel(&config.Config{Name: "Finished"}, &engine.ExecutionPlan{}, false)
 	m.finished = true
@@ -0,0 +1,168 @@
+//go:build linux
+
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE |
+	unix.IN_ATTRIB | unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+// inotify watches directories with the kernel's inotify API. Files are watched through their
+// parent directory, so editors that save by renaming a new file into place are still seen, and
+// a target that does not exist yet is watched through its nearest existing ancestor.
+type inotify struct {
+	notify func(string)
+	file   *os.File
+	fd     int
+
+	mu      sync.Mutex
+	targets []string
+	dirs    map[int]string
+	wds     map[string]int
+}
+
+func newNative(notify func(string)) (backend, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	// A non-blocking descriptor goes through the runtime poller, so Close interrupts the reader.
+	in := &inotify{
+		notify: notify,
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		fd:     fd,
+		dirs:   make(map[int]string),
+		wds:    make(map[string]int),
+	}
+	go in.read()
+	return in, nil
+}
+
+func (in *inotify) add(path string) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.targets = append(in.targets, path)
+	return in.watchTarget(path)
+}
+
+func (in *inotify) close() error {
+	return in.file.Close()
+}
+
+// watchTarget watches the directory holding path, or its nearest existing ancestor, and the
+// whole tree below path when it is a directory.
+func (in *inotify) watchTarget(path string) error {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		if err := in.watchTree(path); err != nil {
+			return err
+		}
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return in.watchDir(dir)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return nil
+		}
+	}
+}
+
+func (in *inotify) watchTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Entries can vanish mid-walk; their parent's watch reports the removal.
+			return nil
+		}
+		if d.IsDir() || path == root {
+			return in.watchDir(path)
+		}
+		return nil
+	})
+}
+
+func (in *inotify) watchDir(dir string) error {
+	wd, err := unix.InotifyAddWatch(in.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	in.dirs[wd] = dir
+	in.wds[dir] = wd
+	return nil
+}
+
+func (in *inotify) read() {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := in.file.Read(buf)
+		if err != nil {
+			// Closed by close, or broken; either way no more events will come.
+			return
+		}
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + unix.SizeofInotifyEvent
+			nameEnd := nameStart + int(event.Len)
+			if nameEnd > n {
+				break
+			}
+			name := string(buf[nameStart:nameEnd])
+			for len(name) > 0 && name[len(name)-1] == 0 {
+				name = name[:len(name)-1]
+			}
+			offset = nameEnd
+
+			for _, path := range in.handle(int(event.Wd), event.Mask, name) {
+				in.notify(path)
+			}
+		}
+	}
+}
+
+// handle keeps the watches in step with the event and returns the paths it concerns.
+func (in *inotify) handle(wd int, mask uint32, name string) []string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	dir, ok := in.dirs[wd]
+	if !ok {
+		return nil
+	}
+	if mask&unix.IN_IGNORED != 0 {
+		delete(in.dirs, wd)
+		if in.wds[dir] == wd {
+			delete(in.wds, dir)
+		}
+		return nil
+	}
+	if name == "" {
+		return []string{dir}
+	}
+
+	path := filepath.Join(dir, name)
+	paths := []string{path}
+	if mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 && mask&unix.IN_ISDIR != 0 {
+		for _, target := range in.targets {
+			switch {
+			case within(path, target):
+				// A new directory inside a watched tree.
+				_ = in.watchTree(path)
+			case within(target, path):
+				// A directory on the way to a target that did not exist yet. The target may have
+				// been created before the new directory was watched, so report it if it is there.
+				_ = in.watchTarget(target)
+				if _, err := os.Lstat(target); err == nil {
+					paths = append(paths, target)
+				}
+			}
+		}
+	}
+	return paths
+}
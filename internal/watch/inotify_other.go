@@ -0,0 +1,10 @@
+//go:build !linux
+
+package watch
+
+import "errors"
+
+// newNative reports that no native backend exists here, so watchers poll.
+func newNative(func(string)) (backend, error) {
+	return nil, errors.New("native file watching is not supported on this platform")
+}
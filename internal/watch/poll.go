@@ -0,0 +1,119 @@
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileState is what polling compares between two scans of a path.
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    fs.FileMode
+}
+
+// poller finds changes by scanning its targets at a fixed interval.
+type poller struct {
+	notify   func(string)
+	interval time.Duration
+
+	mu      sync.Mutex
+	targets []string
+	last    map[string]fileState
+
+	done chan struct{}
+	once sync.Once
+}
+
+func newPoller(notify func(string), interval time.Duration) *poller {
+	p := &poller{
+		notify:   notify,
+		interval: interval,
+		last:     make(map[string]fileState),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *poller) add(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.targets = append(p.targets, path)
+	scan(path, p.last)
+	return nil
+}
+
+func (p *poller) close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+func (p *poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			for _, path := range p.poll() {
+				p.notify(path)
+			}
+		}
+	}
+}
+
+// poll rescans every target and returns the paths that appeared, disappeared or changed.
+func (p *poller) poll() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := make(map[string]fileState, len(p.last))
+	for _, target := range p.targets {
+		scan(target, current)
+	}
+
+	var changed []string
+	for path, state := range current {
+		if prev, ok := p.last[path]; !ok || prev != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range p.last {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	p.last = current
+	return changed
+}
+
+// scan records the state of path and, for a directory, of everything below it. Missing paths
+// are simply left out.
+func scan(path string, into map[string]fileState) {
+	// Follow a symlinked file so edits to what it points at are seen.
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		into[path] = fileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+		return
+	}
+	_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		// A directory's own timestamp moves with every entry change, which its entries already report.
+		if info.IsDir() {
+			into[p] = fileState{mode: info.Mode()}
+			return nil
+		}
+		into[p] = fileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+		return nil
+	})
+}
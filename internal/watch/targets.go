@@ -0,0 +1,127 @@
+package watch
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/alexisbeaulieu97/streamy/internal/checkpoint"
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+)
+
+// sourceStepTypes are the step types whose outcome depends on the content of a source path.
+var sourceStepTypes = map[string]bool{"copy": true, "template": true, "symlink": true}
+
+// Sources maps every source path read by the pipeline's copy, template and symlink steps to the
+// IDs of those steps. Relative sources are resolved against the working directory, as the
+// plugins resolve them.
+func Sources(cfg *config.Config) map[string][]string {
+	sources := make(map[string][]string)
+	if cfg == nil {
+		return sources
+	}
+	for i := range cfg.Steps {
+		step := &cfg.Steps[i]
+		if !sourceStepTypes[step.Type] {
+			continue
+		}
+		var src struct {
+			Source string `yaml:"source"`
+		}
+		if err := step.DecodeConfig(&src); err != nil || src.Source == "" {
+			continue
+		}
+		abs, err := filepath.Abs(src.Source)
+		if err != nil {
+			continue
+		}
+		sources[abs] = append(sources[abs], step.ID)
+	}
+	return sources
+}
+
+// Paths lists what to watch for cfg: the config file and its includes, then every step source.
+func Paths(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, path := range cfg.SourceFiles() {
+		if abs, err := filepath.Abs(path); err == nil && !seen[abs] {
+			seen[abs] = true
+			paths = append(paths, abs)
+		}
+	}
+	sources := Sources(cfg)
+	for _, path := range sortedKeys(toSet(sources)) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// ChangedSteps returns the sorted IDs of the steps in next that must run again after the changed
+// paths were modified: steps that are new or whose definition differs from prev, and steps whose
+// source is one of the paths, lies inside one, or contains one.
+func ChangedSteps(prev, next *config.Config, changed []string) []string {
+	ids := make(map[string]bool)
+
+	previous := make(map[string]string)
+	if prev != nil {
+		for i := range prev.Steps {
+			previous[prev.Steps[i].ID] = prev.Steps[i].Hash()
+		}
+	}
+	for i := range next.Steps {
+		step := &next.Steps[i]
+		if hash, ok := previous[step.ID]; !ok || hash != step.Hash() {
+			ids[step.ID] = true
+		}
+	}
+
+	for source, steps := range Sources(next) {
+		for _, path := range changed {
+			if within(path, source) || within(source, path) {
+				for _, id := range steps {
+					ids[id] = true
+				}
+				break
+			}
+		}
+	}
+
+	out := make([]string, 0, len(ids))
+	for id := range ids {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Carried picks the results a re-run planned by plan carries over from earlier runs: those of the
+// planned steps outside rerun, which the selection keeps only because steps that run read their
+// registered outputs. Steps whose last result did not complete run again.
+func Carried(plan *engine.ExecutionPlan, rerun []string, last map[string]model.StepResult) map[string]model.StepResult {
+	running := make(map[string]bool, len(rerun))
+	for _, id := range rerun {
+		running[id] = true
+	}
+	carried := make(map[string]model.StepResult)
+	for _, level := range plan.Levels {
+		for _, id := range level.StepIDs {
+			if res, ok := last[id]; ok && !running[id] && checkpoint.Completed(res) {
+				carried[id] = res
+			}
+		}
+	}
+	return carried
+}
+
+func toSet(m map[string][]string) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for key := range m {
+		set[key] = true
+	}
+	return set
+}
@@ -0,0 +1,157 @@
+package watch
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexisbeaulieu97/streamy/internal/config"
+	"github.com/alexisbeaulieu97/streamy/internal/domain/pipeline"
+	"github.com/alexisbeaulieu97/streamy/internal/engine"
+	"github.com/alexisbeaulieu97/streamy/internal/logger"
+	"github.com/alexisbeaulieu97/streamy/internal/model"
+	"github.com/alexisbeaulieu97/streamy/internal/plugin"
+	commandplugin "github.com/alexisbeaulieu97/streamy/internal/plugins/command"
+)
+
+func writeFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestPathsAndChangedSteps(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	zshrc := writeFile(t, dir, "dotfiles/zshrc", "export A=1\n")
+	nvim := filepath.Join(dir, "dotfiles", "nvim")
+	writeFile(t, dir, "dotfiles/nvim/init.lua", "")
+	include := writeFile(t, dir, "shell.yaml", `steps:
+  - id: zsh
+    type: symlink
+    source: `+zshrc+`
+    target: `+filepath.Join(dir, "home", ".zshrc")+`
+`)
+	cfgPath := writeFile(t, dir, "streamy.yaml", `version: "1.0"
+name: dotfiles
+include:
+  - path: shell.yaml
+steps:
+  - id: nvim
+    type: copy
+    source: `+nvim+`
+    destination: `+filepath.Join(dir, "home", ".config", "nvim")+`
+    recursive: true
+  - id: motd
+    type: command
+    command: echo hi
+`)
+
+	cfg, err := config.ParseConfig(cfgPath)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{cfgPath, include, nvim, zshrc}, Paths(cfg))
+	require.Equal(t, map[string][]string{zshrc: {"shell/zsh"}, nvim: {"nvim"}}, Sources(cfg))
+
+	require.Equal(t, []string{"shell/zsh"}, ChangedSteps(cfg, cfg, []string{zshrc}))
+	require.Equal(t, []string{"nvim"}, ChangedSteps(cfg, cfg, []string{filepath.Join(nvim, "init.lua")}))
+	require.Empty(t, ChangedSteps(cfg, cfg, []string{cfgPath}))
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`version: "1.0"
+name: dotfiles
+include:
+  - path: shell.yaml
+steps:
+  - id: nvim
+    type: copy
+    source: `+nvim+`
+    destination: `+filepath.Join(dir, "home", ".config", "nvim")+`
+    recursive: true
+  - id: motd
+    type: command
+    command: echo hello
+`), 0o600))
+	edited, err := config.ParseConfig(cfgPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{"motd"}, ChangedSteps(cfg, edited, []string{cfgPath}))
+}
+
+func TestCarried_ReRunReadsRegisteredOutputs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs.log")
+	handled := filepath.Join(dir, "handled.log")
+	out := filepath.Join(dir, "tool.txt")
+	pipelineConfig := func(format string) string {
+		return `version: "1.0"
+name: tools
+steps:
+  - id: version
+    type: command
+    command: "echo run >> ` + runs + `; printf 1.2.3"
+    register: ver
+    notify: [announce]
+  - id: render
+    type: command
+    depends_on: [version]
+    command: "printf '` + format + `' > ` + out + `"
+handlers:
+  - id: announce
+    type: command
+    command: "echo announce >> ` + handled + `"
+`
+	}
+	cfgPath := writeFile(t, dir, "streamy.yaml", pipelineConfig("tool@${outputs.ver.stdout}"))
+
+	log, err := logger.New(logger.Options{Writer: io.Discard})
+	require.NoError(t, err)
+	registry := plugin.NewPluginRegistry(plugin.DefaultConfig(), log)
+	require.NoError(t, registry.Register(commandplugin.New()))
+	service := pipeline.NewService(registry)
+
+	prepared, err := service.Prepare(cfgPath)
+	require.NoError(t, err)
+	outcome, err := service.Apply(context.Background(), pipeline.ApplyRequest{Prepared: prepared, Logger: log})
+	require.NoError(t, err)
+	last := make(map[string]model.StepResult)
+	for _, res := range outcome.Results {
+		last[res.StepID] = res
+	}
+
+	// Editing the consumer re-runs it alone, with the producer kept for its output.
+	require.NoError(t, os.WriteFile(cfgPath, []byte(pipelineConfig("v${outputs.ver.stdout}")), 0o600))
+	next, err := service.Prepare(cfgPath)
+	require.NoError(t, err)
+	steps := next.Graph.Downstream(ChangedSteps(prepared.Config, next.Config, []string{cfgPath})...)
+	require.Equal(t, []string{"render"}, steps)
+	selected, err := service.PrepareWithOptions(cfgPath, pipeline.PrepareOptions{
+		Selection: engine.Selection{Only: steps, NoDeps: true},
+	})
+	require.NoError(t, err)
+
+	carried := Carried(selected.Plan, steps, last)
+	require.Contains(t, carried, "version")
+	require.Len(t, carried, 1)
+	require.Empty(t, Carried(selected.Plan, steps, nil))
+
+	_, err = service.Apply(context.Background(), pipeline.ApplyRequest{Prepared: selected, Logger: log, Carry: carried})
+	require.NoError(t, err)
+	rendered, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "v1.2.3", string(rendered))
+	producerRuns, err := os.ReadFile(runs)
+	require.NoError(t, err)
+	require.Equal(t, "run\n", string(producerRuns), "the producer is not run again")
+	announced, err := os.ReadFile(handled)
+	require.NoError(t, err)
+	require.Equal(t, "announce\n", string(announced), "a carried producer does not notify its handlers again")
+}
@@ -0,0 +1,201 @@
+// Package watch reports changes to the files a pipeline reads. It uses inotify on Linux and falls
+// back to polling elsewhere or when inotify is unavailable, and debounces bursts of edits into a
+// single batch of changed paths.
+package watch
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDebounce     = 300 * time.Millisecond
+	defaultPollInterval = time.Second
+)
+
+// Options customises a Watcher.
+type Options struct {
+	// Debounce is how long the paths must stay quiet before a batch of changes is reported; 300ms
+	// when zero.
+	Debounce time.Duration
+	// PollInterval is how often paths are checked when polling; one second when zero.
+	PollInterval time.Duration
+	// Poll forces polling even where inotify is available.
+	Poll bool
+}
+
+// backend delivers raw change notifications for the paths it was asked to watch.
+type backend interface {
+	// add starts watching path: a file, or a directory and everything below it. The path does not
+	// have to exist yet.
+	add(path string) error
+	close() error
+}
+
+// Watcher reports debounced batches of changes to a set of files and directories.
+type Watcher struct {
+	targets []string
+	backend backend
+	mode    string
+
+	raw     chan string
+	changes chan []string
+	done    chan struct{}
+	once    sync.Once
+}
+
+// New watches paths, each a file or a directory tree. Paths are made absolute; they do not have
+// to exist yet, so a file created later is reported too.
+func New(paths []string, opts Options) (*Watcher, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = defaultDebounce
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+
+	w := &Watcher{
+		raw:     make(chan string, 64),
+		changes: make(chan []string),
+		done:    make(chan struct{}),
+	}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		w.targets = append(w.targets, abs)
+	}
+
+	if err := w.start(opts); err != nil {
+		return nil, err
+	}
+	go w.debounce(opts.Debounce)
+	return w, nil
+}
+
+// start picks the backend: inotify when possible, polling otherwise.
+func (w *Watcher) start(opts Options) error {
+	if !opts.Poll {
+		if native, err := newNative(w.notify); err == nil {
+			if err := w.addAll(native); err == nil {
+				w.backend, w.mode = native, "inotify"
+				return nil
+			}
+			_ = native.close()
+		}
+	}
+
+	poll := newPoller(w.notify, opts.PollInterval)
+	if err := w.addAll(poll); err != nil {
+		_ = poll.close()
+		return err
+	}
+	w.backend, w.mode = poll, "poll"
+	return nil
+}
+
+func (w *Watcher) addAll(b backend) error {
+	for _, target := range w.targets {
+		if err := b.add(target); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// Mode names the backend in use: "inotify" or "poll".
+func (w *Watcher) Mode() string {
+	return w.mode
+}
+
+// Changes delivers the sorted paths changed in each burst of edits. Bursts that happen while the
+// previous batch has not been received yet are merged into the next one.
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// Close stops watching and closes the Changes channel.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.backend.close()
+	})
+	return err
+}
+
+// notify is called by the backend for every raw change.
+func (w *Watcher) notify(path string) {
+	if !w.relevant(path) {
+		return
+	}
+	select {
+	case w.raw <- path:
+	case <-w.done:
+	}
+}
+
+// relevant reports whether path is a target, lies inside a target directory, or is a directory
+// on the way to a target, whose creation or removal changes the target too.
+func (w *Watcher) relevant(path string) bool {
+	for _, target := range w.targets {
+		if within(path, target) || within(target, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) debounce(quiet time.Duration) {
+	defer close(w.changes)
+
+	pending := make(map[string]bool)
+	ready := make(map[string]bool)
+	var timer <-chan time.Time
+	var out chan []string
+	var batch []string
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case path := <-w.raw:
+			pending[path] = true
+			timer = time.After(quiet)
+		case <-timer:
+			timer = nil
+			for path := range pending {
+				ready[path] = true
+			}
+			clear(pending)
+			batch = sortedKeys(ready)
+			out = w.changes
+		case out <- batch:
+			clear(ready)
+			batch, out = nil, nil
+		}
+	}
+}
+
+// within reports whether path is dir or lies below it.
+func within(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
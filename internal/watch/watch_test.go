@@ -0,0 +1,123 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func waitForChanges(t *testing.T, w *Watcher) []string {
+	t.Helper()
+
+	select {
+	case changes := <-w.Changes():
+		return changes
+	case <-time.After(5 * time.Second):
+		t.Fatal("no changes reported")
+		return nil
+	}
+}
+
+func requireNoChanges(t *testing.T, w *Watcher, wait time.Duration) {
+	t.Helper()
+
+	select {
+	case changes := <-w.Changes():
+		t.Fatalf("unexpected changes: %v", changes)
+	case <-time.After(wait):
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+
+	modes := []struct {
+		name string
+		poll bool
+	}{{name: "poll", poll: true}}
+	if runtime.GOOS == "linux" {
+		modes = append(modes, struct {
+			name string
+			poll bool
+		}{name: "inotify"})
+	}
+
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			file := filepath.Join(dir, "zshrc")
+			tree := filepath.Join(dir, "nvim")
+			missing := filepath.Join(dir, "later", "gitconfig")
+			require.NoError(t, os.WriteFile(file, []byte("a"), 0o600))
+			require.NoError(t, os.MkdirAll(filepath.Join(tree, "lua"), 0o755))
+
+			w, err := New([]string{file, tree, missing}, Options{Debounce: 50 * time.Millisecond, PollInterval: 20 * time.Millisecond, Poll: mode.poll})
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = w.Close() })
+			require.Equal(t, mode.name, w.Mode())
+
+			// A burst of edits is reported once.
+			require.NoError(t, os.WriteFile(file, []byte("ab"), 0o600))
+			require.NoError(t, os.WriteFile(file, []byte("abc"), 0o600))
+			require.Contains(t, waitForChanges(t, w), file)
+			requireNoChanges(t, w, 150*time.Millisecond)
+
+			// Files nested in a watched directory are seen.
+			nested := filepath.Join(tree, "lua", "init.lua")
+			require.NoError(t, os.WriteFile(nested, []byte("x"), 0o600))
+			require.Contains(t, waitForChanges(t, w), nested)
+
+			// Files outside the targets are ignored.
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "unrelated"), []byte("x"), 0o600))
+			requireNoChanges(t, w, 150*time.Millisecond)
+
+			// A target is reported once it appears, even when its directory did not exist.
+			require.NoError(t, os.MkdirAll(filepath.Dir(missing), 0o755))
+			require.NoError(t, os.WriteFile(missing, []byte("x"), 0o600))
+			for found := false; !found; {
+				found = slices.Contains(waitForChanges(t, w), missing)
+			}
+		})
+	}
+}
+
+func TestWatcher_SavesByRename(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("a"), 0o600))
+
+	w, err := New([]string{file}, Options{Debounce: 50 * time.Millisecond, PollInterval: 20 * time.Millisecond})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	tmp := filepath.Join(dir, ".config.yaml.swp")
+	require.NoError(t, os.WriteFile(tmp, []byte("b"), 0o600))
+	require.NoError(t, os.Rename(tmp, file))
+
+	require.Equal(t, []string{file}, waitForChanges(t, w))
+}
+
+func TestWatcher_CloseEndsChanges(t *testing.T) {
+	t.Parallel()
+
+	w, err := New([]string{t.TempDir()}, Options{})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+
+	select {
+	case _, ok := <-w.Changes():
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("changes channel not closed")
+	}
+}